@@ -8,12 +8,18 @@ import (
 	"time"
 
 	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/naidog/wechat-framework/internal/api/message"
 	"github.com/naidog/wechat-framework/internal/api/plugin"
+	"github.com/naidog/wechat-framework/internal/api/webhook"
 	"github.com/naidog/wechat-framework/internal/api/wechat"
 	"github.com/naidog/wechat-framework/internal/config"
+	"github.com/naidog/wechat-framework/internal/core/access"
 	"github.com/naidog/wechat-framework/internal/core/account"
 	"github.com/naidog/wechat-framework/internal/core/callback"
+	messageCore "github.com/naidog/wechat-framework/internal/core/message"
 	pluginCore "github.com/naidog/wechat-framework/internal/core/plugin"
+	webhookCore "github.com/naidog/wechat-framework/internal/core/webhook"
+	"github.com/naidog/wechat-framework/internal/core/ws"
 	"github.com/naidog/wechat-framework/internal/server"
 	"github.com/naidog/wechat-framework/internal/service"
 	"github.com/naidog/wechat-framework/internal/utils"
@@ -53,24 +59,54 @@ func main() {
 	})
 
 	// 创建日志服务
-	logService := logger.NewService(app)
+	logService := logger.NewService(app.Event)
 	logger.SetGlobalLogService(logService)
 
 	// 创建账号管理器
-	accountManager := account.NewManager(app)
+	accountManager := account.NewManager(app.Event)
+
+	// 创建访问控制管理器
+	accessManager, err := access.NewManager(access.LoadConfig(ctx))
+	if err != nil {
+		log.Fatalf("访问控制初始化失败: %v", err)
+	}
 
 	// 创建插件管理器
-	pluginManager := pluginCore.NewManager(app, logService)
+	pluginManager := pluginCore.NewManager(service.NewPluginWindowHost(app), logService, accessManager)
+
+	// 创建回调校验器与回调处理器
+	callbackGuard, err := callback.NewGuard(callback.LoadGuardConfig(ctx), accountManager.GetAccounts)
+	if err != nil {
+		log.Fatalf("回调校验器创建失败: %v", err)
+	}
+	callbackHandler := callback.NewHandler(pluginManager, callbackGuard)
 
-	// 创建回调处理器
-	callbackHandler := callback.NewHandler(pluginManager)
+	// 打开消息存储，记录收到的消息
+	messageStore, err := messageCore.Open(messageCore.LoadStorePath(ctx))
+	if err != nil {
+		log.Fatalf("消息存储打开失败: %v", err)
+	}
+	defer messageStore.Close()
+	messageStore.Attach(callbackHandler.Events())
+
+	// 启动 webhook 投递
+	webhooks, err := webhookCore.New(webhookCore.LoadConfig(ctx))
+	if err != nil {
+		log.Fatalf("webhook 配置错误: %v", err)
+	}
+	webhooks.Attach(callbackHandler.Events())
+	webhooks.Start()
+	defer webhooks.Stop()
 
 	// 创建API服务
-	wechatProxy := wechat.NewProxy()
+	wechatProxy := wechat.NewProxy(messageStore, accountManager)
 	pluginAPI := plugin.NewAPI(pluginManager)
+	messageAPI := message.NewAPI(messageStore)
+	wsServer := ws.NewServer(callbackHandler.SSE(), wechatProxy)
+	webhookAPI := webhook.NewAPI(webhooks)
 
 	// 创建HTTP服务器
-	httpServer := server.NewHTTPServer(callbackHandler, wechatProxy, pluginAPI)
+	httpServer := server.NewHTTPServer(callbackHandler, wechatProxy, pluginAPI, messageAPI, wsServer, webhookAPI, accessManager)
 
 	// 创建Wails服务适配器
 	wailsConfigService := service.NewConfigService(configService)
@@ -79,6 +115,7 @@ func main() {
 	wailsAccountService := service.NewAccountService(accountManager)
 	wailsLogService := service.NewLogService(logService)
 	wailsPluginService := service.NewPluginService(pluginManager)
+	wailsAccessService := service.NewAccessService(accessManager)
 
 	// 注册Wails服务
 	app.RegisterService(application.NewService(wailsConfigService))
@@ -87,6 +124,7 @@ func main() {
 	app.RegisterService(application.NewService(wailsAccountService))
 	app.RegisterService(application.NewService(wailsLogService))
 	app.RegisterService(application.NewService(wailsPluginService))
+	app.RegisterService(application.NewService(wailsAccessService))
 
 	// 创建主窗口
 	app.Window.NewWithOptions(application.WebviewWindowOptions{
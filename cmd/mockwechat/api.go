@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// apiResponse DLL 接口响应格式
+type apiResponse struct {
+	Code   int         `json:"code"`
+	Msg    string      `json:"msg"`
+	Result interface{} `json:"result"`
+}
+
+// responder 按接口类型生成响应结果
+type responder func(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string)
+
+// responders 各接口的模拟响应，同时覆盖旧版与新版接口名称
+var responders = map[string]responder{
+	// 框架与登录
+	"getAuthInfo":    respAuthInfo,
+	"getLoginStatus": respLoginStatus,
+	"getLoginQRCode": respLoginQrCode,
+	"getLoginQrCode": respLoginQrCode,
+	"checkWeChat":    respLoginStatus,
+	"getSelfInfo":    respSelfInfo,
+	"getWechatVer":   respWechatVer,
+	"editVersion":    respWechatVer,
+	"changeVersion":  respWechatVer,
+	"logout":         respLogout,
+	"authCami":       respAuthInfo,
+	"getDbNames":     respDbNames,
+	"runCloudFunction": func(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+		return g.Map{"data": gconv.String(data["data"])}, ""
+	},
+
+	// 联系人与群聊
+	"getFriendList":     respFriendList,
+	"getGroupList":      respGroupList,
+	"getPublicList":     respPublicList,
+	"getLabelList":      respLabelList,
+	"getMemberList":     respMemberList,
+	"getGroupMembers":   respMemberList,
+	"getMemberNick":     respMemberNick,
+	"queryObj":          respQueryObj,
+	"queryNewFriend":    respQueryObj,
+	"getContactProfile": respQueryObj,
+	"searchFriend":      respQueryObj,
+	"queryGroup":        respQueryGroup,
+	"getGroupQrCode":    respGroupQrCode,
+	"createGroup":       respCreateGroup,
+	"delFriend":         respDelFriend,
+	"deleteFriend":      respDelFriend,
+	"quitGroup":         respQuitGroup,
+
+	// 图片与文件
+	"decryptImage":  respFilePath,
+	"downloadImage": respFilePath,
+	"downloadFile":  respFilePath,
+}
+
+// handleAPI 处理 /wechat/httpapi 请求，兼容嵌套 data 与平铺参数两种格式
+func (m *Mock) handleAPI(acc *Account) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if r.Method != http.MethodPost {
+			writeAPI(w, http.StatusMethodNotAllowed, apiResponse{Code: 405, Msg: "只支持POST请求"})
+			return
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeAPI(w, http.StatusOK, apiResponse{Code: 400, Msg: "请求参数错误"})
+			return
+		}
+		apiType := gconv.String(body["type"])
+		if apiType == "" {
+			writeAPI(w, http.StatusOK, apiResponse{Code: 400, Msg: "缺少type参数"})
+			return
+		}
+		data, ok := body["data"].(map[string]interface{})
+		if !ok {
+			data = make(map[string]interface{}, len(body))
+			for k, v := range body {
+				if k != "type" {
+					data[k] = v
+				}
+			}
+		}
+
+		m.record(acc.Port, apiType, data)
+		g.Log().Debugf(ctx, "[%d] %s %v", acc.Port, apiType, data)
+
+		if o, ok := m.override(apiType); ok {
+			writeOverride(ctx, w, o)
+			return
+		}
+
+		writeAPI(w, http.StatusOK, m.respond(acc, apiType, data))
+	}
+}
+
+// respond 生成接口响应
+func (m *Mock) respond(acc *Account, apiType string, data map[string]interface{}) apiResponse {
+	if fn, ok := responders[apiType]; ok {
+		result, errMsg := fn(m, acc, data)
+		if errMsg != "" {
+			return apiResponse{Code: 500, Msg: errMsg, Result: g.Map{}}
+		}
+		return apiResponse{Code: 200, Msg: "操作成功", Result: result}
+	}
+
+	// 发送类接口返回消息ID
+	if strings.HasPrefix(apiType, "send") || apiType == "forwardMsg" {
+		return apiResponse{Code: 200, Msg: "操作成功", Result: g.Map{
+			"msgId":  m.nextMsgId(),
+			"sendId": m.nextMsgId(),
+		}}
+	}
+
+	// 其余接口按操作成功处理
+	return apiResponse{Code: 200, Msg: "操作成功", Result: g.Map{}}
+}
+
+// writeOverride 按固定响应返回，可模拟超时与错误
+func writeOverride(ctx context.Context, w http.ResponseWriter, o Override) {
+	if o.Delay != "" {
+		if d, err := time.ParseDuration(o.Delay); err == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(d):
+			}
+		}
+	}
+	status := o.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	resp := apiResponse{Code: o.Code, Msg: o.Msg, Result: o.Result}
+	if resp.Code == 0 {
+		resp.Code = 200
+	}
+	if resp.Msg == "" {
+		resp.Msg = "操作成功"
+	}
+	if resp.Result == nil {
+		resp.Result = g.Map{}
+	}
+	writeAPI(w, status, resp)
+}
+
+// writeAPI 写入 JSON 响应
+func writeAPI(w http.ResponseWriter, status int, resp apiResponse) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func respAuthInfo(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	isExpire := 0
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", acc.ExpireTime, time.Local); err == nil && t.Before(time.Now()) {
+		isExpire = 1
+	}
+	return g.Map{"expireTime": acc.ExpireTime, "isExpire": isExpire}, ""
+}
+
+func respLoginStatus(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	return g.Map{"status": 1, "isLogin": 1, "wxid": acc.Wxid}, ""
+}
+
+func respLoginQrCode(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	return g.Map{"qrcode": "http://weixin.qq.com/x/mock-" + acc.Wxid}, ""
+}
+
+func respSelfInfo(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	return g.Map{
+		"wxid":      acc.Wxid,
+		"wxNum":     acc.WxNum,
+		"nick":      acc.Nick,
+		"avatarUrl": acc.AvatarUrl,
+		"device":    "iPhone",
+		"phone":     "13800000000",
+		"country":   "CN",
+		"province":  "Guangdong",
+		"city":      "Shenzhen",
+		"sign":      "",
+		"port":      acc.Port,
+		"pid":       acc.Pid,
+	}, ""
+}
+
+func respWechatVer(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	version := gconv.String(data["version"])
+	if version == "" {
+		version = "3.9.12.51"
+	}
+	return g.Map{"version": version}, ""
+}
+
+func respLogout(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	// 响应返回后再退出，模拟微信进程结束
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		m.Stop(context.Background(), acc)
+	}()
+	return g.Map{}, ""
+}
+
+func respDbNames(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	return []string{"MicroMsg.db", "ChatMsg.db", "Misc.db", "Emotion.db", "Media.db", "FunctionMsg.db"}, ""
+}
+
+func respFriendList(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return contactList(m.friends), ""
+}
+
+func respGroupList(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return contactList(m.groups), ""
+}
+
+func respPublicList(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return contactList(m.publics), ""
+}
+
+func respLabelList(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	return []g.Map{
+		{"labelId": "1", "labelName": "同事"},
+		{"labelId": "2", "labelName": "家人"},
+	}, ""
+}
+
+func respMemberList(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	groupWxid := firstString(data, "wxid", "groupWxid", "roomId")
+	if m.findContact(groupWxid) == nil {
+		return nil, "群聊不存在"
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	members := []g.Map{{"wxid": acc.Wxid, "nick": acc.Nick, "groupNick": acc.Nick}}
+	for _, f := range m.friends {
+		members = append(members, g.Map{"wxid": f.Wxid, "nick": f.Nick, "groupNick": f.Nick})
+	}
+	return members, ""
+}
+
+func respMemberNick(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	memberWxid := firstString(data, "objWxid", "memberWxid", "wxid")
+	if c := m.findContact(memberWxid); c != nil {
+		return g.Map{"wxid": c.Wxid, "groupNick": c.Nick}, ""
+	}
+	return g.Map{"wxid": memberWxid, "groupNick": ""}, ""
+}
+
+func respQueryObj(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	wxid := firstString(data, "wxid", "objWxid", "keyword")
+	if c := m.findContact(wxid); c != nil {
+		return contactMap(*c), ""
+	}
+	// 未知联系人按陌生人返回
+	return g.Map{
+		"wxid":  wxid,
+		"wxNum": "",
+		"nick":  "陌生人",
+		"v3":    "v3_mock_" + wxid + "@stranger",
+		"v4":    "v4_mock_" + wxid + "@stranger",
+		"sex":   0,
+	}, ""
+}
+
+func respQueryGroup(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	c := m.findContact(firstString(data, "wxid", "groupWxid"))
+	if c == nil {
+		return nil, "群聊不存在"
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := contactMap(*c)
+	result["owner"] = acc.Wxid
+	result["memberNum"] = len(m.friends) + 1
+	return result, ""
+}
+
+func respGroupQrCode(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	groupWxid := firstString(data, "wxid", "groupWxid")
+	return g.Map{"qrcode": "http://weixin.qq.com/g/mock-" + strings.TrimSuffix(groupWxid, "@chatroom")}, ""
+}
+
+func respCreateGroup(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group := Contact{
+		Wxid: m.nextMsgId() + "@chatroom",
+		Nick: "群聊" + time.Now().Format("150405"),
+	}
+	m.groups = append(m.groups, group)
+	return g.Map{"wxid": group.Wxid}, ""
+}
+
+func respDelFriend(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	wxid := firstString(data, "wxid", "objWxid")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed bool
+	m.friends, removed = removeContact(m.friends, wxid)
+	if !removed {
+		return nil, "好友不存在"
+	}
+	return g.Map{}, ""
+}
+
+func respQuitGroup(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	wxid := firstString(data, "wxid", "groupWxid")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed bool
+	m.groups, removed = removeContact(m.groups, wxid)
+	if !removed {
+		return nil, "群聊不存在"
+	}
+	return g.Map{}, ""
+}
+
+func respFilePath(m *Mock, acc *Account, data map[string]interface{}) (interface{}, string) {
+	path := firstString(data, "savePath", "dest", "path")
+	if path == "" {
+		path = "C:\\WeChat Files\\" + acc.Wxid + "\\FileStorage\\mock_" + m.nextMsgId() + ".dat"
+	}
+	return g.Map{"path": path}, ""
+}
+
+// findContact 在好友、群聊与公众号中查找联系人
+func (m *Mock) findContact(wxid string) *Contact {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, list := range [][]Contact{m.friends, m.groups, m.publics} {
+		for i := range list {
+			if list[i].Wxid == wxid {
+				c := list[i]
+				return &c
+			}
+		}
+	}
+	return nil
+}
+
+func contactMap(c Contact) g.Map {
+	return g.Map{
+		"wxid":   c.Wxid,
+		"wxNum":  c.WxNum,
+		"nick":   c.Nick,
+		"remark": c.Remark,
+	}
+}
+
+func contactList(list []Contact) []g.Map {
+	result := make([]g.Map, 0, len(list))
+	for _, c := range list {
+		result = append(result, contactMap(c))
+	}
+	return result
+}
+
+func removeContact(list []Contact, wxid string) ([]Contact, bool) {
+	for i, c := range list {
+		if c.Wxid == wxid {
+			return append(list[:i:i], list[i+1:]...), true
+		}
+	}
+	return list, false
+}
+
+// firstString 取第一个非空参数
+func firstString(data map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if v := gconv.String(data[k]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// Control 控制接口，用于在测试中触发事件、切换账号状态与查看调用记录
+type Control struct {
+	mock   *Mock
+	server *ghttp.Server
+}
+
+// NewControl 创建控制接口
+func NewControl(mock *Mock, address string) *Control {
+	s := g.Server("mockwechat-control")
+	s.SetAddr(address)
+	s.SetDumpRouterMap(false)
+
+	c := &Control{mock: mock, server: s}
+	s.Group("/mock", func(group *ghttp.RouterGroup) {
+		group.GET("/accounts", c.Accounts)
+		group.POST("/event", c.Event)
+		group.POST("/login", c.action(ActionLogin))
+		group.POST("/logout", c.action(ActionLogout))
+		group.POST("/scenario", c.Scenario)
+		group.GET("/calls", c.Calls)
+		group.DELETE("/calls", c.ClearCalls)
+		group.POST("/override", c.SetOverride)
+		group.DELETE("/override", c.RemoveOverride)
+	})
+	return c
+}
+
+// Start 启动控制接口
+func (c *Control) Start() error {
+	return c.server.Start()
+}
+
+// Stop 停止控制接口
+func (c *Control) Stop() error {
+	return c.server.Shutdown()
+}
+
+// Accounts 获取模拟账号列表
+func (c *Control) Accounts(r *ghttp.Request) {
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "success",
+		"data": c.mock.Accounts(),
+	})
+}
+
+// Event 发送回调事件
+// 请求体：{"account": "wxid或端口", "type": "recvMsg", "data": {...}}
+func (c *Control) Event(r *ghttp.Request) {
+	var req struct {
+		Account string                 `json:"account"`
+		Type    string                 `json:"type"`
+		Data    map[string]interface{} `json:"data"`
+	}
+	if err := r.Parse(&req); err != nil {
+		writeError(r, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+	if req.Type == "" {
+		writeError(r, http.StatusBadRequest, "缺少type参数")
+		return
+	}
+	c.do(r, req.Account, req.Type, req.Data)
+}
+
+// action 账号操作接口，请求体：{"account": "wxid或端口"}
+func (c *Control) action(action string) ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		c.do(r, r.Get("account").String(), action, nil)
+	}
+}
+
+// Scenario 异步执行场景步骤，请求体与场景文件的 steps 相同：{"steps": [...]}
+func (c *Control) Scenario(r *ghttp.Request) {
+	var req struct {
+		Steps []Step `json:"steps"`
+	}
+	if err := gconv.Struct(r.GetMap(), &req); err != nil {
+		writeError(r, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+	if len(req.Steps) == 0 {
+		writeError(r, http.StatusBadRequest, "缺少steps参数")
+		return
+	}
+
+	go func() {
+		ctx := gctx.New()
+		if err := c.mock.RunSteps(ctx, req.Steps); err != nil {
+			g.Log().Warningf(ctx, "场景执行失败: %v", err)
+		}
+	}()
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "场景已开始执行",
+	})
+}
+
+// Calls 获取 httpapi 调用记录，可按 port、type 过滤
+func (c *Control) Calls(r *ghttp.Request) {
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "success",
+		"data": c.mock.Calls(r.Get("port").Int(), r.Get("type").String()),
+	})
+}
+
+// ClearCalls 清空调用记录
+func (c *Control) ClearCalls(r *ghttp.Request) {
+	c.mock.ClearCalls()
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "已清空",
+	})
+}
+
+// SetOverride 设置接口固定响应，可模拟失败、超时与 HTTP 错误
+// 请求体：{"type": "sendText", "code": 500, "msg": "发送失败", "result": {}, "delay": "3s", "status": 200}
+func (c *Control) SetOverride(r *ghttp.Request) {
+	var o Override
+	if err := r.Parse(&o); err != nil {
+		writeError(r, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+	if o.Type == "" {
+		writeError(r, http.StatusBadRequest, "缺少type参数")
+		return
+	}
+	c.mock.SetOverride(o)
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "已设置",
+	})
+}
+
+// RemoveOverride 移除接口固定响应，不传 type 时全部移除
+func (c *Control) RemoveOverride(r *ghttp.Request) {
+	c.mock.RemoveOverride(r.Get("type").String())
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "已移除",
+	})
+}
+
+// do 对指定账号执行操作或发送事件
+func (c *Control) do(r *ghttp.Request, account, event string, data map[string]interface{}) {
+	acc := c.mock.Account(account)
+	if acc == nil {
+		writeError(r, http.StatusNotFound, "账号不存在: "+account)
+		return
+	}
+	if err := c.mock.Do(context.WithoutCancel(r.Context()), acc, event, data); err != nil {
+		writeError(r, http.StatusBadGateway, err.Error())
+		return
+	}
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "success",
+	})
+}
+
+// writeError 写入错误响应
+func writeError(r *ghttp.Request, status int, msg string) {
+	r.Response.WriteHeader(status)
+	r.Response.WriteJson(g.Map{
+		"code": status,
+		"msg":  msg,
+	})
+}
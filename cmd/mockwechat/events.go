@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// 账号操作，可在场景步骤与控制接口中代替事件类型使用
+const (
+	ActionLogin  = "login"  // 启动监听并依次发送注入成功、登录成功事件
+	ActionLogout = "logout" // 停止监听，模拟微信退出
+	ActionStart  = "start"  // 只启动监听
+	ActionStop   = "stop"   // 只停止监听
+)
+
+// EventRecvGroupMsg 群消息快捷类型，按群聊默认值生成数据，实际发送为 recvMsg
+const EventRecvGroupMsg = "recvGroupMsg"
+
+// eventDes 回调事件描述
+var eventDes = map[string]string{
+	types.EventInjectSuccess:      "注入成功",
+	types.EventLoginSuccess:       "登录成功",
+	types.EventRecvMsg:            "收到消息",
+	types.EventTransPay:           "转账事件",
+	types.EventFriendReq:          "好友请求",
+	types.EventGroupMemberChanges: "群成员变动",
+	types.EventAuthExpire:         "授权到期",
+}
+
+// Do 执行账号操作或发送回调事件
+func (m *Mock) Do(ctx context.Context, acc *Account, event string, data map[string]interface{}) error {
+	switch event {
+	case ActionLogin:
+		if err := m.Start(ctx, acc); err != nil {
+			return err
+		}
+		if err := m.Emit(ctx, acc, types.EventInjectSuccess, nil); err != nil {
+			return err
+		}
+		return m.Emit(ctx, acc, types.EventLoginSuccess, data)
+	case ActionLogout, ActionStop:
+		m.Stop(ctx, acc)
+		return nil
+	case ActionStart:
+		return m.Start(ctx, acc)
+	default:
+		return m.Emit(ctx, acc, event, data)
+	}
+}
+
+// Emit 向框架回调地址发送事件，data 中未填写的字段使用默认值
+func (m *Mock) Emit(ctx context.Context, acc *Account, eventType string, data map[string]interface{}) error {
+	if m.callback == "" {
+		return fmt.Errorf("未配置回调地址")
+	}
+
+	payload := m.defaultData(acc, eventType)
+	for k, v := range data {
+		payload[k] = v
+	}
+	if eventType == EventRecvGroupMsg {
+		eventType = types.EventRecvMsg
+	}
+
+	event := types.CallbackEvent{
+		Type:      eventType,
+		Des:       eventDes[eventType],
+		Data:      payload,
+		Timestamp: strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Wxid:      acc.Wxid,
+		Port:      acc.Port,
+		Pid:       acc.Pid,
+	}
+	// 注入成功时尚未登录，不携带 wxid
+	if eventType == types.EventInjectSuccess {
+		event.Wxid = ""
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化事件失败: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.callbackURL(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建回调请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送回调失败: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("回调返回 HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	g.Log().Infof(ctx, "[%d] 已发送 %s 事件", acc.Port, eventType)
+	return nil
+}
+
+// callbackURL 回调地址，配置了共享密钥时附带 token 参数
+func (m *Mock) callbackURL() string {
+	if m.secret == "" {
+		return m.callback
+	}
+	sep := "?"
+	if strings.Contains(m.callback, "?") {
+		sep = "&"
+	}
+	return m.callback + sep + "token=" + url.QueryEscape(m.secret)
+}
+
+// defaultData 生成事件默认数据
+func (m *Mock) defaultData(acc *Account, eventType string) map[string]interface{} {
+	now := time.Now()
+	m.mu.RLock()
+	friend, group := Contact{Wxid: "wxid_mock_friend1", Nick: "张三"}, Contact{Wxid: "10001000@chatroom"}
+	if len(m.friends) > 0 {
+		friend = m.friends[0]
+	}
+	if len(m.groups) > 0 {
+		group = m.groups[0]
+	}
+	memberCount := len(m.friends) + 1
+	m.mu.RUnlock()
+
+	switch eventType {
+	case types.EventInjectSuccess:
+		return g.Map{
+			"port": strconv.Itoa(acc.Port),
+			"pid":  strconv.Itoa(acc.Pid),
+		}
+	case types.EventLoginSuccess:
+		return g.Map{
+			"wxid":      acc.Wxid,
+			"wxNum":     acc.WxNum,
+			"nick":      acc.Nick,
+			"device":    "iPhone",
+			"phone":     "13800000000",
+			"avatarUrl": acc.AvatarUrl,
+			"country":   "CN",
+			"province":  "Guangdong",
+			"city":      "Shenzhen",
+			"email":     "",
+			"qq":        "0",
+			"sign":      "",
+		}
+	case types.EventRecvMsg:
+		return g.Map{
+			"timeStamp":     strconv.FormatInt(now.UnixMilli(), 10),
+			"fromType":      1,
+			"msgType":       1,
+			"msgSource":     0,
+			"fromWxid":      friend.Wxid,
+			"finalFromWxid": "",
+			"atWxidList":    []string{},
+			"silence":       0,
+			"membercount":   0,
+			"signature":     "",
+			"msg":           "你好",
+			"msgId":         m.nextMsgId(),
+			"sendId":        "",
+		}
+	case EventRecvGroupMsg:
+		return g.Map{
+			"timeStamp":     strconv.FormatInt(now.UnixMilli(), 10),
+			"fromType":      2,
+			"msgType":       1,
+			"msgSource":     0,
+			"fromWxid":      group.Wxid,
+			"finalFromWxid": friend.Wxid,
+			"atWxidList":    []string{},
+			"silence":       0,
+			"membercount":   memberCount,
+			"signature":     "",
+			"msg":           "大家好",
+			"msgId":         m.nextMsgId(),
+			"sendId":        "",
+		}
+	case types.EventTransPay:
+		return g.Map{
+			"fromWxid":      friend.Wxid,
+			"msgSource":     1,
+			"transType":     1,
+			"money":         "0.01",
+			"memo":          "",
+			"transferid":    "1000050001" + m.nextMsgId(),
+			"transcationid": "5300000" + m.nextMsgId(),
+			"invalidtime":   strconv.FormatInt(now.Add(24*time.Hour).Unix(), 10),
+			"msgId":         m.nextMsgId(),
+		}
+	case types.EventFriendReq:
+		wxid := "wxid_mock_stranger" + m.nextMsgId()
+		return g.Map{
+			"wxid":         wxid,
+			"wxNum":        "",
+			"nick":         "陌生人",
+			"nickBrief":    "MSR",
+			"nickWhole":    "moshengren",
+			"v3":           "v3_mock_" + wxid + "@stranger",
+			"v4":           "v4_mock_" + wxid + "@stranger",
+			"sign":         "",
+			"country":      "CN",
+			"province":     "Guangdong",
+			"city":         "Shenzhen",
+			"avatarMinUrl": "",
+			"avatarMaxUrl": "",
+			"sex":          "0",
+			"content":      "我是陌生人",
+			"scene":        "30",
+			"shareWxid":    "",
+			"shareNick":    "",
+			"groupWxid":    "",
+			"msgId":        m.nextMsgId(),
+		}
+	case types.EventGroupMemberChanges:
+		return g.Map{
+			"timeStamp":     strconv.FormatInt(now.UnixMilli(), 10),
+			"fromWxid":      group.Wxid,
+			"finalFromWxid": friend.Wxid,
+			"eventType":     1,
+			"inviterWxid":   acc.Wxid,
+		}
+	case types.EventAuthExpire:
+		return g.Map{
+			"wxid":       acc.Wxid,
+			"wxNum":      acc.WxNum,
+			"expireTime": acc.ExpireTime,
+			"msg":        "授权已到期",
+		}
+	default:
+		return g.Map{}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+)
+
+// 模拟微信：在本地端口上实现 DLL 的 /wechat/httpapi 接口，并向框架回调地址发送事件，
+// 用于在没有真实微信客户端的环境下开发和测试框架、插件与 webhook。
+func main() {
+	scenarioPath := flag.String("scenario", "", "场景文件（YAML），不指定时模拟一个账号并自动登录")
+	callbackURL := flag.String("callback", "", "框架回调地址，默认 http://127.0.0.1:9001/wechat/callback")
+	secret := flag.String("secret", "", "回调共享密钥，默认读取 resources/callback.secret")
+	controlAddr := flag.String("control", "127.0.0.1:19099", "控制接口监听地址，为空时不启动")
+	port := flag.Int("port", 19088, "未指定场景文件时模拟账号的端口")
+	wxid := flag.String("wxid", "wxid_mock_0001", "未指定场景文件时模拟账号的wxid")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(gctx.New())
+	defer cancel()
+
+	sc := defaultScenario(*port, *wxid)
+	if *scenarioPath != "" {
+		loaded, err := LoadScenario(*scenarioPath)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		sc = loaded
+	}
+	if len(sc.Accounts) == 0 {
+		log.Fatalf("场景中没有账号")
+	}
+
+	// 命令行参数优先于场景文件
+	if *callbackURL != "" {
+		sc.Callback = *callbackURL
+	}
+	if sc.Callback == "" {
+		sc.Callback = "http://127.0.0.1:9001/wechat/callback"
+	}
+	if *secret != "" {
+		sc.Secret = *secret
+	}
+	if sc.Secret == "" {
+		if content, err := os.ReadFile("resources/callback.secret"); err == nil {
+			sc.Secret = strings.TrimSpace(string(content))
+		}
+	}
+
+	mock := NewMock(sc)
+
+	if *controlAddr != "" {
+		control := NewControl(mock, *controlAddr)
+		if err := control.Start(); err != nil {
+			log.Fatalf("控制接口启动失败: %v", err)
+		}
+		defer control.Stop()
+		g.Log().Infof(ctx, "控制接口已启动: http://%s/mock", *controlAddr)
+	}
+
+	// 执行场景步骤，账号的监听由 login/start 步骤启动
+	go func() {
+		if err := mock.RunSteps(ctx, sc.Steps); err != nil && ctx.Err() == nil {
+			g.Log().Errorf(ctx, "场景执行失败: %v", err)
+		}
+	}()
+
+	// 等待退出信号
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	cancel()
+	mock.StopAll(gctx.New())
+}
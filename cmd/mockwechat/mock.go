@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// Call 一次 httpapi 调用记录
+type Call struct {
+	Time int64                  `json:"time"` // 调用时间（毫秒）
+	Port int                    `json:"port"` // 账号端口
+	Type string                 `json:"type"` // 接口类型
+	Data map[string]interface{} `json:"data"` // 请求参数
+}
+
+// Override 指定接口的固定响应
+type Override struct {
+	Type   string      `json:"type"`   // 接口类型
+	Code   int         `json:"code"`   // 响应 code，默认 200
+	Msg    string      `json:"msg"`    // 响应 msg
+	Result interface{} `json:"result"` // 响应 result
+	Delay  string      `json:"delay"`  // 响应前等待，如 3s
+	Status int         `json:"status"` // HTTP 状态码，默认 200
+}
+
+// Mock 模拟微信，管理账号端口、联系人与调用记录
+type Mock struct {
+	callback string
+	secret   string
+	client   *http.Client
+
+	mu        sync.RWMutex
+	accounts  []*Account
+	servers   map[int]*http.Server
+	friends   []Contact
+	groups    []Contact
+	publics   []Contact
+	calls     []Call
+	overrides map[string]Override
+
+	msgSeq atomic.Int64
+}
+
+// maxCalls 保留的调用记录数
+const maxCalls = 1000
+
+// NewMock 按场景创建模拟微信
+func NewMock(sc *Scenario) *Mock {
+	m := &Mock{
+		callback:  sc.Callback,
+		secret:    sc.Secret,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		accounts:  sc.Accounts,
+		servers:   make(map[int]*http.Server),
+		friends:   sc.Friends,
+		groups:    sc.Groups,
+		publics:   sc.Publics,
+		overrides: make(map[string]Override),
+	}
+	m.msgSeq.Store(time.Now().UnixNano() / int64(time.Millisecond))
+
+	if len(m.friends) == 0 {
+		m.friends = []Contact{
+			{Wxid: "wxid_mock_friend1", WxNum: "zhangsan", Nick: "张三"},
+			{Wxid: "wxid_mock_friend2", WxNum: "lisi", Nick: "李四", Remark: "老李"},
+		}
+	}
+	if len(m.groups) == 0 {
+		m.groups = []Contact{
+			{Wxid: "10001000@chatroom", Nick: "测试群"},
+		}
+	}
+	if len(m.publics) == 0 {
+		m.publics = []Contact{
+			{Wxid: "gh_mock_public", WxNum: "mockpublic", Nick: "测试公众号"},
+		}
+	}
+
+	for i, acc := range m.accounts {
+		if acc.Port == 0 {
+			acc.Port = 19088 + i
+		}
+		if acc.Pid == 0 {
+			acc.Pid = os.Getpid()*10 + i
+		}
+		if acc.Wxid == "" {
+			acc.Wxid = fmt.Sprintf("wxid_mock_%04d", i+1)
+		}
+		if acc.WxNum == "" {
+			acc.WxNum = "mock_" + acc.Wxid
+		}
+		if acc.Nick == "" {
+			acc.Nick = fmt.Sprintf("模拟账号%d", i+1)
+		}
+		if acc.ExpireTime == "" {
+			acc.ExpireTime = time.Now().AddDate(1, 0, 0).Format("2006-01-02 15:04:05")
+		}
+	}
+	return m
+}
+
+// Accounts 获取账号列表副本
+func (m *Mock) Accounts() []Account {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]Account, 0, len(m.accounts))
+	for _, acc := range m.accounts {
+		list = append(list, *acc)
+	}
+	return list
+}
+
+// Account 按 wxid 或端口查找账号，key 为空时返回第一个账号
+func (m *Mock) Account(key string) *Account {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.accounts) == 0 {
+		return nil
+	}
+	if key == "" {
+		return m.accounts[0]
+	}
+	port, _ := strconv.Atoi(key)
+	for _, acc := range m.accounts {
+		if acc.Wxid == key || (port != 0 && acc.Port == port) {
+			return acc
+		}
+	}
+	return nil
+}
+
+// Start 启动账号的 httpapi 监听
+func (m *Mock) Start(ctx context.Context, acc *Account) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.servers[acc.Port]; ok {
+		acc.Online = true
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/wechat/httpapi", m.handleAPI(acc))
+	srv := &http.Server{
+		Addr:    fmt.Sprintf("127.0.0.1:%d", acc.Port),
+		Handler: mux,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	// 等待监听成功或失败
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("端口 %d 监听失败: %v", acc.Port, err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	m.servers[acc.Port] = srv
+	acc.Online = true
+	g.Log().Infof(ctx, "模拟微信 %s 已监听 http://127.0.0.1:%d/wechat/httpapi", acc.Wxid, acc.Port)
+	return nil
+}
+
+// Stop 停止账号的 httpapi 监听，模拟微信退出
+func (m *Mock) Stop(ctx context.Context, acc *Account) {
+	m.mu.Lock()
+	srv, ok := m.servers[acc.Port]
+	delete(m.servers, acc.Port)
+	acc.Online = false
+	m.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	shutdownCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		g.Log().Warningf(ctx, "端口 %d 停止失败: %v", acc.Port, err)
+	}
+	g.Log().Infof(ctx, "模拟微信 %s 已退出", acc.Wxid)
+}
+
+// StopAll 停止全部监听
+func (m *Mock) StopAll(ctx context.Context) {
+	for _, acc := range m.Accounts() {
+		if a := m.Account(strconv.Itoa(acc.Port)); a != nil {
+			m.Stop(ctx, a)
+		}
+	}
+}
+
+// Calls 获取调用记录，port/apiType 为零值时不过滤
+func (m *Mock) Calls(port int, apiType string) []Call {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]Call, 0)
+	for _, c := range m.calls {
+		if (port == 0 || c.Port == port) && (apiType == "" || c.Type == apiType) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// ClearCalls 清空调用记录
+func (m *Mock) ClearCalls() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+// SetOverride 设置接口固定响应
+func (m *Mock) SetOverride(o Override) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.overrides[o.Type] = o
+}
+
+// RemoveOverride 移除接口固定响应，apiType 为空时全部移除
+func (m *Mock) RemoveOverride(apiType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if apiType == "" {
+		m.overrides = make(map[string]Override)
+		return
+	}
+	delete(m.overrides, apiType)
+}
+
+// nextMsgId 生成消息ID
+func (m *Mock) nextMsgId() string {
+	return strconv.FormatInt(m.msgSeq.Add(1), 10)
+}
+
+// record 记录调用
+func (m *Mock) record(port int, apiType string, data map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{
+		Time: time.Now().UnixMilli(),
+		Port: port,
+		Type: apiType,
+		Data: data,
+	})
+	if len(m.calls) > maxCalls {
+		m.calls = m.calls[len(m.calls)-maxCalls:]
+	}
+}
+
+// override 获取接口固定响应
+func (m *Mock) override(apiType string) (Override, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	o, ok := m.overrides[apiType]
+	return o, ok
+}
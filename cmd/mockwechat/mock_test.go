@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/naidog/wechat-framework/internal/core/event"
+	"github.com/naidog/wechat-framework/pkg/client"
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// freePort 获取一个空闲的本机端口
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startMock 按默认场景启动一个模拟账号的 httpapi 监听，测试结束时停止
+func startMock(t *testing.T, callback, secret string) (*Mock, *Account) {
+	t.Helper()
+	sc := defaultScenario(freePort(t), "wxid_mock_test")
+	sc.Callback = callback
+	sc.Secret = secret
+	m := NewMock(sc)
+	acc := m.Account("")
+	if err := m.Start(context.Background(), acc); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.StopAll(context.Background()) })
+	return m, acc
+}
+
+func TestClientCallsMock(t *testing.T) {
+	m, acc := startMock(t, "", "")
+	c := client.New(client.Config{Timeout: 5 * time.Second})
+	ctx := context.Background()
+
+	info, err := c.GetSelfInfo(ctx, acc.Port)
+	if err != nil {
+		t.Fatalf("获取账号信息失败: %v", err)
+	}
+	if info.Wxid != acc.Wxid {
+		t.Fatalf("wxid = %s, 期望 %s", info.Wxid, acc.Wxid)
+	}
+
+	friends, err := c.GetFriendList(ctx, acc.Port, false)
+	if err != nil {
+		t.Fatalf("获取好友列表失败: %v", err)
+	}
+	if len(friends) != 2 {
+		t.Fatalf("好友数 = %d, 期望 2", len(friends))
+	}
+
+	sent, err := c.SendText(ctx, acc.Port, friends[0].Wxid, "你好")
+	if err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+	if sent.MsgId == "" {
+		t.Fatal("发送结果缺少 msgId")
+	}
+	calls := m.Calls(acc.Port, client.TypeSendText)
+	if len(calls) != 1 || calls[0].Data["msg"] != "你好" {
+		t.Fatalf("调用记录 = %+v", calls)
+	}
+
+	m.SetOverride(Override{Type: client.TypeGetSelfInfo, Code: 500, Msg: "未登录"})
+	var apiErr *client.APIError
+	if _, err := c.GetSelfInfo(ctx, acc.Port); !errors.As(err, &apiErr) || apiErr.Code != 500 {
+		t.Fatalf("固定响应错误 = %v, 期望返回码 500", err)
+	}
+}
+
+func TestClientBreakerOpensOnMockTimeout(t *testing.T) {
+	m, acc := startMock(t, "", "")
+	c := client.New(client.Config{
+		Timeouts: map[string]time.Duration{client.TypeGetSelfInfo: 100 * time.Millisecond},
+		Breaker:  client.BreakerConfig{Failures: 2, OpenTimeout: time.Minute},
+	})
+	ctx := context.Background()
+
+	m.SetOverride(Override{Type: client.TypeGetSelfInfo, Delay: "1s"})
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetSelfInfo(ctx, acc.Port); err == nil || errors.Is(err, client.ErrUnavailable) {
+			t.Fatalf("第 %d 次超时调用错误 = %v, 期望超时", i+1, err)
+		}
+	}
+	if got := c.BreakerState(acc.Port); got != client.BreakerOpen {
+		t.Fatalf("连续超时后熔断状态 = %s, 期望 %s", got, client.BreakerOpen)
+	}
+
+	start := time.Now()
+	if _, err := c.GetSelfInfo(ctx, acc.Port); !errors.Is(err, client.ErrUnavailable) {
+		t.Fatalf("熔断后调用错误 = %v, 期望 ErrUnavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("熔断后调用耗时 %v, 期望直接失败", elapsed)
+	}
+
+	// 微信重启后清除熔断状态
+	m.RemoveOverride(client.TypeGetSelfInfo)
+	c.ResetBreaker(acc.Port)
+	if _, err := c.GetSelfInfo(ctx, acc.Port); err != nil {
+		t.Fatalf("重置熔断后调用失败: %v", err)
+	}
+}
+
+func TestLoginEmitsCallbacksWithToken(t *testing.T) {
+	type callback struct {
+		token string
+		raw   types.CallbackEvent
+	}
+	received := make(chan callback, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cb := callback{token: r.URL.Query().Get("token")}
+		if err := json.NewDecoder(r.Body).Decode(&cb.raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received <- cb
+	}))
+	defer srv.Close()
+
+	m, acc := startMock(t, srv.URL+"/wechat/callback", "test-secret")
+	if err := m.Do(context.Background(), acc, ActionLogin, nil); err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+
+	registry := event.NewRegistry()
+	for _, eventType := range []string{types.EventInjectSuccess, types.EventLoginSuccess} {
+		cb := <-received
+		if cb.token != "test-secret" {
+			t.Fatalf("%s 回调 token = %q, 期望 test-secret", eventType, cb.token)
+		}
+		if cb.raw.Type != eventType {
+			t.Fatalf("事件类型 = %s, 期望 %s", cb.raw.Type, eventType)
+		}
+		ev, err := registry.Decode(&cb.raw)
+		if err != nil {
+			t.Fatalf("解析 %s 失败: %v", eventType, err)
+		}
+		if ev.Account.Port != acc.Port || ev.Account.Pid != acc.Pid {
+			t.Fatalf("%s 的账号 = %+v, 期望端口 %d、进程 %d", eventType, ev.Account, acc.Port, acc.Pid)
+		}
+	}
+}
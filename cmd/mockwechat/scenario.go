@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gyaml"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// Account 模拟的微信账号，每个账号监听一个端口
+type Account struct {
+	Wxid       string `json:"wxid"`       // 微信ID
+	WxNum      string `json:"wxNum"`      // 微信号
+	Nick       string `json:"nick"`       // 昵称
+	AvatarUrl  string `json:"avatarUrl"`  // 头像URL
+	Port       int    `json:"port"`       // httpapi 端口
+	Pid        int    `json:"pid"`        // 模拟的进程ID
+	ExpireTime string `json:"expireTime"` // 授权到期时间
+	Online     bool   `json:"online"`     // 是否在线（httpapi 是否可访问）
+}
+
+// Contact 好友、群聊或公众号
+type Contact struct {
+	Wxid   string `json:"wxid"`   // 微信ID
+	WxNum  string `json:"wxNum"`  // 微信号
+	Nick   string `json:"nick"`   // 昵称
+	Remark string `json:"remark"` // 备注
+}
+
+// Step 场景步骤，wait 与 event 可单独使用
+type Step struct {
+	Wait    string                 `json:"wait"`    // 执行前等待，如 500ms、2s
+	Event   string                 `json:"event"`   // 回调事件类型，或 login/logout 等账号操作
+	Account string                 `json:"account"` // 账号 wxid 或端口，为空时使用第一个账号
+	Data    map[string]interface{} `json:"data"`    // 事件数据，未填写的字段使用默认值
+	Repeat  int                    `json:"repeat"`  // 重复次数，默认 1
+}
+
+// Scenario 场景文件
+type Scenario struct {
+	Callback string     `json:"callback"` // 框架回调地址
+	Secret   string     `json:"secret"`   // 回调共享密钥
+	Accounts []*Account `json:"accounts"` // 模拟账号
+	Friends  []Contact  `json:"friends"`  // 好友列表
+	Groups   []Contact  `json:"groups"`   // 群聊列表
+	Publics  []Contact  `json:"publics"`  // 公众号列表
+	Steps    []Step     `json:"steps"`    // 启动后依次执行的步骤
+}
+
+// LoadScenario 读取 YAML 场景文件
+func LoadScenario(path string) (*Scenario, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取场景文件失败: %v", err)
+	}
+	return ParseScenario(content)
+}
+
+// ParseScenario 解析 YAML 或 JSON 格式的场景
+func ParseScenario(content []byte) (*Scenario, error) {
+	var raw map[string]interface{}
+	if err := gyaml.DecodeTo(content, &raw); err != nil {
+		return nil, fmt.Errorf("解析场景失败: %v", err)
+	}
+	var sc Scenario
+	if err := gconv.Struct(raw, &sc); err != nil {
+		return nil, fmt.Errorf("解析场景失败: %v", err)
+	}
+	for i, step := range sc.Steps {
+		if step.Wait != "" {
+			if _, err := time.ParseDuration(step.Wait); err != nil {
+				return nil, fmt.Errorf("第 %d 步 wait 格式错误: %s", i+1, step.Wait)
+			}
+		}
+	}
+	return &sc, nil
+}
+
+// defaultScenario 未指定场景文件时使用的场景：一个账号，启动后注入并登录
+func defaultScenario(port int, wxid string) *Scenario {
+	return &Scenario{
+		Accounts: []*Account{{
+			Wxid:  wxid,
+			WxNum: "mock_" + wxid,
+			Nick:  "模拟账号",
+			Port:  port,
+		}},
+		Steps: []Step{
+			{Event: "login"},
+		},
+	}
+}
+
+// RunSteps 依次执行场景步骤
+func (m *Mock) RunSteps(ctx context.Context, steps []Step) error {
+	for i, step := range steps {
+		if step.Wait != "" {
+			d, err := time.ParseDuration(step.Wait)
+			if err != nil {
+				return fmt.Errorf("第 %d 步 wait 格式错误: %s", i+1, step.Wait)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(d):
+			}
+		}
+		if step.Event == "" {
+			continue
+		}
+
+		acc := m.Account(step.Account)
+		if acc == nil {
+			return fmt.Errorf("第 %d 步账号不存在: %s", i+1, step.Account)
+		}
+
+		repeat := step.Repeat
+		if repeat <= 0 {
+			repeat = 1
+		}
+		for n := 0; n < repeat; n++ {
+			if err := m.Do(ctx, acc, step.Event, step.Data); err != nil {
+				g.Log().Warningf(ctx, "第 %d 步 %s 执行失败: %v", i+1, step.Event, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/naidog/wechat-framework/internal/api/message"
+	"github.com/naidog/wechat-framework/internal/api/plugin"
+	"github.com/naidog/wechat-framework/internal/api/webhook"
+	"github.com/naidog/wechat-framework/internal/api/wechat"
+	"github.com/naidog/wechat-framework/internal/core/access"
+	"github.com/naidog/wechat-framework/internal/core/account"
+	"github.com/naidog/wechat-framework/internal/core/callback"
+	messageCore "github.com/naidog/wechat-framework/internal/core/message"
+	pluginCore "github.com/naidog/wechat-framework/internal/core/plugin"
+	webhookCore "github.com/naidog/wechat-framework/internal/core/webhook"
+	"github.com/naidog/wechat-framework/internal/core/ws"
+	"github.com/naidog/wechat-framework/internal/server"
+	"github.com/naidog/wechat-framework/pkg/logger"
+)
+
+// 无界面服务端：只运行回调接收、事件推送、微信API代理、插件HTTP接口和账号监听，
+// 不依赖 Wails，可在 Linux 上单独部署。
+func main() {
+	configDir := flag.String("config", "configs", "配置文件目录")
+	flag.Parse()
+
+	g.Cfg().GetAdapter().(*gcfg.AdapterFile).SetPath(*configDir)
+
+	ctx, cancel := context.WithCancel(gctx.New())
+	defer cancel()
+
+	// 创建日志服务，无界面时日志写入本地日志
+	logService := logger.NewService(nil)
+	logger.SetGlobalLogService(logService)
+
+	// 创建账号管理器
+	accountManager := account.NewManager(nil)
+
+	// 创建访问控制管理器
+	accessManager, err := access.NewManager(access.LoadConfig(ctx))
+	if err != nil {
+		log.Fatalf("访问控制初始化失败: %v", err)
+	}
+
+	// 创建插件管理器，无界面时不支持打开插件窗口
+	pluginManager := pluginCore.NewManager(nil, logService, accessManager)
+
+	// 创建回调校验器与回调处理器
+	callbackGuard, err := callback.NewGuard(callback.LoadGuardConfig(ctx), accountManager.GetAccounts)
+	if err != nil {
+		log.Fatalf("回调校验器创建失败: %v", err)
+	}
+	callbackHandler := callback.NewHandler(pluginManager, callbackGuard)
+
+	// 打开消息存储，记录收到的消息
+	messageStore, err := messageCore.Open(messageCore.LoadStorePath(ctx))
+	if err != nil {
+		log.Fatalf("消息存储打开失败: %v", err)
+	}
+	defer messageStore.Close()
+	messageStore.Attach(callbackHandler.Events())
+
+	// 启动 webhook 投递
+	webhooks, err := webhookCore.New(webhookCore.LoadConfig(ctx))
+	if err != nil {
+		log.Fatalf("webhook 配置错误: %v", err)
+	}
+	webhooks.Attach(callbackHandler.Events())
+	webhooks.Start()
+	defer webhooks.Stop()
+
+	// 创建API服务
+	wechatProxy := wechat.NewProxy(messageStore, accountManager)
+	pluginAPI := plugin.NewAPI(pluginManager)
+	messageAPI := message.NewAPI(messageStore)
+	wsServer := ws.NewServer(callbackHandler.SSE(), wechatProxy)
+	webhookAPI := webhook.NewAPI(webhooks)
+
+	// 创建并启动HTTP服务器
+	httpServer := server.NewHTTPServer(callbackHandler, wechatProxy, pluginAPI, messageAPI, wsServer, webhookAPI, accessManager)
+	if err := httpServer.Start(); err != nil {
+		log.Fatalf("HTTP服务器启动失败: %v", err)
+	}
+
+	// 启动账号监听
+	go accountManager.StartWatching(ctx)
+
+	g.Log().Info(ctx, "奶狗微信框架服务端已启动（无界面模式）")
+
+	// 等待退出信号
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	g.Log().Info(ctx, "正在停止服务...")
+	cancel()
+	if err := httpServer.Stop(); err != nil {
+		g.Log().Errorf(ctx, "HTTP服务器停止失败: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+// @ts-check
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import { Create as $Create } from "@wailsio/runtime";
+
+/**
+ * Token API 令牌
+ */
+export class Token {
+    /**
+     * Creates a new Token instance.
+     * @param {Partial<Token>} [$$source = {}] - The source object to create the Token.
+     */
+    constructor($$source = {}) {
+        if (!("name" in $$source)) {
+            /**
+             * 令牌名称，如插件ID
+             * @member
+             * @type {string}
+             */
+            this["name"] = "";
+        }
+        if (!("token" in $$source)) {
+            /**
+             * 令牌值
+             * @member
+             * @type {string}
+             */
+            this["token"] = "";
+        }
+        if (!("scopes" in $$source)) {
+            /**
+             * 权限范围
+             * @member
+             * @type {string[] | null}
+             */
+            this["scopes"] = null;
+        }
+        if (!("source" in $$source)) {
+            /**
+             * 来源
+             * @member
+             * @type {string}
+             */
+            this["source"] = "";
+        }
+        if (!("createdAt" in $$source)) {
+            /**
+             * 创建时间
+             * @member
+             * @type {string}
+             */
+            this["createdAt"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Token instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Token}
+     */
+    static createFrom($$source = {}) {
+        const $$createField2_0 = $$createType0;
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("scopes" in $$parsedSource) {
+            $$parsedSource["scopes"] = $$createField2_0($$parsedSource["scopes"]);
+        }
+        return new Token(/** @type {Partial<Token>} */($$parsedSource));
+    }
+}
+
+// Private type creation functions
+const $$createType0 = $Create.Array($Create.Any);
@@ -0,0 +1,65 @@
+// @ts-check
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import { Call as $Call, CancellablePromise as $CancellablePromise, Create as $Create } from "@wailsio/runtime";
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as access$0 from "../../internal/core/access/models.js";
+
+/**
+ * 获取框架主界面使用的令牌
+ * @returns {$CancellablePromise<string>}
+ */
+export function GetAppToken() {
+    return $Call.ByID(2637501168);
+}
+
+/**
+ * 获取可分配的权限范围
+ * @returns {$CancellablePromise<string[]>}
+ */
+export function GetScopes() {
+    return $Call.ByID(3341344925).then(/** @type {($result: any) => any} */(($result) => {
+        return $$createType0($result);
+    }));
+}
+
+/**
+ * 签发令牌，同名令牌会被替换；令牌值只在签发时返回一次
+ * @param {string} name
+ * @param {string[]} scopes
+ * @returns {$CancellablePromise<access$0.Token>}
+ */
+export function IssueToken(name, scopes) {
+    return $Call.ByID(1709447668, name, scopes).then(/** @type {($result: any) => any} */(($result) => {
+        return $$createType1($result);
+    }));
+}
+
+/**
+ * 获取全部令牌，令牌值一律隐藏
+ * @returns {$CancellablePromise<access$0.Token[]>}
+ */
+export function ListTokens() {
+    return $Call.ByID(3178703558).then(/** @type {($result: any) => any} */(($result) => {
+        return $$createType2($result);
+    }));
+}
+
+/**
+ * 吊销签发的令牌
+ * @param {string} name
+ * @returns {$CancellablePromise<void>}
+ */
+export function RevokeToken(name) {
+    return $Call.ByID(1104382071, name);
+}
+
+// Private type creation functions
+const $$createType0 = $Create.Array($Create.Any);
+const $$createType1 = access$0.Token.createFrom;
+const $$createType2 = $Create.Array($$createType1);
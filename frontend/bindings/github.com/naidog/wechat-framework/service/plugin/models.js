@@ -47,6 +47,22 @@ export class PluginInfo {
              */
             this["entryUrl"] = "";
         }
+        if (!("permissions" in $$source)) {
+            /**
+             * 实际申请的权限，未声明时为默认权限
+             * @member
+             * @type {string[] | null}
+             */
+            this["permissions"] = null;
+        }
+        if (!("approved" in $$source)) {
+            /**
+             * 权限是否已经用户授权
+             * @member
+             * @type {boolean}
+             */
+            this["approved"] = false;
+        }
 
         Object.assign(this, $$source);
     }
@@ -58,10 +74,14 @@ export class PluginInfo {
      */
     static createFrom($$source = {}) {
         const $$createField0_0 = $$createType0;
+        const $$createField5_0 = $$createType1;
         let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
         if ("metadata" in $$parsedSource) {
             $$parsedSource["metadata"] = $$createField0_0($$parsedSource["metadata"]);
         }
+        if ("permissions" in $$parsedSource) {
+            $$parsedSource["permissions"] = $$createField5_0($$parsedSource["permissions"]);
+        }
         return new PluginInfo(/** @type {Partial<PluginInfo>} */($$parsedSource));
     }
 }
@@ -128,6 +148,14 @@ export class PluginMetadata {
              */
             this["type"] = "";
         }
+        if (/** @type {any} */(false)) {
+            /**
+             * 申请的权限，如 sendText、events:recvMsg
+             * @member
+             * @type {string[] | undefined}
+             */
+            this["permissions"] = undefined;
+        }
 
         Object.assign(this, $$source);
     }
@@ -138,10 +166,15 @@ export class PluginMetadata {
      * @returns {PluginMetadata}
      */
     static createFrom($$source = {}) {
+        const $$createField8_0 = $$createType1;
         let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("permissions" in $$parsedSource) {
+            $$parsedSource["permissions"] = $$createField8_0($$parsedSource["permissions"]);
+        }
         return new PluginMetadata(/** @type {Partial<PluginMetadata>} */($$parsedSource));
     }
 }
 
 // Private type creation functions
 const $$createType0 = PluginMetadata.createFrom;
+const $$createType1 = $Create.Array($Create.Any);
@@ -14,6 +14,15 @@ import * as application$0 from "../../../../wailsapp/wails/v3/pkg/application/mo
 // @ts-ignore: Unused imports
 import * as $models from "./models.js";
 
+/**
+ * ApprovePlugin 授权插件申请的权限
+ * @param {string} pluginID
+ * @returns {$CancellablePromise<void>}
+ */
+export function ApprovePlugin(pluginID) {
+    return $Call.ByID(3286490208, pluginID);
+}
+
 /**
  * BroadcastEventToPlugins 向所有打开的插件广播事件
  * @param {string} eventType
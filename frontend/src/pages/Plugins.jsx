@@ -23,16 +23,36 @@ import { useState, useEffect } from "react";
 import {
   ScanPlugins,
   OpenPlugin,
+  ApprovePlugin,
   RefreshPlugins,
   UninstallPlugin,
 } from "../../bindings/github.com/naidog/wechat-framework/service/plugin/pluginservice";
+import { GetAppToken } from "../../bindings/github.com/naidog/wechat-framework/service/access/accessservice";
+
+// 敏感权限，插件需在 permissions 中逐个声明
+const SENSITIVE_PERMISSIONS = {
+  confirmTrans: "确认收款",
+  returnTrans: "退还转账",
+  receiveTransfer: "接收转账",
+  delFriend: "删除好友",
+  deleteFriend: "删除好友",
+};
+
+// 权限说明
+const describePermission = (permission) => {
+  if (permission === "*") return "调用微信接口（转账、删除好友除外）";
+  if (permission === "events:*") return "接收全部事件";
+  if (permission.startsWith("events:")) return `接收 ${permission.slice(7)} 事件`;
+  if (SENSITIVE_PERMISSIONS[permission]) return `${SENSITIVE_PERMISSIONS[permission]}（${permission}）`;
+  return `调用 ${permission} 接口`;
+};
 
 const Plugins = () => {
   const [plugins, setPlugins] = useState([]);
   const [loading, setLoading] = useState(true);
   const [currentPage, setCurrentPage] = useState(1);
   const [pageSize, setPageSize] = useState(4);
-  const { message } = App.useApp();
+  const { message, modal } = App.useApp();
 
   useEffect(() => {
     loadPlugins();
@@ -55,6 +75,7 @@ const Plugins = () => {
       const data = await RefreshPlugins();
       setPlugins(data || []);
       message.success(`刷新成功！共 ${data?.length || 0} 个插件`);
+      return data || [];
     } catch (error) {
       console.error("刷新插件失败:", error);
       message.error("刷新失败");
@@ -88,8 +109,11 @@ const Plugins = () => {
         const formData = new FormData();
         formData.append('file', file);
 
+        // 开启令牌校验时需携带主界面令牌
+        const token = await GetAppToken();
         const response = await fetch('http://localhost:9001/api/plugin/upload', {
           method: 'POST',
+          headers: { 'X-Api-Token': token },
           body: formData,
         });
 
@@ -100,9 +124,14 @@ const Plugins = () => {
         if (result.code === 200) {
           message.success(`上传成功: ${file.name}，正在识别插件...`);
           
-          // 延迟一下再刷新，等待文件解压完成
-          setTimeout(() => {
-            refreshPlugins();
+          // 延迟一下再刷新，等待文件解压完成，然后请用户授权新插件的权限
+          setTimeout(async () => {
+            const data = await refreshPlugins();
+            for (const plugin of data || []) {
+              if (!plugin.approved) {
+                await requestApproval(plugin);
+              }
+            }
           }, 1000);
         } else {
           message.error(`上传失败: ${result.msg}`);
@@ -117,7 +146,51 @@ const Plugins = () => {
     },
   };
 
+  // 展示插件申请的权限，用户同意后保存授权
+  const requestApproval = (plugin) =>
+    new Promise((resolve) => {
+      const permissions = plugin.permissions || [];
+      modal.confirm({
+        title: `授权插件: ${plugin.metadata.name}`,
+        content: (
+          <div>
+            <div style={{ marginBottom: "8px" }}>该插件申请以下权限：</div>
+            {permissions.length === 0 ? (
+              <Tag>无</Tag>
+            ) : (
+              permissions.map((permission) => (
+                <div key={permission} style={{ marginBottom: "4px" }}>
+                  <Tag color={SENSITIVE_PERMISSIONS[permission] ? "red" : "blue"}>
+                    {permission}
+                  </Tag>
+                  {describePermission(permission)}
+                </div>
+              ))
+            )}
+          </div>
+        ),
+        okText: "授权",
+        cancelText: "取消",
+        onOk: async () => {
+          try {
+            await ApprovePlugin(plugin.metadata.id);
+            plugin.approved = true;
+            message.success(`已授权插件: ${plugin.metadata.name}`);
+            resolve(true);
+          } catch (error) {
+            message.error(`授权失败: ${error}`);
+            resolve(false);
+          }
+        },
+        onCancel: () => resolve(false),
+      });
+    });
+
   const openPlugin = async (plugin) => {
+    // 首次打开或插件申请了新权限时需要用户授权
+    if (!plugin.approved && !(await requestApproval(plugin))) {
+      return;
+    }
     try {
       // 调用后端服务在新窗口中打开插件
       await OpenPlugin(plugin.metadata.id);
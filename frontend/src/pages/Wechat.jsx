@@ -5,8 +5,20 @@ import { GetAccounts } from "../../bindings/github.com/naidog/wechat-framework/s
 import { msg } from "../hooks/useNotification";
 import { Events } from "@wailsio/runtime";
 import { useEffect, useState } from "react";
+// 微信实例状态（account:state 事件）
+const stateTags = {
+  launched: { color: "default", text: "已启动" },
+  injected: { color: "processing", text: "已注入" },
+  awaitingLogin: { color: "processing", text: "等待登录" },
+  online: { color: "success", text: "正常" },
+  unresponsive: { color: "warning", text: "无响应" },
+  expired: { color: "error", text: "已过期" },
+  exited: { color: "default", text: "已退出" },
+};
+
 const WechatList = () => {
   const [accounts, setAccounts] = useState([]);
+  const [states, setStates] = useState({});
   const [loading, setLoading] = useState(true);
   const [loginLoading, setLoginLoading] = useState(false);
 
@@ -69,11 +81,25 @@ const WechatList = () => {
       setLoading(false);
     });
 
+    // 监听微信实例状态变化，账号掉线时提示原因
+    const unsubscribeState = Events.On("account:state", (event) => {
+      const data = Array.isArray(event?.data) ? event.data[0] : event?.data;
+      if (!data || !data.port) return;
+
+      setStates((prev) => ({ ...prev, [data.port]: data }));
+      if (data.to === "exited" && data.wxid) {
+        msg.warning(`${data.nick || data.wxid} 已退出：${data.reason}`);
+      }
+    });
+
     // 组件卸载时取消监听
     return () => {
       if (unsubscribe) {
         unsubscribe();
       }
+      if (unsubscribeState) {
+        unsubscribeState();
+      }
     };
   }, []);
 
@@ -178,11 +204,17 @@ const WechatList = () => {
       width: 100,
       align: "center",
       fixed: "right",
-      render: (isExpire) => (
-        <Tag color={isExpire === 0 ? "success" : "error"}>
-          {isExpire === 0 ? "正常" : "已过期"}
-        </Tag>
-      ),
+      render: (isExpire, record) => {
+        const state = states[record.port];
+        const tag = state
+          ? stateTags[state.to]
+          : stateTags[isExpire === 0 ? "online" : "expired"];
+        return (
+          <Tooltip title={state?.reason}>
+            <Tag color={tag?.color}>{tag?.text || state.to}</Tag>
+          </Tooltip>
+        );
+      },
     },
   ];
 
@@ -0,0 +1,71 @@
+package message
+
+import (
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/naidog/wechat-framework/internal/core/message"
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// API 消息历史API服务
+type API struct {
+	store *message.Store
+}
+
+// NewAPI 创建消息历史API实例
+func NewAPI(store *message.Store) *API {
+	return &API{
+		store: store,
+	}
+}
+
+// Query 查询历史消息
+// 参数：account 账号wxid，chat 会话wxid，before 时间上限（13位/10位时间戳或 RFC3339），limit 条数
+func (a *API) Query(r *ghttp.Request) {
+	if a.store == nil {
+		r.Response.WriteJson(g.Map{
+			"code": 500,
+			"msg":  "消息存储未启用",
+		})
+		return
+	}
+
+	q := message.Query{
+		Account: r.Get("account").String(),
+		Chat:    r.Get("chat").String(),
+		Limit:   r.Get("limit").Int(),
+	}
+
+	if before := r.Get("before").String(); before != "" {
+		q.Before = types.ParseTimestamp(before)
+		if q.Before.IsZero() {
+			t, err := time.Parse(time.RFC3339, before)
+			if err != nil {
+				r.Response.WriteJson(g.Map{
+					"code": 400,
+					"msg":  "before 参数格式错误",
+				})
+				return
+			}
+			q.Before = t
+		}
+	}
+
+	list, err := a.store.Query(q)
+	if err != nil {
+		r.Response.WriteJson(g.Map{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"data": g.Map{
+			"list": list,
+		},
+	})
+}
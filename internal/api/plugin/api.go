@@ -5,6 +5,7 @@ import (
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/naidog/wechat-framework/internal/core/account"
 )
 
 // PluginManager 插件管理器接口
@@ -62,6 +63,22 @@ func (a *API) GetWechat(r *ghttp.Request) {
 	})
 }
 
+// GetWechatStates 获取各微信实例的生命周期状态与最近的状态变化，包含最近退出的实例
+func (a *API) GetWechatStates(r *ghttp.Request) {
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"data": account.Default().Instances(),
+	})
+}
+
+// GetWechatHealth 获取各微信实例的心跳检测统计：耗时、最近成功时间与最近的检测记录
+func (a *API) GetWechatHealth(r *ghttp.Request) {
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"data": account.DefaultHeartbeat().Health(),
+	})
+}
+
 // SendLog 发送日志
 func (a *API) SendLog(r *ghttp.Request) {
 	var req struct {
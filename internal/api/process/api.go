@@ -0,0 +1,82 @@
+package process
+
+import (
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/naidog/wechat-framework/internal/core/ports"
+	"github.com/naidog/wechat-framework/internal/core/supervisor"
+)
+
+// API 微信进程管理API服务
+type API struct {
+	supervisor *supervisor.Supervisor
+	ports      *ports.Allocator
+}
+
+// NewAPI 创建微信进程管理API实例
+func NewAPI(s *supervisor.Supervisor, allocator *ports.Allocator) *API {
+	return &API{
+		supervisor: s,
+		ports:      allocator,
+	}
+}
+
+// List 获取框架启动的微信进程及其启动与退出记录
+func (a *API) List(r *ghttp.Request) {
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"data": g.Map{
+			"list":   a.supervisor.Processes(),
+			"config": a.supervisor.Config(),
+		},
+	})
+}
+
+// Ports 获取端口范围、已保留的端口与账号常用端口
+func (a *API) Ports(r *ghttp.Request) {
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"data": a.ports.Stats(),
+	})
+}
+
+// Stop 结束微信进程，结束后不再自动重启
+// 参数：port 微信端口
+func (a *API) Stop(r *ghttp.Request) {
+	port := r.Get("port").Int()
+	if err := a.supervisor.Stop(r.Context(), port); err != nil {
+		r.Response.WriteJson(g.Map{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "已停止",
+	})
+}
+
+// Restart 用相同的端口与缓存目录重新启动微信进程
+// 参数：port 微信端口
+func (a *API) Restart(r *ghttp.Request) {
+	port := r.Get("port").Int()
+	pid, err := a.supervisor.Restart(r.Context(), port)
+	if err != nil {
+		r.Response.WriteJson(g.Map{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "已重启",
+		"data": g.Map{
+			"port": port,
+			"pid":  pid,
+		},
+	})
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/naidog/wechat-framework/internal/core/webhook"
+)
+
+// API webhook 管理API服务
+type API struct {
+	dispatcher *webhook.Dispatcher
+}
+
+// NewAPI 创建 webhook 管理API实例
+func NewAPI(dispatcher *webhook.Dispatcher) *API {
+	return &API{
+		dispatcher: dispatcher,
+	}
+}
+
+// List 获取订阅列表与投递统计
+func (a *API) List(r *ghttp.Request) {
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"data": g.Map{
+			"subscriptions": a.dispatcher.Subscriptions(),
+			"stats":         a.dispatcher.Stats(),
+		},
+	})
+}
+
+// DeadLetters 获取死信列表
+func (a *API) DeadLetters(r *ghttp.Request) {
+	list, err := a.dispatcher.DeadLetters()
+	if err != nil {
+		r.Response.WriteJson(g.Map{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"data": g.Map{
+			"list": list,
+		},
+	})
+}
+
+// Redrive 重新投递死信
+// 参数：ids 投递ID列表，为空时重投全部
+func (a *API) Redrive(r *ghttp.Request) {
+	ids := r.Get("ids").Strings()
+
+	count, err := a.dispatcher.Redrive(ids)
+	if err != nil {
+		r.Response.WriteJson(g.Map{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"msg":  "已重新投递",
+		"data": g.Map{
+			"count": count,
+		},
+	})
+}
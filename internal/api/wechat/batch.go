@@ -0,0 +1,302 @@
+package wechat
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
+	"github.com/naidog/wechat-framework/internal/core/access"
+	"github.com/naidog/wechat-framework/pkg/client"
+)
+
+// 批量调用限制
+const (
+	DefaultBatchConcurrency = 1                // 默认并发数，按顺序逐个调用
+	MaxBatchConcurrency     = 16               // 最大并发数
+	MaxBatchOps             = 500              // 单次批量调用的最大操作数
+	MaxBatchDelay           = time.Minute      // 最大调用间隔
+	batchJobTTL             = 30 * time.Minute // 异步任务完成后结果的保留时间
+)
+
+// 批量调用任务状态
+const (
+	JobRunning = "running" // 执行中
+	JobDone    = "done"    // 已完成
+)
+
+// BatchOp 批量调用中的单个操作
+type BatchOp struct {
+	ID      string                 `json:"id"`      // 调用方自定义的标识，原样返回
+	Type    string                 `json:"type"`    // 接口名，支持别名
+	Port    int                    `json:"port"`    // 微信端口
+	Wxid    string                 `json:"wxid"`    // 调用账号的 wxid 或别名，不是消息接收人
+	Account string                 `json:"account"` // 同 wxid
+	Data    map[string]interface{} `json:"data"`    // 接口参数
+}
+
+// BatchRequest 批量调用请求
+type BatchRequest struct {
+	Ops         []BatchOp `json:"ops"`         // 操作列表
+	Concurrency int       `json:"concurrency"` // 并发数，默认逐个调用
+	Delay       string    `json:"delay"`       // 相邻两个操作开始调用的间隔，如 500ms
+	Async       bool      `json:"async"`       // 是否异步执行，异步时立即返回任务ID
+}
+
+// BatchResult 单个操作的结果，requestId 为 {任务ID}-{序号}
+type BatchResult struct {
+	Index int    `json:"index"`        // 操作序号
+	ID    string `json:"id,omitempty"` // 调用方自定义的标识
+	Type  string `json:"type"`         // 接口名
+	client.Envelope
+}
+
+// BatchJob 批量调用任务
+type BatchJob struct {
+	ID         string         `json:"id"`                   // 任务ID
+	Status     string         `json:"status"`               // running 或 done
+	Total      int            `json:"total"`                // 操作总数
+	Finished   int            `json:"finished"`             // 已完成数
+	Succeeded  int            `json:"succeeded"`            // 成功数
+	Failed     int            `json:"failed"`               // 失败数
+	CreatedAt  time.Time      `json:"createdAt"`            // 创建时间
+	FinishedAt *time.Time     `json:"finishedAt,omitempty"` // 完成时间
+	Results    []*BatchResult `json:"results"`              // 各操作结果，未完成的操作为 null
+
+	owner string // 发起任务的令牌，只有同一令牌可以查询任务
+	mu    sync.Mutex
+}
+
+// ownedBy 判断任务是否由该令牌发起
+func (j *BatchJob) ownedBy(token *access.Token) bool {
+	return subtle.ConstantTimeCompare([]byte(j.owner), []byte(tokenValue(token))) == 1
+}
+
+// tokenValue 获取令牌值，未携带令牌时为空
+func tokenValue(token *access.Token) string {
+	if token == nil {
+		return ""
+	}
+	return token.Token
+}
+
+// snapshot 复制任务当前状态
+func (j *BatchJob) snapshot() *BatchJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return &BatchJob{
+		ID:         j.ID,
+		Status:     j.Status,
+		Total:      j.Total,
+		Finished:   j.Finished,
+		Succeeded:  j.Succeeded,
+		Failed:     j.Failed,
+		CreatedAt:  j.CreatedAt,
+		FinishedAt: j.FinishedAt,
+		Results:    append([]*BatchResult(nil), j.Results...),
+	}
+}
+
+// batchJobs 异步任务列表
+type batchJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*BatchJob
+}
+
+// add 登记任务，同时清理过期的已完成任务
+func (s *batchJobs) add(job *BatchJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobs == nil {
+		s.jobs = make(map[string]*BatchJob)
+	}
+	for id, j := range s.jobs {
+		j.mu.Lock()
+		expired := j.FinishedAt != nil && time.Since(*j.FinishedAt) > batchJobTTL
+		j.mu.Unlock()
+		if expired {
+			delete(s.jobs, id)
+		}
+	}
+	s.jobs[job.ID] = job
+}
+
+// get 获取任务
+func (s *batchJobs) get(id string) (*BatchJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+// Batch 批量调用微信接口：POST /api/wechat/_batch。
+// 同步模式在全部操作完成后返回各操作结果；异步模式立即返回任务ID，通过 GET /api/wechat/_batch/{id} 查询进度与结果
+func (p *Proxy) Batch(r *ghttp.Request) {
+	w := newResponder(r)
+
+	req, delay, err := parseBatch(r.GetBody())
+	if err != nil {
+		w.fail(&apiError{
+			code:   client.ErrCodeBadRequest,
+			status: 400,
+			msg:    "批量请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	job := &BatchJob{
+		ID:        guid.S(),
+		Status:    JobRunning,
+		Total:     len(req.Ops),
+		CreatedAt: time.Now(),
+		Results:   make([]*BatchResult, len(req.Ops)),
+	}
+	token := access.FromRequest(r)
+	job.owner = tokenValue(token)
+
+	if req.Async {
+		p.jobs.add(job)
+		go p.runBatch(context.WithoutCancel(r.Context()), job, req, delay, token)
+		w.ok(job.snapshot())
+		return
+	}
+	p.runBatch(r.Context(), job, req, delay, token)
+	w.ok(job.snapshot())
+}
+
+// BatchStatus 查询异步批量调用任务：GET /api/wechat/_batch/{id}，只有发起任务的令牌可以查询
+func (p *Proxy) BatchStatus(r *ghttp.Request) {
+	w := newResponder(r)
+
+	id := r.GetRouter("id").String()
+	job, ok := p.jobs.get(id)
+	if !ok || !job.ownedBy(access.FromRequest(r)) {
+		w.fail(&apiError{
+			code:   client.ErrCodeNotFound,
+			status: 404,
+			msg:    fmt.Sprintf("批量任务 %s 不存在或已过期", id),
+		})
+		return
+	}
+	w.ok(job.snapshot())
+}
+
+// parseBatch 解析并校验批量请求，数字按原文保留
+func parseBatch(body []byte) (*BatchRequest, time.Duration, error) {
+	var req BatchRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case len(req.Ops) == 0:
+		return nil, 0, fmt.Errorf("ops 不能为空")
+	case len(req.Ops) > MaxBatchOps:
+		return nil, 0, fmt.Errorf("单次最多 %d 个操作", MaxBatchOps)
+	}
+
+	if req.Concurrency <= 0 {
+		req.Concurrency = DefaultBatchConcurrency
+	}
+	if req.Concurrency > MaxBatchConcurrency {
+		req.Concurrency = MaxBatchConcurrency
+	}
+
+	var delay time.Duration
+	if req.Delay != "" {
+		d, err := time.ParseDuration(req.Delay)
+		if err != nil || d < 0 || d > MaxBatchDelay {
+			return nil, 0, fmt.Errorf("delay 格式错误或超过 %s: %s", MaxBatchDelay, req.Delay)
+		}
+		delay = d
+	}
+	return &req, delay, nil
+}
+
+// runBatch 按并发数执行全部操作，相邻操作开始调用的间隔不小于 delay
+func (p *Proxy) runBatch(ctx context.Context, job *BatchJob, req *BatchRequest, delay time.Duration, token *access.Token) {
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range req.Ops {
+			if i > 0 && delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for n := 0; n < req.Concurrency; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := p.runOp(ctx, job.ID, i, req.Ops[i], token)
+
+				job.mu.Lock()
+				job.Results[i] = result
+				job.Finished++
+				if result.OK {
+					job.Succeeded++
+				} else {
+					job.Failed++
+				}
+				job.mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now()
+	job.mu.Lock()
+	job.Status = JobDone
+	job.FinishedAt = &now
+	job.mu.Unlock()
+}
+
+// runOp 执行单个操作，令牌按操作的接口校验权限
+func (p *Proxy) runOp(ctx context.Context, jobID string, index int, op BatchOp, token *access.Token) *BatchResult {
+	result := &BatchResult{Index: index, ID: op.ID, Type: op.Type}
+	requestID := fmt.Sprintf("%s-%d", jobID, index)
+
+	if token != nil && !token.AllowAPI(op.Type) {
+		result.Envelope = (&apiError{
+			code:   client.ErrCodeForbidden,
+			status: 403,
+			msg:    "缺少权限: " + op.Type,
+		}).envelope(requestID)
+		return result
+	}
+
+	key := op.Wxid
+	if key == "" {
+		key = op.Account
+	}
+	if op.Data == nil {
+		op.Data = make(map[string]interface{})
+	}
+
+	body, apiErr := p.invoke(ctx, op.Type, op.Port, key, op.Data)
+	if apiErr != nil {
+		result.Envelope = apiErr.envelope(requestID)
+		return result
+	}
+	result.Envelope = wechatEnvelope(body, requestID)
+	return result
+}
@@ -2,355 +2,205 @@ package wechat
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
 
+	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/internal/core/access"
+	"github.com/naidog/wechat-framework/internal/core/account"
+	"github.com/naidog/wechat-framework/internal/core/dll"
+	"github.com/naidog/wechat-framework/internal/core/message"
+	"github.com/naidog/wechat-framework/pkg/client"
+	"github.com/naidog/wechat-framework/pkg/types"
 )
 
-// Proxy 微信API代理服务
-type Proxy struct{}
+// AccountProvider 账号列表提供者
+type AccountProvider interface {
+	GetAccounts(ctx context.Context) []types.WechatAccount
+	Resolve(ctx context.Context, port int, key string) (int, error)
+}
 
-// NewProxy 创建微信API代理实例
-func NewProxy() *Proxy {
-	return &Proxy{}
+// Proxy 微信API代理服务
+type Proxy struct {
+	store    *message.Store  // 消息存储，为 nil 时不记录发出的消息
+	accounts AccountProvider // 账号列表，用于将端口映射为 wxid
+	dll      *client.Client  // 微信接口客户端
+	jobs     batchJobs       // 异步批量调用任务
 }
 
-// callWechatAPI 调用微信服务API的通用函数
-func (p *Proxy) callWechatAPI(r *ghttp.Request, apiType string) {
-	// 从 URL 参数获取端口号
-	port := r.Get("port").Int()
-	if port == 0 {
-		r.Response.WriteJson(map[string]interface{}{
-			"code": 400,
-			"msg":  "缺少 port 参数",
-		})
-		return
+// NewProxy 创建微信API代理实例
+func NewProxy(store *message.Store, accounts AccountProvider) *Proxy {
+	return &Proxy{
+		store:    store,
+		accounts: accounts,
+		dll:      dll.Client(),
 	}
+}
 
-	// 构建目标 URL
-	url := fmt.Sprintf("http://127.0.0.1:%d/wechat/httpapi", port)
+// Handle 代理微信接口调用，接口名取自路径 /api/wechat/{type}，支持规范名称与别名。
+// 默认原样返回微信接口的响应，请求头 X-Response-Format: envelope 或配置 proxy.responseFormat 可改为统一响应格式
+func (p *Proxy) Handle(r *ghttp.Request) {
+	w := newResponder(r)
 
-	// 获取请求体
-	var requestBody map[string]interface{}
-	if err := r.Parse(&requestBody); err != nil {
-		r.Response.WriteJson(map[string]interface{}{
-			"code": 400,
-			"msg":  "请求参数解析失败",
+	data, err := parseBody(r.GetBody())
+	if err != nil {
+		w.fail(&apiError{
+			code:   client.ErrCodeBadRequest,
+			status: 400,
+			msg:    "请求参数解析失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 添加 type 字段
-	requestBody["type"] = apiType
-
-	// 序列化请求体
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		r.Response.WriteJson(map[string]interface{}{
-			"code": 500,
-			"msg":  "请求序列化失败",
-		})
+	// 只取路径中的接口名，请求体中的 type 是接口参数（如列表获取方式）
+	body, apiErr := p.invoke(r.Context(), r.GetRouter("type").String(), r.Get("port").Int(), account.RequestKey(r), data)
+	if apiErr != nil {
+		w.fail(apiErr)
 		return
 	}
-
-	// 创建 HTTP 客户端
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	w.success(body)
+}
+
+// invoke 查找接口并按 port、wxid 或别名确定微信端口后调用，供单个调用与批量调用共用
+func (p *Proxy) invoke(ctx context.Context, name string, port int, key string, data map[string]interface{}) ([]byte, *apiError) {
+	route, ok := client.Lookup(name)
+	if !ok {
+		return nil, &apiError{
+			code:   client.ErrCodeUnknownAPI,
+			status: 404,
+			msg:    fmt.Sprintf("未知的微信接口: %s，可通过 /api/wechat/_routes 查看全部接口", name),
+		}
 	}
 
-	// 发送请求
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	port, err := p.Resolve(ctx, port, key)
 	if err != nil {
-		r.Response.WriteJson(map[string]interface{}{
-			"code": 500,
-			"msg":  "创建请求失败",
-		})
-		return
+		return nil, newAPIError(err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
+	// 参数由 Call 统一校验，校验不通过时不调用微信，返回出错的参数
+	body, err := p.Call(ctx, port, route.Type, data)
 	if err != nil {
-		r.Response.WriteJson(map[string]interface{}{
-			"code": 500,
-			"msg":  fmt.Sprintf("请求失败: %v", err),
-		})
-		return
+		return nil, newAPIError(err)
 	}
-	defer resp.Body.Close()
-
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		r.Response.WriteJson(map[string]interface{}{
-			"code": 500,
-			"msg":  "读取响应失败",
+	return body, nil
+}
+
+// Routes 列出全部支持的微信接口及其别名、参数与所需权限
+func (p *Proxy) Routes(r *ghttp.Request) {
+	list := make([]g.Map, 0, len(client.Routes))
+	for _, route := range client.Routes {
+		aliases := route.Aliases
+		if aliases == nil {
+			aliases = []string{}
+		}
+		params := client.Params(route.Type)
+		if params == nil {
+			params = []client.Param{}
+		}
+		list = append(list, g.Map{
+			"type":      route.Type,
+			"aliases":   aliases,
+			"group":     route.Group,
+			"desc":      route.Desc,
+			"params":    params,
+			"path":      "/api/wechat/" + route.Type,
+			"scope":     access.ScopeForAPI(route.Type),
+			"sensitive": access.IsSensitive(route.Type),
 		})
-		return
 	}
-
-	// 解析响应
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		r.Response.WriteJson(map[string]interface{}{
-			"code": 500,
-			"msg":  "响应解析失败",
-		})
-		return
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"data": g.Map{
+			"list": list,
+		},
+	})
+}
+
+// parseBody 解析代理请求体。规范格式为接口参数本身，如 {"wxid": "...", "msg": "..."}；
+// 兼容微信接口的 {"type": "...", "data": {...}} 格式，此时取 data 作为参数。
+// 数字按原文保留，避免较长的消息ID、转账ID丢失精度
+func parseBody(body []byte) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if len(body) == 0 {
+		return data, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
 	}
 
-	// 返回结果
-	r.Response.WriteJson(result)
-}
-
-// 基础接口 (7个)
-
-// ChangeVersion 修改版本号
-func (p *Proxy) ChangeVersion(r *ghttp.Request) {
-	p.callWechatAPI(r, "changeVersion")
-}
-
-// GetLoginStatus 获取登录状态
-func (p *Proxy) GetLoginStatus(r *ghttp.Request) {
-	p.callWechatAPI(r, "getLoginStatus")
-}
-
-// GetLoginQrCode 获取登录二维码
-func (p *Proxy) GetLoginQrCode(r *ghttp.Request) {
-	p.callWechatAPI(r, "getLoginQrCode")
-}
-
-// GetSelfInfo 获取个人信息
-func (p *Proxy) GetSelfInfo(r *ghttp.Request) {
-	p.callWechatAPI(r, "getSelfInfo")
-}
-
-// GetAuthInfo 获取授权信息
-func (p *Proxy) GetAuthInfo(r *ghttp.Request) {
-	p.callWechatAPI(r, "getAuthInfo")
-}
-
-// Logout 退出登录
-func (p *Proxy) Logout(r *ghttp.Request) {
-	p.callWechatAPI(r, "logout")
-}
-
-// GetWechatVer 获取微信版本
-func (p *Proxy) GetWechatVer(r *ghttp.Request) {
-	p.callWechatAPI(r, "getWechatVer")
-}
-
-// 消息发送接口 (13个)
-
-// SendText 发送文本消息
-func (p *Proxy) SendText(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendText")
-}
-
-// SendImage 发送图片
-func (p *Proxy) SendImage(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendImage")
-}
-
-// SendFile 发送文件
-func (p *Proxy) SendFile(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendFile")
-}
-
-// SendVideo 发送视频
-func (p *Proxy) SendVideo(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendVideo")
-}
-
-// SendEmoji 发送表情
-func (p *Proxy) SendEmoji(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendEmoji")
-}
-
-// SendCard 发送名片
-func (p *Proxy) SendCard(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendCard")
-}
-
-// SendLink 发送链接
-func (p *Proxy) SendLink(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendLink")
-}
-
-// SendMiniProgram 发送小程序
-func (p *Proxy) SendMiniProgram(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendMiniProgram")
-}
-
-// SendMusic 发送音乐
-func (p *Proxy) SendMusic(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendMusic")
-}
-
-// SendLocation 发送位置
-func (p *Proxy) SendLocation(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendLocation")
-}
-
-// ForwardMsg 转发消息
-func (p *Proxy) ForwardMsg(r *ghttp.Request) {
-	p.callWechatAPI(r, "forwardMsg")
-}
-
-// SendAtText 发送@消息
-func (p *Proxy) SendAtText(r *ghttp.Request) {
-	p.callWechatAPI(r, "sendAtText")
-}
-
-// RevokeMsg 撤回消息
-func (p *Proxy) RevokeMsg(r *ghttp.Request) {
-	p.callWechatAPI(r, "revokeMsg")
-}
-
-// 信息获取接口 (5个)
-
-// GetFriendList 获取好友列表
-func (p *Proxy) GetFriendList(r *ghttp.Request) {
-	p.callWechatAPI(r, "getFriendList")
-}
-
-// GetGroupList 获取群聊列表
-func (p *Proxy) GetGroupList(r *ghttp.Request) {
-	p.callWechatAPI(r, "getGroupList")
-}
-
-// GetGroupMembers 获取群成员列表
-func (p *Proxy) GetGroupMembers(r *ghttp.Request) {
-	p.callWechatAPI(r, "getGroupMembers")
-}
-
-// GetContactProfile 获取联系人详细信息
-func (p *Proxy) GetContactProfile(r *ghttp.Request) {
-	p.callWechatAPI(r, "getContactProfile")
-}
-
-// GetDbNames 获取数据库名称
-func (p *Proxy) GetDbNames(r *ghttp.Request) {
-	p.callWechatAPI(r, "getDbNames")
-}
-
-// 好友管理接口 (7个)
-
-// AddFriend 添加好友
-func (p *Proxy) AddFriend(r *ghttp.Request) {
-	p.callWechatAPI(r, "addFriend")
-}
-
-// AcceptFriend 同意好友请求
-func (p *Proxy) AcceptFriend(r *ghttp.Request) {
-	p.callWechatAPI(r, "acceptFriend")
-}
-
-// DeleteFriend 删除好友
-func (p *Proxy) DeleteFriend(r *ghttp.Request) {
-	p.callWechatAPI(r, "deleteFriend")
-}
-
-// SetRemark 设置备注
-func (p *Proxy) SetRemark(r *ghttp.Request) {
-	p.callWechatAPI(r, "setRemark")
-}
-
-// TopContact 置顶联系人
-func (p *Proxy) TopContact(r *ghttp.Request) {
-	p.callWechatAPI(r, "topContact")
-}
-
-// SetBlacklist 设置黑名单
-func (p *Proxy) SetBlacklist(r *ghttp.Request) {
-	p.callWechatAPI(r, "setBlacklist")
-}
-
-// SearchFriend 搜索好友
-func (p *Proxy) SearchFriend(r *ghttp.Request) {
-	p.callWechatAPI(r, "searchFriend")
-}
-
-// 群聊管理接口 (9个)
-
-// CreateGroup 创建群聊
-func (p *Proxy) CreateGroup(r *ghttp.Request) {
-	p.callWechatAPI(r, "createGroup")
-}
-
-// AddGroupMember 添加群成员
-func (p *Proxy) AddGroupMember(r *ghttp.Request) {
-	p.callWechatAPI(r, "addGroupMember")
-}
-
-// DeleteGroupMember 删除群成员
-func (p *Proxy) DeleteGroupMember(r *ghttp.Request) {
-	p.callWechatAPI(r, "deleteGroupMember")
-}
-
-// QuitGroup 退出群聊
-func (p *Proxy) QuitGroup(r *ghttp.Request) {
-	p.callWechatAPI(r, "quitGroup")
-}
-
-// ModifyGroupName 修改群名称
-func (p *Proxy) ModifyGroupName(r *ghttp.Request) {
-	p.callWechatAPI(r, "modifyGroupName")
-}
-
-// ModifyGroupNotice 修改群公告
-func (p *Proxy) ModifyGroupNotice(r *ghttp.Request) {
-	p.callWechatAPI(r, "modifyGroupNotice")
-}
-
-// ModifyNickInGroup 修改群昵称
-func (p *Proxy) ModifyNickInGroup(r *ghttp.Request) {
-	p.callWechatAPI(r, "modifyNickInGroup")
-}
-
-// InviteIntoGroup 邀请进群
-func (p *Proxy) InviteIntoGroup(r *ghttp.Request) {
-	p.callWechatAPI(r, "inviteIntoGroup")
+	nested, ok := data["data"].(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+	for key := range data {
+		if key != "data" && key != "type" {
+			return data, nil
+		}
+	}
+	return nested, nil
 }
 
-// GetGroupQrCode 获取群二维码
-func (p *Proxy) GetGroupQrCode(r *ghttp.Request) {
-	p.callWechatAPI(r, "getGroupQrCode")
+// Resolve 将 port、wxid 或别名解析为微信端口，未配置账号列表时必须指定 port
+func (p *Proxy) Resolve(ctx context.Context, port int, key string) (int, error) {
+	if p.accounts == nil {
+		if port == 0 {
+			return 0, ErrMissingPort
+		}
+		return port, nil
+	}
+	return p.accounts.Resolve(ctx, port, key)
 }
 
-// 其他接口 (6个)
+// Call 校验参数后调用微信服务API并返回原始响应，供 HTTP 接口和 WebSocket 命令共用，接口别名按规范名称发送
+func (p *Proxy) Call(ctx context.Context, port int, apiType string, data map[string]interface{}) ([]byte, error) {
+	if err := client.Validate(apiType, data); err != nil {
+		return nil, err
+	}
+	apiType = client.Canonical(apiType)
+	body, err := p.dll.Raw(ctx, port, apiType, data)
+	var apiErr *client.APIError
+	if err != nil && !errors.As(err, &apiErr) {
+		return nil, err
+	}
 
-// ReceiveTransfer 接收转账
-func (p *Proxy) ReceiveTransfer(r *ghttp.Request) {
-	p.callWechatAPI(r, "receiveTransfer")
-}
+	// 解析响应
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, ErrBadResponse
+	}
 
-// OpenBrowser 打开浏览器
-func (p *Proxy) OpenBrowser(r *ghttp.Request) {
-	p.callWechatAPI(r, "openBrowser")
-}
+	// 记录发出的消息
+	if message.IsOutbound(apiType) && gconv.Int(result["code"]) == 200 {
+		p.recordOutbound(ctx, port, apiType, data, body)
+	}
 
-// DownloadImage 下载图片
-func (p *Proxy) DownloadImage(r *ghttp.Request) {
-	p.callWechatAPI(r, "downloadImage")
+	return body, nil
 }
 
-// DownloadFile 下载文件
-func (p *Proxy) DownloadFile(r *ghttp.Request) {
-	p.callWechatAPI(r, "downloadFile")
-}
+// recordOutbound 将通过代理发出的消息写入消息存储
+func (p *Proxy) recordOutbound(ctx context.Context, port int, apiType string, data map[string]interface{}, resp []byte) {
+	if p.store == nil {
+		return
+	}
 
-// ExecuteSql 执行SQL
-func (p *Proxy) ExecuteSql(r *ghttp.Request) {
-	p.callWechatAPI(r, "executeSql")
-}
+	var account string
+	if p.accounts != nil {
+		for _, acc := range p.accounts.GetAccounts(ctx) {
+			if acc.Port == port {
+				account = acc.Wxid
+				break
+			}
+		}
+	}
 
-// CallCloudFunc 调用云函数
-func (p *Proxy) CallCloudFunc(r *ghttp.Request) {
-	p.callWechatAPI(r, "callCloudFunc")
+	msg := message.Outbound(account, apiType, data, resp)
+	if err := p.store.Save(msg); err != nil {
+		g.Log().Warningf(ctx, "保存发出的消息失败 (port:%d): %v", port, err)
+	}
 }
@@ -0,0 +1,194 @@
+package wechat
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
+	"github.com/naidog/wechat-framework/internal/core/account"
+	"github.com/naidog/wechat-framework/pkg/client"
+)
+
+var (
+	// ErrMissingPort 未配置账号列表且未指定 port
+	ErrMissingPort = errors.New("缺少 port 参数")
+	// ErrBadResponse 微信接口的响应无法解析
+	ErrBadResponse = errors.New("响应解析失败")
+)
+
+// apiError 代理自身的错误
+type apiError struct {
+	code   string      // 统一响应的错误码
+	status int         // 原始格式中的返回码
+	msg    string      // 提示信息
+	data   interface{} // 错误详情，可为 nil
+}
+
+// newAPIError 按错误类型确定错误码
+func newAPIError(err error) *apiError {
+	e := &apiError{code: ErrorCode(err), status: 500, msg: err.Error()}
+
+	var validationErr *client.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		e.status = 400
+		e.data = g.Map{
+			"type":   validationErr.Type,
+			"errors": validationErr.Errors,
+		}
+	case e.code == client.ErrCodeBadRequest:
+		e.status = 400
+	case e.code == client.ErrCodeUnavailable:
+		e.status = 503
+	case e.code == client.ErrCodeNoAccount, e.code == client.ErrCodeAmbiguousAccount, e.code == client.ErrCodeAccountOffline:
+		e.status = account.ErrorCode(err)
+	}
+	return e
+}
+
+// ErrorCode 代理错误对应的统一错误码
+func ErrorCode(err error) string {
+	var (
+		validationErr *client.ValidationError
+		offlineErr    *account.OfflineError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		return client.ErrCodeValidation
+	case errors.Is(err, ErrMissingPort):
+		return client.ErrCodeBadRequest
+	case errors.Is(err, account.ErrNoAccount):
+		return client.ErrCodeNoAccount
+	case errors.Is(err, account.ErrAmbiguous):
+		return client.ErrCodeAmbiguousAccount
+	case errors.As(err, &offlineErr):
+		return client.ErrCodeAccountOffline
+	case errors.Is(err, client.ErrUnavailable):
+		return client.ErrCodeUnavailable
+	case client.IsTimeout(err):
+		return client.ErrCodeTimeout
+	case errors.Is(err, ErrBadResponse):
+		return client.ErrCodeBadResponse
+	default:
+		return client.ErrCodeUnreachable
+	}
+}
+
+// responder 按请求的响应格式写回代理结果
+type responder struct {
+	r         *ghttp.Request
+	envelope  bool   // 是否使用统一响应格式
+	requestID string // 请求ID
+}
+
+// newResponder 确定响应格式与请求ID，请求ID写入响应头
+func newResponder(r *ghttp.Request) *responder {
+	requestID := r.Header.Get(client.RequestIDHeader)
+	if requestID == "" {
+		requestID = guid.S()
+	}
+	r.Response.Header().Set(client.RequestIDHeader, requestID)
+
+	return &responder{
+		r:         r,
+		envelope:  responseFormat(r) == client.FormatEnvelope,
+		requestID: requestID,
+	}
+}
+
+// responseFormat 请求头 X-Response-Format 优先，其次为配置 proxy.responseFormat，默认原样返回
+func responseFormat(r *ghttp.Request) string {
+	switch format := strings.ToLower(r.Header.Get(client.FormatHeader)); format {
+	case client.FormatRaw, client.FormatEnvelope:
+		return format
+	}
+	if v, err := g.Cfg().Get(r.Context(), "proxy.responseFormat"); err == nil && v.String() == client.FormatEnvelope {
+		return client.FormatEnvelope
+	}
+	return client.FormatRaw
+}
+
+// ok 写回框架自身的成功结果，如批量调用任务
+func (w *responder) ok(data interface{}) {
+	if !w.envelope {
+		w.r.Response.WriteJson(g.Map{
+			"code": 200,
+			"data": data,
+		})
+		return
+	}
+
+	raw, _ := json.Marshal(data)
+	w.r.Response.WriteJson(client.Envelope{
+		OK:        true,
+		Code:      client.ErrCodeOK,
+		Data:      raw,
+		Source:    client.SourceFramework,
+		RequestID: w.requestID,
+	})
+}
+
+// fail 写回框架错误
+func (w *responder) fail(e *apiError) {
+	if !w.envelope {
+		resp := g.Map{
+			"code": e.status,
+			"msg":  e.msg,
+		}
+		if e.data != nil {
+			resp["data"] = e.data
+		}
+		w.r.Response.WriteJson(resp)
+		return
+	}
+	w.r.Response.WriteJson(e.envelope(w.requestID))
+}
+
+// success 写回微信接口的响应，统一格式下按返回码区分成功与微信接口错误
+func (w *responder) success(body []byte) {
+	if !w.envelope {
+		w.r.Response.Header().Set("Content-Type", "application/json")
+		w.r.Response.Write(body)
+		return
+	}
+	w.r.Response.WriteJson(wechatEnvelope(body, w.requestID))
+}
+
+// envelope 将框架错误转换为统一响应
+func (e *apiError) envelope(requestID string) client.Envelope {
+	data, _ := json.Marshal(e.data)
+	return client.Envelope{
+		Code:      e.code,
+		Message:   e.msg,
+		Data:      data,
+		Source:    client.SourceFramework,
+		RequestID: requestID,
+	}
+}
+
+// wechatEnvelope 将微信接口的响应转换为统一响应
+func wechatEnvelope(body []byte, requestID string) client.Envelope {
+	var resp client.Response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return newAPIError(ErrBadResponse).envelope(requestID)
+	}
+	env := client.Envelope{
+		OK:         resp.Code == client.CodeOK,
+		Code:       client.ErrCodeOK,
+		Message:    resp.Msg,
+		Data:       resp.Result,
+		Source:     client.SourceWechat,
+		WechatCode: resp.Code,
+		RequestID:  requestID,
+	}
+	if !env.OK {
+		env.Code = client.ErrCodeWechat
+	}
+	if len(env.Data) == 0 {
+		env.Data = json.RawMessage("null")
+	}
+	return env
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/gogf/gf/v2/encoding/gyaml"
+)
+
+// Masked 隐藏后的敏感配置值
+const Masked = "******"
+
+// Redact 隐藏 config.yaml 内容中的令牌与密钥（access.tokens[].token、server.callbackSecret、
+// webhook.subscriptions[].secret），用于向插件等只读调用方提供配置
+func Redact(content []byte) (string, error) {
+	var configMap map[string]interface{}
+	if err := gyaml.DecodeTo(content, &configMap); err != nil {
+		return "", fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	if access, ok := configMap["access"].(map[string]interface{}); ok {
+		maskList(access["tokens"], "token")
+	}
+	if server, ok := configMap["server"].(map[string]interface{}); ok {
+		mask(server, "callbackSecret")
+	}
+	if webhook, ok := configMap["webhook"].(map[string]interface{}); ok {
+		maskList(webhook["subscriptions"], "secret")
+	}
+
+	yamlBytes, err := gyaml.Encode(configMap)
+	if err != nil {
+		return "", fmt.Errorf("配置文件编码失败: %v", err)
+	}
+	return string(yamlBytes), nil
+}
+
+// maskList 隐藏列表中每一项的指定字段
+func maskList(list interface{}, key string) {
+	items, ok := list.([]interface{})
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			mask(m, key)
+		}
+	}
+}
+
+// mask 隐藏非空的字段值
+func mask(m map[string]interface{}, key string) {
+	if v, ok := m[key]; ok && v != nil && fmt.Sprint(v) != "" {
+		m[key] = Masked
+	}
+}
@@ -0,0 +1,618 @@
+package access
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/pkg/client"
+)
+
+const (
+	DefaultTokenPath = "resources/tokens.json"        // 签发令牌的保存路径
+	DefaultGrantPath = "resources/plugin_grants.json" // 插件权限授权记录的保存路径
+	TokenHeader      = "X-Api-Token"                  // 令牌请求头，也可使用 Authorization: Bearer
+	TokenParam       = "token"                        // 令牌查询参数，用于 EventSource/WebSocket
+	ctxKeyToken      = "access.token"                 // 请求上下文中的令牌
+)
+
+// AppOrigins 框架主界面的来源（Wails 页面），为默认允许的跨域来源
+var AppOrigins = []string{
+	"wails://localhost",      // macOS、Linux
+	"http://wails.localhost", // Windows
+}
+
+// 令牌来源
+const (
+	SourceConfig    = "config"    // 配置文件
+	SourceIssued    = "issued"    // 通过管理接口签发
+	SourceApp       = "app"       // 框架主界面
+	SourcePlugin    = "plugin"    // 打开插件窗口时签发
+	SourceAnonymous = "anonymous" // 未启用令牌校验时不携带令牌的请求
+)
+
+// anonymousToken 未启用令牌校验时，不携带有效令牌的请求按只读令牌处理
+var anonymousToken = Token{
+	Name:   "anonymous",
+	Scopes: []string{ScopeRead},
+	Source: SourceAnonymous,
+}
+
+// Token API 令牌
+type Token struct {
+	Name        string    `json:"name"`                  // 令牌名称，如插件ID
+	Token       string    `json:"token"`                 // 令牌值
+	Scopes      []string  `json:"scopes"`                // 权限范围
+	Permissions []string  `json:"permissions,omitempty"` // 插件权限，非空时微信接口与事件按此校验
+	Source      string    `json:"source"`                // 来源
+	CreatedAt   time.Time `json:"createdAt"`             // 创建时间
+}
+
+// HasScope 判断令牌是否拥有指定权限
+func (t *Token) HasScope(scope string) bool {
+	return HasScope(t.Scopes, scope)
+}
+
+// Config 访问控制配置
+type Config struct {
+	Enabled      bool     `json:"enabled"`      // 是否要求令牌
+	AllowIPs     []string `json:"allowIps"`     // 允许访问的 IP 或网段，为空表示不限制
+	AllowOrigins []string `json:"allowOrigins"` // 允许跨域的来源，* 表示全部（不携带凭据）
+	Tokens       []Token  `json:"tokens"`       // 配置文件中的令牌
+	TokenPath    string   `json:"tokenPath"`    // 签发令牌的保存路径
+	GrantPath    string   `json:"grantPath"`    // 插件权限授权记录的保存路径
+}
+
+// Manager 访问控制管理器
+// 负责 CORS 来源策略、IP 白名单以及带权限范围的 API 令牌校验。
+type Manager struct {
+	cfg      Config
+	nets     []*net.IPNet
+	exempt   []string // 不校验令牌的路径前缀
+	appToken Token
+
+	mu      sync.RWMutex
+	issued  []Token
+	plugins map[string]Token    // 插件ID -> 插件窗口令牌，仅保存在内存中
+	grants  map[string][]string // 插件ID -> 用户已授权的权限
+}
+
+var (
+	defaultManager     *Manager
+	defaultManagerOnce sync.Once
+)
+
+// Default 获取按配置文件创建的全局访问控制管理器，供旧版服务共用
+func Default() *Manager {
+	defaultManagerOnce.Do(func() {
+		ctx := gctx.New()
+		m, err := NewManager(LoadConfig(ctx))
+		if err != nil {
+			g.Log().Errorf(ctx, "访问控制初始化失败，令牌校验已关闭: %v", err)
+			m, _ = NewManager(Config{})
+		}
+		defaultManager = m
+	})
+	return defaultManager
+}
+
+// LoadConfig 从配置文件读取访问控制配置（access.*）
+func LoadConfig(ctx context.Context) Config {
+	var cfg Config
+	if v, err := g.Cfg().Get(ctx, "access"); err == nil && !v.IsNil() {
+		if err := gconv.Struct(v.Map(), &cfg); err != nil {
+			g.Log().Warningf(ctx, "访问控制配置解析失败: %v", err)
+		}
+	}
+	return cfg
+}
+
+// NewManager 创建访问控制管理器
+func NewManager(cfg Config) (*Manager, error) {
+	if cfg.TokenPath == "" {
+		cfg.TokenPath = DefaultTokenPath
+	}
+	if cfg.GrantPath == "" {
+		cfg.GrantPath = DefaultGrantPath
+	}
+	if len(cfg.AllowOrigins) == 0 {
+		cfg.AllowOrigins = AppOrigins
+	}
+
+	m := &Manager{
+		cfg:     cfg,
+		plugins: make(map[string]Token),
+		grants:  make(map[string][]string),
+	}
+
+	for _, item := range cfg.AllowIPs {
+		ipNet, err := parseIPNet(item)
+		if err != nil {
+			return nil, err
+		}
+		m.nets = append(m.nets, ipNet)
+	}
+
+	for i := range cfg.Tokens {
+		if !validScopes(cfg.Tokens[i].Scopes) {
+			return nil, fmt.Errorf("令牌 %s 的权限范围无效: %v", cfg.Tokens[i].Name, cfg.Tokens[i].Scopes)
+		}
+		cfg.Tokens[i].Source = SourceConfig
+	}
+
+	// 主界面令牌每次启动重新生成，仅保存在内存中
+	m.appToken = Token{
+		Name:      "app",
+		Token:     newTokenValue(),
+		Scopes:    []string{ScopeAll},
+		Source:    SourceApp,
+		CreatedAt: time.Now(),
+	}
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Enabled 是否要求令牌
+func (m *Manager) Enabled() bool {
+	return m.cfg.Enabled
+}
+
+// AppToken 获取框架主界面使用的令牌
+func (m *Manager) AppToken() string {
+	return m.appToken.Token
+}
+
+// Exempt 设置不校验令牌的路径前缀（如微信回调、插件静态文件），IP 白名单仍然生效
+func (m *Manager) Exempt(prefixes ...string) {
+	m.exempt = append(m.exempt, prefixes...)
+}
+
+// List 获取全部令牌，令牌值一律隐藏；签发的令牌值只在 Issue 时返回一次
+func (m *Manager) List() []Token {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]Token, 0, len(m.cfg.Tokens)+len(m.issued)+len(m.plugins))
+	list = append(list, m.cfg.Tokens...)
+	list = append(list, m.issued...)
+	for _, t := range m.plugins {
+		list = append(list, t)
+	}
+	for i := range list {
+		list[i].Token = mask(list[i].Token)
+	}
+	return list
+}
+
+// Issue 签发令牌，同名令牌会被替换；返回值中的令牌值需由调用方保存，之后无法再次获取
+func (m *Manager) Issue(name string, scopes []string) (Token, error) {
+	if name == "" {
+		return Token{}, fmt.Errorf("令牌名称不能为空")
+	}
+	if len(scopes) == 0 || !validScopes(scopes) {
+		return Token{}, fmt.Errorf("权限范围无效: %v", scopes)
+	}
+
+	token := Token{
+		Name:      name,
+		Token:     newTokenValue(),
+		Scopes:    scopes,
+		Source:    SourceIssued,
+		CreatedAt: time.Now(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	issued := make([]Token, 0, len(m.issued)+1)
+	for _, t := range m.issued {
+		if t.Name != name {
+			issued = append(issued, t)
+		}
+	}
+	issued = append(issued, token)
+
+	if err := writeJSON(m.cfg.TokenPath, issued); err != nil {
+		return Token{}, err
+	}
+	m.issued = issued
+	return token, nil
+}
+
+// Revoke 吊销签发的令牌
+func (m *Manager) Revoke(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	issued := make([]Token, 0, len(m.issued))
+	for _, t := range m.issued {
+		if t.Name != name {
+			issued = append(issued, t)
+		}
+	}
+	if len(issued) == len(m.issued) {
+		return fmt.Errorf("令牌不存在: %s", name)
+	}
+
+	if err := writeJSON(m.cfg.TokenPath, issued); err != nil {
+		return err
+	}
+	m.issued = issued
+	return nil
+}
+
+// Lookup 根据令牌值查找令牌
+func (m *Manager) Lookup(value string) (*Token, bool) {
+	if value == "" {
+		return nil, false
+	}
+	if equal(value, m.appToken.Token) {
+		t := m.appToken
+		return &t, true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, list := range [][]Token{m.cfg.Tokens, m.issued} {
+		for i := range list {
+			if equal(value, list[i].Token) {
+				t := list[i]
+				return &t, true
+			}
+		}
+	}
+	for _, t := range m.plugins {
+		if equal(value, t.Token) {
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+// Approved 判断插件申请的权限是否均已经用户授权
+func (m *Manager) Approved(pluginID string, permissions []string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	granted, ok := m.grants[pluginID]
+	if !ok {
+		return false
+	}
+	for _, p := range permissions {
+		if !contains(granted, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Approve 记录用户对插件权限的授权
+func (m *Manager) Approve(pluginID string, permissions []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	grants := make(map[string][]string, len(m.grants)+1)
+	for k, v := range m.grants {
+		grants[k] = v
+	}
+	grants[pluginID] = append([]string{}, permissions...)
+
+	if err := writeJSON(m.cfg.GrantPath, grants); err != nil {
+		return err
+	}
+	m.grants = grants
+	return nil
+}
+
+// IssuePlugin 为插件窗口签发令牌，插件权限须已授权，同一插件的旧令牌会失效
+func (m *Manager) IssuePlugin(pluginID string, permissions []string) (Token, error) {
+	if !m.Approved(pluginID, permissions) {
+		return Token{}, fmt.Errorf("插件权限未授权: %s", pluginID)
+	}
+
+	token := Token{
+		Name:        "plugin:" + pluginID,
+		Token:       newTokenValue(),
+		Scopes:      []string{ScopeRead},
+		Permissions: append([]string{}, permissions...),
+		Source:      SourcePlugin,
+		CreatedAt:   time.Now(),
+	}
+
+	m.mu.Lock()
+	m.plugins[pluginID] = token
+	m.mu.Unlock()
+	return token, nil
+}
+
+// PluginToken 获取插件窗口当前的令牌
+func (m *Manager) PluginToken(pluginID string) (*Token, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.plugins[pluginID]
+	if !ok {
+		return nil, false
+	}
+	return &t, true
+}
+
+// RevokePlugin 吊销插件窗口令牌，forget 为 true 时同时清除授权记录（卸载插件时）
+func (m *Manager) RevokePlugin(pluginID string, forget bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.plugins, pluginID)
+	if !forget {
+		return nil
+	}
+	if _, ok := m.grants[pluginID]; !ok {
+		return nil
+	}
+
+	grants := make(map[string][]string, len(m.grants))
+	for k, v := range m.grants {
+		if k != pluginID {
+			grants[k] = v
+		}
+	}
+	if err := writeJSON(m.cfg.GrantPath, grants); err != nil {
+		return err
+	}
+	m.grants = grants
+	return nil
+}
+
+// FromRequest 获取中间件校验通过的令牌，未启用令牌校验且未携带令牌时为只读的匿名令牌，免令牌路径返回 nil
+func FromRequest(r *ghttp.Request) *Token {
+	if t, ok := r.GetCtxVar(ctxKeyToken).Interface().(*Token); ok {
+		return t
+	}
+	return nil
+}
+
+// Middleware 访问控制中间件，替代 ghttp.MiddlewareCORS
+func (m *Manager) Middleware(r *ghttp.Request) {
+	ctx := r.Context()
+
+	// 跨域来源策略，同源请求（如插件页面）不受限制。
+	// 列表中的来源原样返回并允许携带凭据，* 只返回字面量 *，不允许携带凭据
+	if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(r, origin) {
+		allowed, listed := m.allowOrigin(origin)
+		if !allowed {
+			g.Log().Warningf(ctx, "拒绝跨域请求 %s %s，来源: %s", r.Method, r.URL.Path, origin)
+			m.deny(r, http.StatusForbidden, "来源不允许")
+			return
+		}
+		header := r.Response.Header()
+		if listed {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+			header.Add("Vary", "Origin")
+		} else {
+			header.Set("Access-Control-Allow-Origin", "*")
+		}
+		header.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		header.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+TokenHeader+", Last-Event-ID, "+client.FormatHeader+", "+client.RequestIDHeader)
+		header.Set("Access-Control-Expose-Headers", client.RequestIDHeader)
+		header.Set("Access-Control-Max-Age", "3600")
+	}
+	if r.Method == http.MethodOptions {
+		r.Response.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// IP 白名单
+	if ip := r.GetClientIp(); !m.allowIP(ip) {
+		g.Log().Warningf(ctx, "拒绝访问 %s %s，IP 不在白名单: %s", r.Method, r.URL.Path, ip)
+		m.deny(r, http.StatusForbidden, "IP 不允许")
+		return
+	}
+
+	if m.isExempt(r.URL.Path) {
+		r.Middleware.Next()
+		return
+	}
+
+	// 令牌与权限。未启用令牌校验时，不携带有效令牌的请求只能访问只读接口，
+	// 发送、管理、转账类接口与事件流仍需令牌，插件无法通过丢弃窗口令牌绕过权限声明
+	token, ok := m.Lookup(requestToken(r))
+	if !ok {
+		if m.cfg.Enabled || IsStreamPath(r.URL.Path) {
+			m.deny(r, http.StatusUnauthorized, "缺少或无效的令牌")
+			return
+		}
+		t := anonymousToken
+		token = &t
+	}
+	if perm, ok := token.allowPath(r.Method, r.URL.Path); !ok {
+		if token.Source == SourceAnonymous {
+			g.Log().Warningf(ctx, "拒绝未携带令牌的请求 %s %s，需要权限: %s", r.Method, r.URL.Path, perm)
+			m.deny(r, http.StatusUnauthorized, "缺少或无效的令牌")
+			return
+		}
+		g.Log().Warningf(ctx, "令牌 %s 缺少权限 %s: %s", token.Name, perm, r.URL.Path)
+		m.deny(r, http.StatusForbidden, "缺少权限: "+perm)
+		return
+	}
+
+	r.SetCtxVar(ctxKeyToken, token)
+	r.Middleware.Next()
+}
+
+// deny 拒绝请求
+func (m *Manager) deny(r *ghttp.Request, status int, msg string) {
+	r.Response.WriteHeader(status)
+	r.Response.WriteJson(g.Map{
+		"code": status,
+		"msg":  msg,
+	})
+}
+
+// allowOrigin 判断跨域来源是否允许，listed 表示来源在列表中（而非通过 * 允许）
+func (m *Manager) allowOrigin(origin string) (allowed, listed bool) {
+	for _, item := range m.cfg.AllowOrigins {
+		if strings.EqualFold(item, origin) {
+			return true, true
+		}
+		if item == "*" {
+			allowed = true
+		}
+	}
+	return allowed, false
+}
+
+// sameOrigin 判断请求来源是否与服务地址相同
+func sameOrigin(r *ghttp.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Scheme == "http" && strings.EqualFold(u.Host, r.Host)
+}
+
+// allowIP 判断客户端 IP 是否在白名单中
+func (m *Manager) allowIP(ip string) bool {
+	if len(m.nets) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range m.nets {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExempt 判断路径是否免令牌校验
+func (m *Manager) isExempt(path string) bool {
+	for _, prefix := range m.exempt {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// load 读取签发的令牌与插件授权记录
+func (m *Manager) load() error {
+	if err := readJSON(m.cfg.TokenPath, &m.issued); err != nil {
+		return fmt.Errorf("读取令牌文件失败: %v", err)
+	}
+	if err := readJSON(m.cfg.GrantPath, &m.grants); err != nil {
+		return fmt.Errorf("读取插件授权记录失败: %v", err)
+	}
+	if m.grants == nil {
+		m.grants = make(map[string][]string)
+	}
+	return nil
+}
+
+// readJSON 读取 JSON 文件，文件不存在或为空时保持原值
+func readJSON(path string, v interface{}) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && len(content) == 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+// writeJSON 保存 JSON 文件，先写临时文件再替换
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// requestToken 从请求头或查询参数读取令牌
+func requestToken(r *ghttp.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if token := r.Header.Get(TokenHeader); token != "" {
+		return token
+	}
+	return r.GetQuery(TokenParam).String()
+}
+
+// parseIPNet 解析 IP 或 CIDR 网段
+func parseIPNet(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("IP 白名单格式错误: %s", value)
+		}
+		return ipNet, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("IP 白名单格式错误: %s", value)
+	}
+	bits := 32
+	if ip.To4() == nil {
+		bits = 128
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// newTokenValue 生成随机令牌值
+func newTokenValue() string {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// contains 判断列表是否包含指定值
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// equal 常量时间比较令牌
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// mask 隐藏令牌值
+func mask(value string) string {
+	if len(value) <= 8 {
+		return "******"
+	}
+	return value[:4] + "******" + value[len(value)-4:]
+}
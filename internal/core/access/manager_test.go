@@ -0,0 +1,151 @@
+package access
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+func newTestManager(t *testing.T, cfg Config) *Manager {
+	t.Helper()
+	dir := t.TempDir()
+	cfg.TokenPath = filepath.Join(dir, "tokens.json")
+	cfg.GrantPath = filepath.Join(dir, "plugin_grants.json")
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// startTestServer 启动挂载访问控制中间件的服务，返回服务地址
+func startTestServer(t *testing.T, m *Manager) string {
+	t.Helper()
+	s := g.Server(fmt.Sprintf("access-test-%s-%d", t.Name(), time.Now().UnixNano()))
+	s.SetAddr("127.0.0.1:0")
+	s.SetDumpRouterMap(false)
+	s.Group("/", func(group *ghttp.RouterGroup) {
+		group.Middleware(m.Middleware)
+		group.ALL("/api/*", func(r *ghttp.Request) {
+			r.Response.Write("ok")
+		})
+	})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Shutdown() })
+	return fmt.Sprintf("http://127.0.0.1:%d", s.GetListenedPort())
+}
+
+func TestListMasksEveryTokenValue(t *testing.T) {
+	m := newTestManager(t, Config{Tokens: []Token{{Name: "bot", Token: "config-token-value", Scopes: []string{ScopeRead}}}})
+	issued, err := m.Issue("tool", []string{ScopeSend})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Approve("demo", []string{"sendText"}); err != nil {
+		t.Fatal(err)
+	}
+	plugin, err := m.IssuePlugin("demo", []string{"sendText"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]string{
+		"bot":         "config-token-value",
+		"tool":        issued.Token,
+		"plugin:demo": plugin.Token,
+	}
+	list := m.List()
+	if len(list) != len(values) {
+		t.Fatalf("令牌数 = %d, 期望 %d", len(list), len(values))
+	}
+	for _, tk := range list {
+		if tk.Token == values[tk.Name] || !strings.Contains(tk.Token, "******") {
+			t.Errorf("令牌 %s (%s) 的值未隐藏: %s", tk.Name, tk.Source, tk.Token)
+		}
+	}
+
+	// 签发时返回的令牌值可用于访问
+	if tk, ok := m.Lookup(issued.Token); !ok || tk.Name != "tool" {
+		t.Fatal("签发的令牌值无法使用")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tokens := []Token{
+		{Name: "reader", Token: "reader-token-0123456789", Scopes: []string{ScopeRead}},
+		{Name: "sender", Token: "sender-token-0123456789", Scopes: []string{ScopeRead, ScopeSend}},
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		method  string
+		path    string
+		token   string
+		origin  string
+		status  int
+		allowed string // 期望的 Access-Control-Allow-Origin
+	}{
+		{name: "未启用校验时匿名只读", path: "/api/wechat/getSelfInfo", status: http.StatusOK},
+		{name: "未启用校验时匿名发送", path: "/api/wechat/sendText", status: http.StatusUnauthorized},
+		{name: "未启用校验时匿名订阅事件流", path: "/api/plugin/events", status: http.StatusUnauthorized},
+		{name: "只读令牌发送", path: "/api/wechat/sendText", token: "reader-token-0123456789", status: http.StatusForbidden},
+		{name: "发送令牌发送", path: "/api/wechat/sendText", token: "sender-token-0123456789", status: http.StatusOK},
+		{name: "启用校验时缺少令牌", cfg: Config{Enabled: true}, path: "/api/wechat/getSelfInfo", status: http.StatusUnauthorized},
+		{name: "启用校验时无效令牌", cfg: Config{Enabled: true}, path: "/api/wechat/getSelfInfo", token: "bad", status: http.StatusUnauthorized},
+		{name: "启用校验时有效令牌", cfg: Config{Enabled: true}, path: "/api/wechat/getSelfInfo", token: "reader-token-0123456789", status: http.StatusOK},
+		{name: "IP 不在白名单", cfg: Config{AllowIPs: []string{"10.0.0.0/8"}}, path: "/api/wechat/getSelfInfo", status: http.StatusForbidden},
+		{name: "IP 在白名单", cfg: Config{AllowIPs: []string{"127.0.0.1"}}, path: "/api/wechat/getSelfInfo", status: http.StatusOK},
+		{name: "主界面来源", path: "/api/wechat/getSelfInfo", origin: "wails://localhost", status: http.StatusOK, allowed: "wails://localhost"},
+		{name: "不允许的来源", path: "/api/wechat/getSelfInfo", origin: "http://evil.example", status: http.StatusForbidden},
+		{name: "允许全部来源", cfg: Config{AllowOrigins: []string{"*"}}, path: "/api/wechat/getSelfInfo", origin: "http://evil.example", status: http.StatusOK, allowed: "*"},
+		{name: "预检请求", method: http.MethodOptions, path: "/api/wechat/sendText", origin: "wails://localhost", status: http.StatusNoContent, allowed: "wails://localhost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Tokens = append([]Token{}, tokens...)
+			base := startTestServer(t, newTestManager(t, cfg))
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req, err := http.NewRequest(method, base+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.token != "" {
+				req.Header.Set(TokenHeader, tt.token)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("状态码 = %d, 期望 %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get("Access-Control-Allow-Origin"); got != tt.allowed {
+				t.Fatalf("Access-Control-Allow-Origin = %q, 期望 %q", got, tt.allowed)
+			}
+			credentials := resp.Header.Get("Access-Control-Allow-Credentials") == "true"
+			if want := tt.allowed != "" && tt.allowed != "*"; credentials != want {
+				t.Fatalf("允许携带凭据 = %v, 期望 %v", credentials, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package access
+
+import (
+	"strings"
+
+	"github.com/naidog/wechat-framework/pkg/client"
+)
+
+// 插件权限，在 plugin.json 的 permissions 中声明
+const (
+	PermAll       = "*"        // 除敏感接口外的全部微信接口
+	PermAllEvents = "events:*" // 全部事件
+	EventPrefix   = "events:"  // 事件权限前缀，如 events:recvMsg
+)
+
+// DefaultPluginPermissions 未声明 permissions 的插件获得的权限
+var DefaultPluginPermissions = []string{PermAll, PermAllEvents}
+
+// 敏感接口：转账与删除好友，插件必须在 permissions 中逐个声明并经用户授权（按规范名称，别名同样生效）
+var sensitiveAPIs = map[string]bool{
+	"confirmTrans": true,
+	"returnTrans":  true,
+	"delFriend":    true,
+}
+
+// IsSensitive 判断接口或权限是否为敏感权限
+func IsSensitive(permission string) bool {
+	return sensitiveAPIs[client.Canonical(permission)]
+}
+
+// PluginPermissions 获取插件实际申请的权限，未声明时使用默认权限
+func PluginPermissions(declared []string) []string {
+	if declared == nil {
+		return DefaultPluginPermissions
+	}
+	permissions := make([]string, 0, len(declared))
+	for _, p := range declared {
+		if p = strings.TrimSpace(p); p != "" {
+			permissions = append(permissions, p)
+		}
+	}
+	return permissions
+}
+
+// AllowAPI 判断令牌能否调用微信接口
+// 带插件权限的令牌按权限列表校验，其余令牌按权限范围校验；接口名与权限中的别名均按规范名称比较
+func (t *Token) AllowAPI(apiType string) bool {
+	apiType = client.Canonical(apiType)
+	if t.Permissions == nil {
+		return t.HasScope(ScopeForAPI(apiType))
+	}
+	for _, p := range t.Permissions {
+		if client.Canonical(p) == apiType || (p == PermAll && !sensitiveAPIs[apiType]) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowEvent 判断令牌能否接收指定类型的事件
+func (t *Token) AllowEvent(eventType string) bool {
+	if t.Permissions == nil {
+		return t.HasScope(ScopeRead)
+	}
+	for _, p := range t.Permissions {
+		if p == PermAllEvents || p == EventPrefix+eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// allowPath 判断令牌能否访问 HTTP 路径，拒绝时返回缺少的权限；
+// 以下划线开头的路径（如 /api/wechat/_routes）不是微信接口，按权限范围校验
+func (t *Token) allowPath(method, path string) (string, bool) {
+	if apiType, ok := strings.CutPrefix(strings.TrimSuffix(path, "/"), "/api/wechat/"); ok && !strings.HasPrefix(apiType, "_") {
+		if t.Permissions != nil {
+			return apiType, t.AllowAPI(apiType)
+		}
+	}
+	scope := ScopeForPath(method, path)
+	return scope, t.HasScope(scope)
+}
@@ -0,0 +1,108 @@
+package access
+
+import (
+	"strings"
+
+	"github.com/naidog/wechat-framework/pkg/client"
+)
+
+// 令牌权限范围
+const (
+	ScopeRead   = "read"   // 只读：查询信息、监听事件
+	ScopeSend   = "send"   // 发送与撤回消息
+	ScopeManage = "manage" // 管理好友、群聊与框架设置
+	ScopeMoney  = "money"  // 转账相关操作
+	ScopeAll    = "*"      // 全部权限
+)
+
+// Scopes 全部可分配的权限范围
+var Scopes = []string{ScopeRead, ScopeSend, ScopeManage, ScopeMoney}
+
+// 转账相关接口
+var moneyAPIs = map[string]bool{
+	"confirmTrans": true,
+	"returnTrans":  true,
+}
+
+// 发送前缀以外的消息类接口
+var sendAPIs = map[string]bool{
+	"forwardMsg":  true,
+	"revokeMyMsg": true,
+}
+
+// 查询前缀以外的只读接口
+var readAPIs = map[string]bool{
+	"decryptImage":  true,
+	"downloadImage": true,
+	"downloadFile":  true,
+}
+
+// 事件流路径，未启用令牌校验时也必须携带令牌
+var streamPaths = map[string]bool{
+	"/api/plugin/events": true,
+	"/api/plugin/ws":     true,
+}
+
+// IsStreamPath 判断 HTTP 路径是否为事件流（SSE / WebSocket）
+func IsStreamPath(path string) bool {
+	return streamPaths[strings.TrimSuffix(path, "/")]
+}
+
+// ScopeForAPI 获取调用微信接口所需的权限范围，别名按规范名称处理，未知接口按管理权限处理
+func ScopeForAPI(apiType string) string {
+	apiType = client.Canonical(apiType)
+	switch {
+	case moneyAPIs[apiType]:
+		return ScopeMoney
+	case strings.HasPrefix(apiType, "send"), sendAPIs[apiType]:
+		return ScopeSend
+	case readAPIs[apiType]:
+		return ScopeRead
+	}
+	for _, prefix := range []string{"get", "query", "check", "search"} {
+		if strings.HasPrefix(apiType, prefix) {
+			return ScopeRead
+		}
+	}
+	return ScopeManage
+}
+
+// ScopeForPath 获取访问 HTTP 路径所需的权限范围
+func ScopeForPath(method, path string) string {
+	path = strings.TrimSuffix(path, "/")
+	switch {
+	case path == "/api/wechat/_routes", strings.HasPrefix(path, "/api/wechat/_batch"):
+		// 批量调用按各操作的接口单独校验权限
+		return ScopeRead
+	case strings.HasPrefix(path, "/api/wechat/"):
+		return ScopeForAPI(strings.TrimPrefix(path, "/api/wechat/"))
+	case path == "/api/plugin/upload":
+		return ScopeManage
+	case strings.HasPrefix(path, "/api/webhooks") && method != "GET":
+		return ScopeManage
+	case strings.HasPrefix(path, "/api/processes") && method != "GET":
+		return ScopeManage
+	default:
+		return ScopeRead
+	}
+}
+
+// HasScope 判断权限列表是否包含指定权限
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// validScopes 校验权限范围是否合法
+func validScopes(scopes []string) bool {
+	for _, s := range scopes {
+		if s != ScopeAll && !HasScope(Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,373 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/internal/core/dll"
+	"github.com/naidog/wechat-framework/pkg/client"
+)
+
+// 心跳检测接口
+const (
+	ProbeLoginStatus = "loginStatus" // getLoginStatus，只确认微信在线且已登录
+	ProbeAuthInfo    = "authInfo"    // getAuthInfo，同时刷新授权信息
+)
+
+const (
+	DefaultHeartbeatDelay    = 15 * time.Second // 默认启动后首次检测前的等待时间
+	DefaultHeartbeatInterval = 2 * time.Second  // 默认检测间隔
+	DefaultHeartbeatFailures = 3                // 默认连续失败多少次后判定微信已退出
+	DefaultHeartbeatBackoff  = 30 * time.Second // 默认检测失败后的最大检测间隔
+	DefaultAuthInterval      = 5 * time.Minute  // 默认刷新授权信息的间隔
+	healthHistorySize        = 20               // 每个实例保留的最近检测记录条数
+)
+
+var (
+	heartbeatOnce    sync.Once
+	defaultHeartbeat *Heartbeat
+)
+
+// HeartbeatConfig 心跳检测配置（heartbeat.*）
+type HeartbeatConfig struct {
+	Delay        time.Duration `json:"delay"`        // 启动后首次检测前的等待时间
+	Interval     time.Duration `json:"interval"`     // 检测间隔
+	Timeout      time.Duration `json:"timeout"`      // 单次检测超时，为 0 时使用 dll.timeouts 中该接口的超时
+	Failures     int           `json:"failures"`     // 连续失败多少次后判定微信已退出
+	MaxBackoff   time.Duration `json:"maxBackoff"`   // 检测失败后间隔按连续失败次数翻倍，最大不超过此值
+	Probe        string        `json:"probe"`        // 存活检测接口：loginStatus 或 authInfo
+	AuthInterval time.Duration `json:"authInterval"` // probe 为 loginStatus 时刷新授权信息的间隔
+}
+
+// LoadHeartbeatConfig 从配置文件读取心跳检测配置（heartbeat.*）
+func LoadHeartbeatConfig(ctx context.Context) HeartbeatConfig {
+	var cfg HeartbeatConfig
+	if v, err := g.Cfg().Get(ctx, "heartbeat"); err == nil && !v.IsNil() {
+		if err := gconv.Struct(v.Map(), &cfg); err != nil {
+			g.Log().Warningf(ctx, "heartbeat 配置解析失败: %v", err)
+		}
+	}
+	return cfg
+}
+
+// HealthSample 一次检测记录
+type HealthSample struct {
+	At        time.Time `json:"at"`              // 检测时间
+	Probe     string    `json:"probe"`           // 检测接口
+	OK        bool      `json:"ok"`              // 是否成功
+	LatencyMs float64   `json:"latencyMs"`       // 耗时（毫秒）
+	Error     string    `json:"error,omitempty"` // 失败原因
+}
+
+// Health 微信实例的心跳检测统计
+type Health struct {
+	Port         int            `json:"port"`                  // 微信端口
+	Wxid         string         `json:"wxid,omitempty"`        // 检测时端口上的账号
+	Checks       uint64         `json:"checks"`                // 累计检测次数
+	Errors       uint64         `json:"errors"`                // 累计失败次数
+	Failures     int            `json:"failures"`              // 当前连续失败次数
+	AvgLatencyMs float64        `json:"avgLatencyMs"`          // 平均耗时（毫秒）
+	LastSuccess  *time.Time     `json:"lastSuccess,omitempty"` // 最近一次成功时间
+	LastFailure  *time.Time     `json:"lastFailure,omitempty"` // 最近一次失败时间
+	LastError    string         `json:"lastError,omitempty"`   // 最近一次失败原因
+	NextCheck    time.Time      `json:"nextCheck"`             // 下次检测时间，失败后按退避推迟
+	History      []HealthSample `json:"history"`               // 最近的检测记录
+}
+
+// health 单个端口的检测状态
+type health struct {
+	Health
+	total    time.Duration
+	lastAuth time.Time // 最近一次成功刷新授权信息的时间
+}
+
+// Heartbeat 心跳检测：定期检测注册表中的微信实例，连续失败达到阈值后判定微信已退出，
+// 失败期间按退避推迟下次检测，避免高负载时账号在列表中反复出现和消失
+type Heartbeat struct {
+	cfg      HeartbeatConfig
+	registry *Registry
+	client   *client.Client
+	checkMu  sync.Mutex // 定时检测与登录后的强制检测可能同时触发，逐轮执行
+	mu       sync.Mutex
+	ports    map[int]*health
+}
+
+// DefaultHeartbeat 获取框架共用账号注册表的心跳检测，配置读取自 heartbeat.*
+func DefaultHeartbeat() *Heartbeat {
+	heartbeatOnce.Do(func() {
+		ctx := gctx.New()
+		defaultHeartbeat = NewHeartbeat(Default(), LoadHeartbeatConfig(ctx))
+	})
+	return defaultHeartbeat
+}
+
+// NewHeartbeat 创建心跳检测，未配置的项使用默认值
+func NewHeartbeat(registry *Registry, cfg HeartbeatConfig) *Heartbeat {
+	if cfg.Delay < 0 {
+		cfg.Delay = 0
+	} else if cfg.Delay == 0 {
+		cfg.Delay = DefaultHeartbeatDelay
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultHeartbeatInterval
+	}
+	if cfg.Failures <= 0 {
+		cfg.Failures = DefaultHeartbeatFailures
+	}
+	if cfg.MaxBackoff < cfg.Interval {
+		cfg.MaxBackoff = max(DefaultHeartbeatBackoff, cfg.Interval)
+	}
+	if cfg.Probe != ProbeAuthInfo {
+		cfg.Probe = ProbeLoginStatus
+	}
+	if cfg.AuthInterval <= 0 {
+		cfg.AuthInterval = DefaultAuthInterval
+	}
+
+	return &Heartbeat{
+		cfg:      cfg,
+		registry: registry,
+		client:   dll.Client(),
+		ports:    make(map[int]*health),
+	}
+}
+
+// Config 获取生效的心跳检测配置
+func (h *Heartbeat) Config() HeartbeatConfig {
+	return h.cfg
+}
+
+// Run 等待 Delay 后按 Interval 定期检测，直到 ctx 结束
+func (h *Heartbeat) Run(ctx context.Context) {
+	g.Log().Infof(ctx, "启动微信心跳检测服务: 间隔 %s, 连续失败 %d 次判定退出, 检测接口 %s", h.cfg.Interval, h.cfg.Failures, h.cfg.Probe)
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(h.cfg.Delay):
+	}
+
+	ticker := time.NewTicker(h.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			g.Log().Info(ctx, "心跳检测服务已停止")
+			return
+		case <-ticker.C:
+			h.Check(ctx, false)
+		}
+	}
+}
+
+// probeTarget 一次心跳要检测的实例
+type probeTarget struct {
+	port   int
+	wxid   string
+	online bool // 已登录的账号，未登录的实例只查询登录状态
+}
+
+// targets 获取需要心跳检测的实例，刚启动尚未注入的实例不检测
+func (r *Registry) targets() []probeTarget {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]probeTarget, 0, len(r.instances))
+	for port, in := range r.instances {
+		switch in.State {
+		case StateOnline, StateUnresponsive, StateExpired:
+			list = append(list, probeTarget{port: port, wxid: in.Wxid, online: true})
+		case StateInjected, StateAwaitingLogin:
+			list = append(list, probeTarget{port: port, wxid: in.Wxid})
+		}
+	}
+	return list
+}
+
+// Check 并发检测一轮并更新账号状态。force 为 true 时忽略退避，已登录的账号都刷新授权信息。
+// 请求失败但连续失败次数未达到阈值时只标记为无响应，等待下次检测
+func (h *Heartbeat) Check(ctx context.Context, force bool) {
+	h.checkMu.Lock()
+	defer h.checkMu.Unlock()
+
+	targets := h.due(h.registry.targets(), force)
+	if len(targets) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make(map[int]Probe, len(targets))
+
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target probeTarget) {
+			defer wg.Done()
+			p := h.probe(ctx, target, force)
+			mu.Lock()
+			results[target.port] = p
+			mu.Unlock()
+		}(target)
+	}
+
+	wg.Wait()
+
+	if err := h.registry.ApplyProbes(ctx, results); err != nil {
+		g.Log().Warningf(ctx, "更新账号列表失败: %v", err)
+	}
+}
+
+// due 筛选到达检测时间的实例，同时清理已不需要检测的端口
+func (h *Heartbeat) due(targets []probeTarget, force bool) []probeTarget {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	active := make(map[int]bool, len(targets))
+	list := targets[:0]
+	for _, target := range targets {
+		active[target.port] = true
+		st, ok := h.ports[target.port]
+		if ok && st.Wxid != target.wxid {
+			// 端口上换了账号或重新启动，重新统计
+			ok = false
+		}
+		if !ok {
+			st = &health{Health: Health{Port: target.port, Wxid: target.wxid}}
+			h.ports[target.port] = st
+		}
+		if force || !now.Before(st.NextCheck) {
+			list = append(list, target)
+		}
+	}
+	for port := range h.ports {
+		if !active[port] {
+			delete(h.ports, port)
+		}
+	}
+	return list
+}
+
+// probe 检测单个实例：未登录的实例与 probe 为 loginStatus 的账号查询登录状态，
+// 需要刷新授权信息时查询授权信息
+func (h *Heartbeat) probe(ctx context.Context, target probeTarget, force bool) Probe {
+	var lastAuth time.Time
+	h.mu.Lock()
+	if st, ok := h.ports[target.port]; ok {
+		lastAuth = st.lastAuth
+	}
+	h.mu.Unlock()
+
+	kind := ProbeLoginStatus
+	if target.online && (force || h.cfg.Probe == ProbeAuthInfo || time.Since(lastAuth) >= h.cfg.AuthInterval) {
+		kind = ProbeAuthInfo
+	}
+
+	if h.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.cfg.Timeout)
+		defer cancel()
+	}
+
+	p := Probe{Wxid: target.wxid}
+	start := time.Now()
+	if kind == ProbeAuthInfo {
+		p.Auth, p.Err = h.client.GetAuthInfo(ctx, target.port)
+	} else if status, err := h.client.GetLoginStatus(ctx, target.port); err != nil {
+		p.Err = err
+	} else {
+		loggedIn := status.IsLogin == 1
+		p.Login = &loggedIn
+	}
+	elapsed := time.Since(start)
+
+	if p.Err != nil && errors.Is(p.Err, context.Canceled) && ctx.Err() != nil {
+		// 服务停止，不计入结果
+		p.Err = nil
+		p.Auth, p.Login = nil, nil
+		return p
+	}
+
+	p.Down = h.record(target.port, kind, elapsed, p.Err)
+	if p.Err != nil {
+		g.Log().Debugf(ctx, "心跳检测失败 (port:%d): %v", target.port, p.Err)
+	}
+	return p
+}
+
+// record 记录检测结果并计算下次检测时间，返回连续失败次数是否达到阈值
+func (h *Heartbeat) record(port int, kind string, elapsed time.Duration, err error) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st, ok := h.ports[port]
+	if !ok {
+		return false
+	}
+
+	now := time.Now()
+	sample := HealthSample{At: now, Probe: kind, OK: err == nil, LatencyMs: ms(elapsed)}
+	st.Checks++
+	st.total += elapsed
+	st.AvgLatencyMs = ms(st.total / time.Duration(st.Checks))
+
+	if err == nil {
+		st.Failures = 0
+		st.LastSuccess = &now
+		if kind == ProbeAuthInfo {
+			st.lastAuth = now
+		}
+	} else {
+		st.Errors++
+		st.Failures++
+		st.LastFailure = &now
+		st.LastError = err.Error()
+		sample.Error = err.Error()
+	}
+	st.History = append(st.History, sample)
+	if len(st.History) > healthHistorySize {
+		st.History = st.History[len(st.History)-healthHistorySize:]
+	}
+	st.NextCheck = now.Add(h.backoff(st.Failures))
+	return st.Failures >= h.cfg.Failures
+}
+
+// backoff 连续失败后的检测间隔，每多失败一次翻倍
+func (h *Heartbeat) backoff(failures int) time.Duration {
+	delay := h.cfg.Interval
+	for i := 0; i < failures && delay < h.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > h.cfg.MaxBackoff {
+		delay = h.cfg.MaxBackoff
+	}
+	// 预留一点余量，避免与检测周期对齐时多等一个周期
+	return delay - delay/10
+}
+
+// Health 获取各实例的心跳检测统计，按端口排序
+func (h *Heartbeat) Health() []Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	list := make([]Health, 0, len(h.ports))
+	for _, st := range h.ports {
+		item := st.Health
+		item.History = append([]HealthSample(nil), st.History...)
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Port < list[j].Port })
+	return list
+}
+
+// ms 转换为毫秒
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
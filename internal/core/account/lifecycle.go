@@ -0,0 +1,289 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/naidog/wechat-framework/internal/core/dll"
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// 微信实例的生命周期状态
+const (
+	StateLaunched      = "launched"      // 框架已启动微信进程，等待注入
+	StateInjected      = "injected"      // DLL 注入成功（injectSuccess）
+	StateAwaitingLogin = "awaitingLogin" // 已注入，等待扫码登录
+	StateOnline        = "online"        // 已登录（loginSuccess）
+	StateUnresponsive  = "unresponsive"  // 心跳失败，尚未判定退出
+	StateExpired       = "expired"       // 授权已到期（authExpire 或 isExpire=1）
+	StateExited        = "exited"        // 已退出，从账号列表移除
+)
+
+const (
+	MaxHistory = 20               // 每个实例保留的最近状态变化条数
+	exitedTTL  = 30 * time.Minute // 已退出的实例保留多久，便于查询退出原因
+)
+
+// allowed 允许的状态变化，空字符串表示尚未登记的实例；登录成功可从任意状态进入 online
+var allowed = map[string][]string{
+	"":                 {StateLaunched, StateInjected},
+	StateLaunched:      {StateInjected, StateExited},
+	StateInjected:      {StateAwaitingLogin, StateExited},
+	StateAwaitingLogin: {StateExited},
+	StateOnline:        {StateUnresponsive, StateExpired, StateAwaitingLogin, StateExited},
+	StateUnresponsive:  {StateExpired, StateAwaitingLogin, StateExited},
+	StateExpired:       {StateUnresponsive, StateAwaitingLogin, StateExited},
+	StateExited:        {StateLaunched, StateInjected},
+}
+
+// Transition 实例的一次状态变化，以 account:state 事件推送给前端、插件和 SSE 客户端
+type Transition struct {
+	Port   int       `json:"port"`           // 微信端口
+	Pid    int       `json:"pid,omitempty"`  // 进程ID
+	Wxid   string    `json:"wxid,omitempty"` // 已登录过的账号 wxid
+	Nick   string    `json:"nick,omitempty"` // 昵称
+	From   string    `json:"from"`           // 原状态，首次登记时为空
+	To     string    `json:"to"`             // 新状态
+	Reason string    `json:"reason"`         // 变化原因
+	At     time.Time `json:"at"`             // 变化时间
+}
+
+// Instance 微信实例的当前状态与最近的状态变化
+type Instance struct {
+	Port    int          `json:"port"`           // 微信端口
+	Pid     int          `json:"pid,omitempty"`  // 进程ID
+	Wxid    string       `json:"wxid,omitempty"` // 已登录过的账号 wxid
+	Nick    string       `json:"nick,omitempty"` // 昵称
+	State   string       `json:"state"`          // 当前状态
+	Reason  string       `json:"reason"`         // 进入当前状态的原因
+	Since   time.Time    `json:"since"`          // 进入当前状态的时间
+	History []Transition `json:"history"`        // 最近的状态变化，最多 MaxHistory 条
+}
+
+// StateListener 实例状态变化的订阅者
+type StateListener func(ctx context.Context, t Transition)
+
+// tx 一次修改中产生的状态变化，只在写锁内使用
+type tx struct {
+	r           *Registry
+	now         time.Time
+	transitions []Transition
+}
+
+// transition 切换实例状态，fill 用于更新实例的 pid、wxid 等信息。
+// 不允许的状态变化被忽略；状态未变化时只更新信息，不产生事件
+func (t *tx) transition(port int, to, reason string, fill func(in *Instance)) {
+	in, ok := t.r.instances[port]
+	if !ok {
+		in = &Instance{Port: port}
+	}
+	if in.State == to {
+		if fill != nil {
+			fill(in)
+		}
+		return
+	}
+	if to != StateOnline && !slices.Contains(allowed[in.State], to) {
+		return
+	}
+
+	if fill != nil {
+		fill(in)
+	}
+	tr := Transition{
+		Port:   port,
+		Pid:    in.Pid,
+		Wxid:   in.Wxid,
+		Nick:   in.Nick,
+		From:   in.State,
+		To:     to,
+		Reason: reason,
+		At:     t.now,
+	}
+	in.State = to
+	in.Reason = reason
+	in.Since = t.now
+	in.History = append(in.History, tr)
+	if len(in.History) > MaxHistory {
+		in.History = slices.Clone(in.History[len(in.History)-MaxHistory:])
+	}
+	t.r.instances[port] = in
+	t.transitions = append(t.transitions, tr)
+}
+
+// fillAccount 用登录账号的信息更新实例
+func fillAccount(acc types.WechatAccount) func(in *Instance) {
+	return func(in *Instance) {
+		in.Wxid = acc.Wxid
+		in.Nick = acc.Nick
+		if acc.Pid != 0 {
+			in.Pid = acc.Pid
+		}
+	}
+}
+
+// fillProcess 端口上启动了新的微信进程，清除之前登录的账号信息
+func fillProcess(pid int) func(in *Instance) {
+	return func(in *Instance) {
+		in.Wxid = ""
+		in.Nick = ""
+		if pid != 0 {
+			in.Pid = pid
+		}
+	}
+}
+
+// resetBreaker 端口上是新的微信进程时清除该端口的熔断状态，
+// 同一进程重复登记（如启动后再收到 injectSuccess）时保留
+func (r *Registry) resetBreaker(port, pid int) {
+	r.mu.RLock()
+	in, ok := r.instances[port]
+	same := ok && pid != 0 && in.Pid == pid && in.State != StateExited
+	r.mu.RUnlock()
+	if !same {
+		dll.Client().ResetBreaker(port)
+	}
+}
+
+// pruneExited 清理退出已久的实例，调用方需持有写锁
+func (r *Registry) pruneExited(now time.Time) {
+	for port, in := range r.instances {
+		if in.State == StateExited && now.Sub(in.Since) > exitedTTL {
+			delete(r.instances, port)
+		}
+	}
+}
+
+// SubscribeState 订阅实例状态变化
+func (r *Registry) SubscribeState(listener StateListener) {
+	r.publishMu.Lock()
+	defer r.publishMu.Unlock()
+	r.stateListeners = append(r.stateListeners, listener)
+}
+
+// Instances 获取各微信实例的状态，按端口排序，包含最近退出的实例
+func (r *Registry) Instances() []Instance {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Instance, 0, len(r.instances))
+	for _, in := range r.instances {
+		item := *in
+		item.History = slices.Clone(in.History)
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Port < list[j].Port })
+	return list
+}
+
+// State 获取端口上实例的当前状态，未登记时为空
+func (r *Registry) State(port int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if in, ok := r.instances[port]; ok {
+		return in.State
+	}
+	return ""
+}
+
+// Launch 登记框架启动的微信进程
+func (r *Registry) Launch(ctx context.Context, port, pid int) error {
+	r.resetBreaker(port, pid)
+	return r.mutate(ctx, func(t *tx) {
+		t.transition(port, StateLaunched, "框架已启动微信", fillProcess(pid))
+	})
+}
+
+// Inject 登记 DLL 注入成功的微信实例
+func (r *Registry) Inject(ctx context.Context, port, pid int) error {
+	r.resetBreaker(port, pid)
+	return r.mutate(ctx, func(t *tx) {
+		t.transition(port, StateInjected, "注入成功", fillProcess(pid))
+	})
+}
+
+// Expire 登记授权到期，更新账号的到期信息
+func (r *Registry) Expire(ctx context.Context, port int, expireTime, reason string) error {
+	return r.mutate(ctx, func(t *tx) {
+		if i := r.accountIndex(port); i >= 0 {
+			r.accounts[i].IsExpire = 1
+			if expireTime != "" {
+				r.accounts[i].ExpireTime = expireTime
+			}
+		}
+		t.transition(port, StateExpired, reason, nil)
+	})
+}
+
+// Exit 登记微信实例已退出，从账号列表移除该端口上的账号
+func (r *Registry) Exit(ctx context.Context, port int, reason string) error {
+	return r.mutate(ctx, func(t *tx) {
+		r.removeAccount(port)
+		t.transition(port, StateExited, reason, nil)
+	})
+}
+
+// Probe 一次心跳检测的结果
+type Probe struct {
+	Wxid  string          // 检测时端口上的账号，账号在检测期间重新登录时忽略结果
+	Auth  *types.AuthInfo // 已登录账号的授权信息
+	Login *bool           // 登录状态
+	Err   error           // 检测失败的原因
+	Down  bool            // 连续失败次数达到阈值，判定微信已退出
+}
+
+// ApplyProbes 按心跳检测的结果更新账号列表与实例状态：
+// 检测失败且连续失败达到阈值的实例判定为退出并移除账号，未达到阈值时标记为无响应；
+// 已登录的账号查询到未登录时移除账号并等待重新登录；
+// 检测成功时更新授权信息，授权到期的账号标记为已到期，其余恢复在线
+func (r *Registry) ApplyProbes(ctx context.Context, results map[int]Probe) error {
+	return r.mutate(ctx, func(t *tx) {
+		for port, p := range results {
+			in, ok := r.instances[port]
+			if !ok || in.Wxid != p.Wxid || in.State == StateExited {
+				continue
+			}
+			switch {
+			case p.Err != nil && p.Down:
+				r.removeAccount(port)
+				t.transition(port, StateExited, fmt.Sprintf("连续请求失败，微信已退出: %v", p.Err), nil)
+			case p.Err != nil:
+				if r.accountIndex(port) >= 0 {
+					t.transition(port, StateUnresponsive, fmt.Sprintf("心跳失败: %v", p.Err), nil)
+				}
+			case p.Login != nil:
+				i := r.accountIndex(port)
+				switch {
+				case !*p.Login && i >= 0:
+					r.removeAccount(port)
+					t.transition(port, StateAwaitingLogin, "账号已退出登录", nil)
+				case !*p.Login:
+					t.transition(port, StateAwaitingLogin, "已注入，等待扫码登录", nil)
+				case i >= 0 && in.State == StateUnresponsive:
+					if r.accounts[i].IsExpire == 1 {
+						t.transition(port, StateExpired, "心跳恢复，授权已到期", nil)
+					} else {
+						t.transition(port, StateOnline, "心跳恢复", nil)
+					}
+				}
+			case p.Auth != nil:
+				i := r.accountIndex(port)
+				if i < 0 {
+					continue
+				}
+				r.accounts[i].ExpireTime = p.Auth.ExpireTime
+				r.accounts[i].IsExpire = p.Auth.IsExpire
+				if p.Auth.IsExpire == 1 {
+					t.transition(port, StateExpired, fmt.Sprintf("授权已到期（到期时间: %s）", p.Auth.ExpireTime), nil)
+				} else if in.State == StateExpired {
+					t.transition(port, StateOnline, "授权已续期", nil)
+				} else {
+					t.transition(port, StateOnline, "心跳恢复", nil)
+				}
+			}
+		}
+	})
+}
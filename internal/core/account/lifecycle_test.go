@@ -0,0 +1,160 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	return NewRegistry(context.Background(), filepath.Join(t.TempDir(), "accounts.json"))
+}
+
+func TestRegistryTransitions(t *testing.T) {
+	const port = 19088
+	login := func(r *Registry) error {
+		return r.Login(context.Background(), types.WechatAccount{Wxid: "wxid_a", Port: port, Pid: 100})
+	}
+	probe := func(p Probe) func(r *Registry) error {
+		return func(r *Registry) error {
+			p.Wxid = r.instances[port].Wxid
+			return r.ApplyProbes(context.Background(), map[int]Probe{port: p})
+		}
+	}
+	notLoggedIn, loggedIn := false, true
+
+	tests := []struct {
+		name string
+		ops  []func(r *Registry) error
+		want []string // 依次产生的状态
+	}{
+		{
+			name: "启动、注入、等待登录、登录",
+			ops: []func(r *Registry) error{
+				func(r *Registry) error { return r.Launch(context.Background(), port, 100) },
+				func(r *Registry) error { return r.Inject(context.Background(), port, 100) },
+				probe(Probe{Login: &notLoggedIn}),
+				login,
+			},
+			want: []string{StateLaunched, StateInjected, StateAwaitingLogin, StateOnline},
+		},
+		{
+			name: "心跳失败后恢复",
+			ops: []func(r *Registry) error{
+				login,
+				probe(Probe{Err: errors.New("timeout")}),
+				probe(Probe{Login: &loggedIn}),
+			},
+			want: []string{StateOnline, StateUnresponsive, StateOnline},
+		},
+		{
+			name: "授权到期后续期",
+			ops: []func(r *Registry) error{
+				login,
+				probe(Probe{Auth: &types.AuthInfo{IsExpire: 1}}),
+				probe(Probe{Auth: &types.AuthInfo{IsExpire: 0}}),
+			},
+			want: []string{StateOnline, StateExpired, StateOnline},
+		},
+		{
+			name: "连续失败判定退出",
+			ops: []func(r *Registry) error{
+				login,
+				probe(Probe{Err: errors.New("timeout"), Down: true}),
+			},
+			want: []string{StateOnline, StateExited},
+		},
+		{
+			name: "不允许的状态变化被忽略",
+			ops: []func(r *Registry) error{
+				func(r *Registry) error { return r.Inject(context.Background(), port, 100) },
+				func(r *Registry) error { return r.Launch(context.Background(), port, 100) },
+				func(r *Registry) error { return r.Expire(context.Background(), port, "", "授权到期") },
+			},
+			want: []string{StateInjected},
+		},
+		{
+			name: "退出后重新启动",
+			ops: []func(r *Registry) error{
+				login,
+				func(r *Registry) error { return r.Exit(context.Background(), port, "进程已退出") },
+				func(r *Registry) error { return r.Launch(context.Background(), port, 200) },
+			},
+			want: []string{StateOnline, StateExited, StateLaunched},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRegistry(t)
+			var got []string
+			r.SubscribeState(func(ctx context.Context, tr Transition) {
+				got = append(got, tr.To)
+			})
+			for _, op := range tt.ops {
+				if err := op(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("状态变化 = %v, 期望 %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("状态变化 = %v, 期望 %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryLoginOnNewPortExitsOldInstance(t *testing.T) {
+	r := newTestRegistry(t)
+	ctx := context.Background()
+	if err := r.Login(ctx, types.WechatAccount{Wxid: "wxid_a", Port: 19088}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Login(ctx, types.WechatAccount{Wxid: "wxid_a", Port: 19089}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.State(19088); got != StateExited {
+		t.Fatalf("原端口状态 = %s, 期望 %s", got, StateExited)
+	}
+	if got := r.State(19089); got != StateOnline {
+		t.Fatalf("新端口状态 = %s, 期望 %s", got, StateOnline)
+	}
+	if list := r.List(); len(list) != 1 || list[0].Port != 19089 {
+		t.Fatalf("账号列表 = %+v, 期望只有端口 19089", list)
+	}
+}
+
+func TestRegistryLaunchClearsPreviousAccount(t *testing.T) {
+	r := newTestRegistry(t)
+	ctx := context.Background()
+	if err := r.Login(ctx, types.WechatAccount{Wxid: "wxid_a", Nick: "A", Port: 19088, Pid: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Exit(ctx, 19088, "进程已退出"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Launch(ctx, 19088, 200); err != nil {
+		t.Fatal(err)
+	}
+
+	list := r.Instances()
+	if len(list) != 1 {
+		t.Fatalf("实例数 = %d, 期望 1", len(list))
+	}
+	in := list[0]
+	if in.Pid != 200 || in.Wxid != "" || in.Nick != "" {
+		t.Fatalf("重新启动后的实例 = %+v, 期望只保留新进程ID", in)
+	}
+	if len(in.History) != 3 {
+		t.Fatalf("状态历史条数 = %d, 期望 3", len(in.History))
+	}
+}
@@ -1,311 +1,88 @@
 package account
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"sync"
-	"time"
 
 	"github.com/gogf/gf/v2/frame/g"
-	"github.com/gogf/gf/v2/os/gfile"
 	"github.com/naidog/wechat-framework/pkg/types"
-	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
-const (
-	AccountFilePath = "resources/currentWechat.json"
-)
+// AccountFilePath 账号注册表保存账号列表的文件
+const AccountFilePath = "resources/currentWechat.json"
 
-// Manager 微信账号管理器
+// Manager 微信账号管理器，账号列表保存在账号注册表中
 type Manager struct {
-	app         *application.App
-	lastContent string
-	mu          sync.RWMutex
+	emitter   types.Emitter
+	registry  *Registry
+	heartbeat *Heartbeat
+	mu        sync.RWMutex
 }
 
-// NewManager 创建账号管理器实例
-func NewManager(app *application.App) *Manager {
+// NewManager 创建账号管理器实例，使用框架共用的账号注册表；emitter 为 nil 时不向前端推送账号变化
+func NewManager(emitter types.Emitter) *Manager {
 	return &Manager{
-		app: app,
+		emitter:   emitter,
+		registry:  Default(),
+		heartbeat: DefaultHeartbeat(),
 	}
 }
 
-// SetApp 设置应用实例
-func (m *Manager) SetApp(app *application.App) {
+// SetEmitter 设置前端事件发送器
+func (m *Manager) SetEmitter(emitter types.Emitter) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.app = app
+	m.emitter = emitter
+}
+
+// Registry 获取账号注册表
+func (m *Manager) Registry() *Registry {
+	return m.registry
 }
 
-// StartWatching 开始监听账号文件变化并推送到前端
+// StartWatching 订阅账号列表与实例状态变化并推送到前端，同时启动心跳检测
 func (m *Manager) StartWatching(ctx context.Context) {
-	g.Log().Info(ctx, "开始监听微信账号文件变化...")
+	m.registry.Subscribe(m.emitAccounts)
+	m.registry.SubscribeState(func(ctx context.Context, t Transition) {
+		g.Log().Infof(ctx, "微信实例状态变化 (端口:%d): %s -> %s, %s", t.Port, t.From, t.To, t.Reason)
+		m.emit(types.EventAccountState, t)
+	})
 
 	// 立即发送一次初始数据
-	go m.checkAndEmit(ctx)
+	m.emitAccounts(ctx, m.registry.List())
 
-	// 每秒检查一次文件变化
-	ticker := time.NewTicker(1 * time.Second)
-	go func() {
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				m.checkAndEmit(ctx)
-			}
-		}
-	}()
-
-	// 启动心跳检测，每 2 秒检查一次微信是否在线
-	go m.startHeartbeat(ctx)
+	// 启动心跳检测，间隔与失败阈值读取自 heartbeat.*
+	go m.heartbeat.Run(ctx)
 }
 
-// checkAndEmit 检查文件内容是否变化，如果变化则发送事件
-func (m *Manager) checkAndEmit(ctx context.Context) {
-	if !gfile.Exists(AccountFilePath) {
-		m.mu.RLock()
-		lastContent := m.lastContent
-		m.mu.RUnlock()
-
-		if lastContent != "" {
-			m.mu.Lock()
-			m.lastContent = ""
-			m.mu.Unlock()
-			m.emitAccounts(ctx, []types.WechatAccount{})
-		} else if lastContent == "" {
-			m.emitAccounts(ctx, []types.WechatAccount{})
-		}
-		return
-	}
-
-	fileData, err := os.ReadFile(AccountFilePath)
-	if err != nil {
-		g.Log().Warningf(ctx, "读取账号文件失败: %v", err)
-		return
-	}
-
-	content := string(fileData)
+// emitAccounts 发送账号列表到前端
+func (m *Manager) emitAccounts(ctx context.Context, accounts []types.WechatAccount) {
+	g.Log().Debugf(ctx, "账号列表已更新, 账号数量: %d", len(accounts))
+	m.emit("wechat:accounts:update", accounts)
+}
 
+// emit 发送事件到前端，未设置 emitter 时忽略
+func (m *Manager) emit(name string, data interface{}) {
 	m.mu.RLock()
-	lastContent := m.lastContent
+	emitter := m.emitter
 	m.mu.RUnlock()
 
-	if content == lastContent && lastContent != "" {
-		return
-	}
-
-	var accountList types.WechatAccountList
-	if len(fileData) > 0 {
-		if err := json.Unmarshal(fileData, &accountList); err != nil {
-			g.Log().Warningf(ctx, "解析账号JSON失败: %v", err)
-			return
-		}
-	}
-
-	m.mu.Lock()
-	m.lastContent = content
-	m.mu.Unlock()
-
-	g.Log().Infof(ctx, "账号文件内容变化，准备发送 %d 个账号", len(accountList.List))
-	m.emitAccounts(ctx, accountList.List)
-}
-
-// emitAccounts 发送账号列表到前端
-func (m *Manager) emitAccounts(ctx context.Context, accounts []types.WechatAccount) {
-	if m.app == nil || m.app.Event == nil {
-		g.Log().Warning(ctx, "应用实例未初始化，无法发送事件")
-		return
+	if emitter != nil {
+		emitter.Emit(name, data)
 	}
-
-	g.Log().Infof(ctx, "发送事件: wechat:accounts:update, 账号数量: %d", len(accounts))
-	m.app.Event.Emit("wechat:accounts:update", accounts)
 }
 
 // GetAccounts 获取当前账号列表
 func (m *Manager) GetAccounts(ctx context.Context) []types.WechatAccount {
-	if !gfile.Exists(AccountFilePath) {
-		return []types.WechatAccount{}
-	}
-
-	fileData, err := os.ReadFile(AccountFilePath)
-	if err != nil {
-		return []types.WechatAccount{}
-	}
-
-	var accountList types.WechatAccountList
-	if len(fileData) > 0 {
-		if err := json.Unmarshal(fileData, &accountList); err != nil {
-			return []types.WechatAccount{}
-		}
-	}
-
-	return accountList.List
+	return m.registry.List()
 }
 
-// startHeartbeat 启动心跳检测
-func (m *Manager) startHeartbeat(ctx context.Context) {
-	g.Log().Info(ctx, "启动微信心跳检测服务...")
-
-	// 等待 15 秒，给授权检查留出时间
-	time.Sleep(15 * time.Second)
-
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			g.Log().Info(ctx, "心跳检测服务已停止")
-			return
-		case <-ticker.C:
-			m.checkAccountsHealth(ctx)
-		}
-	}
-}
-
-// checkAccountsHealth 检查所有账号的健康状态
-func (m *Manager) checkAccountsHealth(ctx context.Context) {
-	if !gfile.Exists(AccountFilePath) {
-		return
-	}
-
-	fileData, err := os.ReadFile(AccountFilePath)
-	if err != nil {
-		return
-	}
-
-	var accountList types.WechatAccountList
-	if len(fileData) > 0 {
-		if err := json.Unmarshal(fileData, &accountList); err != nil {
-			return
-		}
-	}
-
-	if len(accountList.List) == 0 {
-		return
-	}
-
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	aliveAccounts := make([]types.WechatAccount, 0)
-	removedCount := 0
-	updatedCount := 0
-
-	for i := range accountList.List {
-		wg.Add(1)
-		go func(index int) {
-			defer wg.Done()
-
-			acc := accountList.List[index]
-
-			// 查询授权信息
-			authInfo := m.getAuthInfo(ctx, acc.Port)
-			if authInfo == nil {
-				mu.Lock()
-				removedCount++
-				mu.Unlock()
-				g.Log().Warningf(ctx, "检测到微信已退出: %s (端口:%d)", acc.Wxid, acc.Port)
-				return
-			}
-
-			oldExpire := acc.IsExpire
-			oldExpireTime := acc.ExpireTime
-
-			acc.ExpireTime = authInfo.ExpireTime
-			acc.IsExpire = authInfo.IsExpire
-
-			if oldExpire != acc.IsExpire || oldExpireTime != acc.ExpireTime {
-				mu.Lock()
-				updatedCount++
-				mu.Unlock()
-			}
-
-			mu.Lock()
-			aliveAccounts = append(aliveAccounts, acc)
-			mu.Unlock()
-		}(i)
-	}
-
-	wg.Wait()
-
-	if removedCount > 0 || updatedCount > 0 {
-		if removedCount > 0 {
-			g.Log().Infof(ctx, "移除 %d 个已退出的微信账号，剩余 %d 个", removedCount, len(aliveAccounts))
-		}
-		if updatedCount > 0 {
-			g.Log().Infof(ctx, "更新 %d 个账号的授权信息", updatedCount)
-		}
-
-		m.mu.Lock()
-		accountList.List = aliveAccounts
-		jsonData, err := json.MarshalIndent(accountList, "", "  ")
-		if err == nil {
-			os.WriteFile(AccountFilePath, jsonData, 0644)
-		}
-		m.mu.Unlock()
-	}
+// GetInstances 获取各微信实例的生命周期状态
+func (m *Manager) GetInstances(ctx context.Context) []Instance {
+	return m.registry.Instances()
 }
 
-// getAuthInfo 获取授权信息
-func (m *Manager) getAuthInfo(ctx context.Context, port int) *types.AuthInfo {
-	url := fmt.Sprintf("http://127.0.0.1:%d/wechat/httpapi", port)
-
-	requestBody := map[string]string{
-		"type": "getAuthInfo",
-	}
-	jsonData, _ := json.Marshal(requestBody)
-
-	client := &http.Client{
-		Timeout: 3 * time.Second,
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil
-	}
-
-	var result struct {
-		Code   int `json:"code"`
-		Result struct {
-			ExpireTime string `json:"expireTime"`
-			IsExpire   int    `json:"isExpire"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil
-	}
-
-	if result.Code != 200 {
-		return nil
-	}
-
-	return &types.AuthInfo{
-		ExpireTime: result.Result.ExpireTime,
-		IsExpire:   result.Result.IsExpire,
-	}
+// GetHealth 获取各微信实例的心跳检测统计
+func (m *Manager) GetHealth(ctx context.Context) []Health {
+	return m.heartbeat.Health()
 }
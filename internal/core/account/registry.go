@@ -0,0 +1,199 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// Listener 账号列表变化的订阅者，收到的是变化后的完整账号列表
+type Listener func(ctx context.Context, accounts []types.WechatAccount)
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *Registry
+)
+
+// Registry 账号注册表，内存中保存当前已登录的微信账号与各微信实例的生命周期状态。
+// 所有修改在写锁内串行执行，账号列表变化后原子写入账号文件，并按修改顺序通知订阅者
+type Registry struct {
+	path           string
+	mu             sync.RWMutex
+	accounts       []types.WechatAccount
+	instances      map[int]*Instance // 按端口的实例状态
+	publishMu      sync.Mutex        // 保证订阅者按修改顺序收到通知
+	listeners      []Listener
+	stateListeners []StateListener
+}
+
+// Default 获取框架共用的账号注册表，首次调用时从 AccountFilePath 恢复账号列表
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		defaultRegistry = NewRegistry(gctx.New(), AccountFilePath)
+	})
+	return defaultRegistry
+}
+
+// NewRegistry 创建账号注册表，从 path 恢复上次保存的账号列表，文件损坏时从空列表开始。
+// 恢复的账号视为在线，由心跳检测确认
+func NewRegistry(ctx context.Context, path string) *Registry {
+	r := &Registry{
+		path:      path,
+		accounts:  []types.WechatAccount{},
+		instances: make(map[int]*Instance),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return r
+	}
+	var list types.WechatAccountList
+	if err := json.Unmarshal(data, &list); err != nil {
+		g.Log().Warningf(ctx, "解析账号文件失败，忽略已保存的账号: %v", err)
+		return r
+	}
+	if list.List == nil {
+		return r
+	}
+
+	r.accounts = list.List
+	t := &tx{r: r, now: time.Now()}
+	for _, acc := range r.accounts {
+		t.transition(acc.Port, StateOnline, "从账号文件恢复", fillAccount(acc))
+	}
+	return r
+}
+
+// Subscribe 订阅账号列表变化
+func (r *Registry) Subscribe(listener Listener) {
+	r.publishMu.Lock()
+	defer r.publishMu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// List 获取当前账号列表的副本
+func (r *Registry) List() []types.WechatAccount {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.accounts)
+}
+
+// GetAccounts 获取当前账号列表，供代理和回调校验器使用
+func (r *Registry) GetAccounts(ctx context.Context) []types.WechatAccount {
+	return r.List()
+}
+
+// Get 按 wxid 查找账号
+func (r *Registry) Get(wxid string) (types.WechatAccount, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, acc := range r.accounts {
+		if acc.Wxid == wxid {
+			return acc, true
+		}
+	}
+	return types.WechatAccount{}, false
+}
+
+// Login 登记登录成功的账号，wxid 已存在时替换原记录；
+// 同一账号换端口重新登录时，原端口的实例视为已退出
+func (r *Registry) Login(ctx context.Context, account types.WechatAccount) error {
+	if account.Wxid == "" {
+		return fmt.Errorf("wxid 为空")
+	}
+	return r.mutate(ctx, func(t *tx) {
+		found := false
+		for i, acc := range r.accounts {
+			if acc.Wxid != account.Wxid {
+				continue
+			}
+			if acc.Port != account.Port {
+				t.transition(acc.Port, StateExited, fmt.Sprintf("账号已在端口 %d 重新登录", account.Port), nil)
+			}
+			r.accounts[i] = account
+			found = true
+			break
+		}
+		if !found {
+			r.accounts = append(r.accounts, account)
+		}
+		t.transition(account.Port, StateOnline, "登录成功", fillAccount(account))
+	})
+}
+
+// mutate 在写锁内执行 fn，fn 可直接修改 r.accounts 与实例状态；
+// 账号列表有变化时保存文件，随后依次推送状态变化与新的账号列表
+func (r *Registry) mutate(ctx context.Context, fn func(t *tx)) error {
+	r.mu.Lock()
+	before := r.accounts
+	r.accounts = slices.Clone(before)
+	t := &tx{r: r, now: time.Now()}
+	fn(t)
+	r.pruneExited(t.now)
+
+	changed := !slices.Equal(before, r.accounts)
+	var err error
+	if changed {
+		err = r.persist(r.accounts)
+	}
+	accounts := slices.Clone(r.accounts)
+
+	// 先取得发布锁再释放写锁，订阅者按修改顺序收到通知，且不阻塞读取
+	r.publishMu.Lock()
+	r.mu.Unlock()
+	defer r.publishMu.Unlock()
+
+	if err != nil {
+		g.Log().Errorf(ctx, "保存账号文件失败: %v", err)
+	}
+	for _, tr := range t.transitions {
+		for _, listener := range r.stateListeners {
+			listener(ctx, tr)
+		}
+	}
+	if changed {
+		for _, listener := range r.listeners {
+			listener(ctx, slices.Clone(accounts))
+		}
+	}
+	return err
+}
+
+// persist 保存账号列表，先写临时文件再替换，避免读到写了一半的文件
+func (r *Registry) persist(accounts []types.WechatAccount) error {
+	data, err := json.MarshalIndent(types.WechatAccountList{List: accounts}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// removeAccount 从账号列表移除端口上的账号，调用方需持有写锁
+func (r *Registry) removeAccount(port int) {
+	r.accounts = slices.DeleteFunc(r.accounts, func(acc types.WechatAccount) bool {
+		return acc.Port == port
+	})
+}
+
+// accountIndex 按端口查找账号，调用方需持有锁
+func (r *Registry) accountIndex(port int) int {
+	return slices.IndexFunc(r.accounts, func(acc types.WechatAccount) bool {
+		return acc.Port == port
+	})
+}
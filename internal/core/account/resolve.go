@@ -0,0 +1,107 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// AccountHeader 也可通过请求头指定账号（wxid 或别名）
+const AccountHeader = "X-Wechat-Account"
+
+var (
+	// ErrNoAccount 没有已登录的账号
+	ErrNoAccount = errors.New("当前没有已登录的微信账号")
+	// ErrAmbiguous 已登录多个账号且未指定
+	ErrAmbiguous = errors.New("已登录多个微信账号，请通过 wxid、account 或 port 参数指定")
+)
+
+// OfflineError 指定的账号不在线
+type OfflineError struct {
+	Account string // 请求中的 wxid 或别名
+}
+
+// Error 实现 error 接口
+func (e *OfflineError) Error() string {
+	return fmt.Sprintf("微信账号 %s 不在线或未登录", e.Account)
+}
+
+// LoadAliases 从配置文件读取账号别名（account.aliases，别名 -> wxid）
+func LoadAliases(ctx context.Context) map[string]string {
+	aliases := make(map[string]string)
+	if v, err := g.Cfg().Get(ctx, "account.aliases"); err == nil {
+		for alias, wxid := range v.MapStrStr() {
+			aliases[alias] = wxid
+		}
+	}
+	return aliases
+}
+
+// Resolve 将代理请求中的账号参数解析为微信端口。
+// 指定 port 时直接使用；指定 key 时按别名、wxid、微信号查找在线账号；
+// 都未指定且只有一个在线账号时使用该账号。
+func Resolve(accounts []types.WechatAccount, aliases map[string]string, port int, key string) (int, error) {
+	if port != 0 {
+		return port, nil
+	}
+
+	if key == "" {
+		switch len(accounts) {
+		case 0:
+			return 0, ErrNoAccount
+		case 1:
+			return accounts[0].Port, nil
+		default:
+			return 0, ErrAmbiguous
+		}
+	}
+
+	wxid := key
+	if target, ok := aliases[key]; ok {
+		wxid = target
+	}
+	for _, acc := range accounts {
+		if acc.Wxid == wxid || (acc.WxNum != "" && acc.WxNum == wxid) {
+			return acc.Port, nil
+		}
+	}
+	return 0, &OfflineError{Account: key}
+}
+
+// Resolve 按当前账号列表与配置中的别名解析微信端口
+func (r *Registry) Resolve(ctx context.Context, port int, key string) (int, error) {
+	if port != 0 {
+		return port, nil
+	}
+	return Resolve(r.List(), LoadAliases(ctx), port, key)
+}
+
+// Resolve 按当前账号列表与配置中的别名解析微信端口
+func (m *Manager) Resolve(ctx context.Context, port int, key string) (int, error) {
+	return m.registry.Resolve(ctx, port, key)
+}
+
+// RequestKey 获取代理请求指定的账号：查询参数 wxid、account 或请求头。
+// 只读取 URL 查询参数，请求体中的 wxid 是消息接收人
+func RequestKey(r *ghttp.Request) string {
+	query := r.URL.Query()
+	if key := query.Get("wxid"); key != "" {
+		return key
+	}
+	if key := query.Get("account"); key != "" {
+		return key
+	}
+	return r.Header.Get(AccountHeader)
+}
+
+// ErrorCode 账号解析错误对应的返回码
+func ErrorCode(err error) int {
+	if errors.Is(err, ErrAmbiguous) {
+		return 400
+	}
+	return 404
+}
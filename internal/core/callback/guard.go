@@ -0,0 +1,300 @@
+package callback
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// 回调校验模式
+const (
+	AuthToken    = "token"    // 校验回调地址中的共享密钥
+	AuthInstance = "instance" // 校验 port/pid 是否为框架启动或已登记的微信实例，弱于 token：端口与 PID 均来自请求内容
+	AuthOff      = "off"      // 不校验
+)
+
+const (
+	DefaultSecretPath = "resources/callback.secret" // 自动生成的共享密钥文件
+	TokenParam        = "token"                     // 回调地址中的密钥参数名
+	TokenHeader       = "X-Callback-Token"          // 也可通过请求头传递密钥
+)
+
+// GuardConfig 回调校验配置
+type GuardConfig struct {
+	Mode       string // 校验模式：token / instance / off
+	Secret     string // 共享密钥，为空时使用 SecretPath 中的密钥（不存在则自动生成）
+	SecretPath string // 共享密钥文件路径
+}
+
+// GuardStats 回调校验统计
+type GuardStats struct {
+	Mode     string            `json:"mode"`     // 校验模式
+	Accepted uint64            `json:"accepted"` // 通过数
+	Rejected uint64            `json:"rejected"` // 拒绝数
+	Reasons  map[string]uint64 `json:"reasons"`  // 按原因统计的拒绝数
+}
+
+// AccountLister 已登记账号列表，用于实例校验
+type AccountLister func(ctx context.Context) []types.WechatAccount
+
+// Guard 回调校验器，拒绝伪造的回调事件
+type Guard struct {
+	mode      string
+	secret    string
+	accounts  AccountLister
+	mu        sync.RWMutex
+	instances map[int]int       // 框架启动的实例：端口 -> PID
+	reasons   map[string]uint64 // 拒绝原因 -> 次数
+	accepted  atomic.Uint64
+	rejected  atomic.Uint64
+}
+
+var (
+	defaultGuard     *Guard
+	defaultGuardOnce sync.Once
+)
+
+// DefaultGuard 获取按配置文件创建的全局回调校验器，供旧版服务共用
+func DefaultGuard() *Guard {
+	defaultGuardOnce.Do(func() {
+		ctx := gctx.New()
+		guard, err := NewGuard(LoadGuardConfig(ctx), nil)
+		if err != nil {
+			g.Log().Errorf(ctx, "回调校验器初始化失败，回调校验已关闭: %v", err)
+			guard, _ = NewGuard(GuardConfig{Mode: AuthOff}, nil)
+		}
+		defaultGuard = guard
+	})
+	return defaultGuard
+}
+
+// LoadGuardConfig 从配置文件读取回调校验配置（server.callbackAuth / server.callbackSecret）
+func LoadGuardConfig(ctx context.Context) GuardConfig {
+	cfg := GuardConfig{
+		Mode:       AuthToken,
+		SecretPath: DefaultSecretPath,
+	}
+	if v, err := g.Cfg().Get(ctx, "server.callbackAuth"); err == nil && v.String() != "" {
+		cfg.Mode = v.String()
+	}
+	if v, err := g.Cfg().Get(ctx, "server.callbackSecret"); err == nil {
+		cfg.Secret = v.String()
+	}
+	return cfg
+}
+
+// NewGuard 创建回调校验器，accounts 为 nil 时实例校验只认可框架启动的实例
+func NewGuard(cfg GuardConfig, accounts AccountLister) (*Guard, error) {
+	switch cfg.Mode {
+	case AuthToken, AuthInstance, AuthOff:
+	case "":
+		cfg.Mode = AuthToken
+	default:
+		return nil, fmt.Errorf("未知的回调校验模式: %s", cfg.Mode)
+	}
+
+	guard := &Guard{
+		mode:      cfg.Mode,
+		secret:    cfg.Secret,
+		accounts:  accounts,
+		instances: make(map[int]int),
+		reasons:   make(map[string]uint64),
+	}
+
+	if guard.secret == "" && cfg.Mode == AuthToken {
+		if cfg.SecretPath == "" {
+			cfg.SecretPath = DefaultSecretPath
+		}
+		secret, err := loadOrCreateSecret(cfg.SecretPath)
+		if err != nil {
+			return nil, err
+		}
+		guard.secret = secret
+	}
+	return guard, nil
+}
+
+// SetAccounts 设置已登记账号列表
+func (gd *Guard) SetAccounts(accounts AccountLister) {
+	gd.mu.Lock()
+	defer gd.mu.Unlock()
+	gd.accounts = accounts
+}
+
+// Mode 获取校验模式
+func (gd *Guard) Mode() string {
+	return gd.mode
+}
+
+// CallbackURL 生成写入微信 config.json 的回调地址，token 模式下附带共享密钥
+func (gd *Guard) CallbackURL(base string) string {
+	if gd.mode != AuthToken {
+		return base
+	}
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + TokenParam + "=" + url.QueryEscape(gd.secret)
+}
+
+// RedactURL 隐藏回调地址中的共享密钥，用于日志输出
+func RedactURL(callbackURL string) string {
+	u, err := url.Parse(callbackURL)
+	if err != nil || u.RawQuery == "" {
+		return callbackURL
+	}
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		if strings.HasPrefix(param, TokenParam+"=") {
+			params[i] = TokenParam + "=******"
+		}
+	}
+	u.RawQuery = strings.Join(params, "&")
+	return u.String()
+}
+
+// Register 登记框架启动的微信实例
+func (gd *Guard) Register(port, pid int) {
+	gd.mu.Lock()
+	defer gd.mu.Unlock()
+	gd.instances[port] = pid
+}
+
+// Unregister 移除已登记的微信实例
+func (gd *Guard) Unregister(port int) {
+	gd.mu.Lock()
+	defer gd.mu.Unlock()
+	delete(gd.instances, port)
+}
+
+// Verify 校验回调请求，失败时记录日志与统计并返回原因
+func (gd *Guard) Verify(r *ghttp.Request, raw *types.CallbackEvent) error {
+	var reason string
+	switch gd.mode {
+	case AuthToken:
+		reason = gd.checkToken(r)
+	case AuthInstance:
+		reason = gd.checkInstance(r.Context(), raw)
+	}
+
+	if reason == "" {
+		gd.accepted.Add(1)
+		return nil
+	}
+
+	gd.rejected.Add(1)
+	gd.mu.Lock()
+	gd.reasons[reason]++
+	gd.mu.Unlock()
+
+	g.Log().Warningf(r.Context(), "拒绝回调事件 [%s] 来源: %s, 端口: %d, PID: %d, 原因: %s",
+		raw.Type, r.GetClientIp(), raw.Port, raw.Pid, reason)
+	return fmt.Errorf("回调校验失败: %s", reason)
+}
+
+// Stats 获取校验统计
+func (gd *Guard) Stats() GuardStats {
+	gd.mu.RLock()
+	defer gd.mu.RUnlock()
+
+	reasons := make(map[string]uint64, len(gd.reasons))
+	for k, v := range gd.reasons {
+		reasons[k] = v
+	}
+	return GuardStats{
+		Mode:     gd.mode,
+		Accepted: gd.accepted.Load(),
+		Rejected: gd.rejected.Load(),
+		Reasons:  reasons,
+	}
+}
+
+// checkToken 校验共享密钥
+func (gd *Guard) checkToken(r *ghttp.Request) string {
+	token := r.GetQuery(TokenParam).String()
+	if token == "" {
+		token = r.Header.Get(TokenHeader)
+	}
+	if token == "" {
+		return "缺少密钥"
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(gd.secret)) != 1 {
+		return "密钥错误"
+	}
+	return ""
+}
+
+// checkInstance 校验事件来源实例，注入成功事件的端口和 PID 在 data 中
+func (gd *Guard) checkInstance(ctx context.Context, raw *types.CallbackEvent) string {
+	port, pid := raw.Port, raw.Pid
+	if port == 0 {
+		port = gconv.Int(raw.Data["port"])
+	}
+	if pid == 0 {
+		pid = gconv.Int(raw.Data["pid"])
+	}
+	if port == 0 {
+		return "缺少端口"
+	}
+
+	gd.mu.RLock()
+	knownPid, ok := gd.instances[port]
+	accounts := gd.accounts
+	gd.mu.RUnlock()
+
+	if !ok && accounts != nil {
+		for _, acc := range accounts(ctx) {
+			if acc.Port == port {
+				knownPid, ok = acc.Pid, true
+				break
+			}
+		}
+	}
+
+	if !ok {
+		return "未知实例"
+	}
+	// 已知实例 PID 时回调必须携带相同的 PID，不接受只带端口的请求
+	if knownPid != 0 && pid != knownPid {
+		return "PID 不匹配"
+	}
+	return ""
+}
+
+// loadOrCreateSecret 读取共享密钥文件，不存在时生成随机密钥
+func loadOrCreateSecret(path string) (string, error) {
+	if content, err := os.ReadFile(path); err == nil {
+		if secret := strings.TrimSpace(string(content)); secret != "" {
+			return secret, nil
+		}
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成回调密钥失败: %v", err)
+	}
+	secret := hex.EncodeToString(buf)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("创建回调密钥目录失败: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(secret), 0600); err != nil {
+		return "", fmt.Errorf("写入回调密钥失败: %v", err)
+	}
+	return secret, nil
+}
@@ -2,29 +2,28 @@ package callback
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"os"
-	"path/filepath"
-	"sync"
-	"time"
+	"errors"
+	"net/http"
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
-	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/os/gctx"
 	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/internal/core/account"
+	"github.com/naidog/wechat-framework/internal/core/dll"
+	"github.com/naidog/wechat-framework/internal/core/event"
+	"github.com/naidog/wechat-framework/internal/core/sse"
 	"github.com/naidog/wechat-framework/pkg/types"
 )
 
-// SSE 客户端管理
-var (
-	sseClients      = make(map[chan string]bool)
-	sseClientsMutex sync.RWMutex
-)
-
 // Handler 回调处理器
 type Handler struct {
 	pluginBroadcaster PluginBroadcaster
+	events            *event.Registry
+	pipeline          *event.Pipeline
+	sse               *sse.Hub
+	guard             *Guard
+	accounts          *account.Registry
 }
 
 // PluginBroadcaster 插件广播接口
@@ -32,11 +31,83 @@ type PluginBroadcaster interface {
 	BroadcastEventToPlugins(eventType string, eventData interface{})
 }
 
-// NewHandler 创建回调处理器实例
-func NewHandler(pluginBroadcaster PluginBroadcaster) *Handler {
-	return &Handler{
+// NewHandler 创建回调处理器实例，guard 为 nil 时不校验回调来源
+func NewHandler(pluginBroadcaster PluginBroadcaster, guard *Guard) *Handler {
+	ctx := gctx.New()
+	h := &Handler{
 		pluginBroadcaster: pluginBroadcaster,
+		events:            event.NewRegistry(),
+		sse:               sse.NewHub(sse.LoadBufferSize(ctx)),
+		guard:             guard,
+		accounts:          account.Default(),
+	}
+	h.pipeline = event.NewPipeline(h.events, event.LoadPipelineConfig(ctx))
+
+	// 广播事件到插件和SSE客户端
+	h.events.OnAny(func(ctx context.Context, ev *event.Event) {
+		h.broadcastEvent(ctx, ev.Type, *ev.Raw)
+	})
+
+	// 账号列表与实例状态变化时广播到插件和SSE客户端
+	h.accounts.Subscribe(func(ctx context.Context, accounts []types.WechatAccount) {
+		h.broadcastEvent(ctx, types.EventAccountsUpdate, types.WechatAccountList{List: accounts})
+	})
+	h.accounts.SubscribeState(func(ctx context.Context, t account.Transition) {
+		h.broadcastEvent(ctx, types.EventAccountState, t)
+	})
+
+	// 注册内置事件处理
+	h.events.OnInjectSuccess(h.handleInjectSuccess)
+	h.events.OnLoginSuccess(h.handleLoginSuccess)
+	h.events.OnRecvMsg(h.handleRecvMsg)
+	h.events.OnTransPay(h.handleTransPay)
+	h.events.OnFriendReq(h.handleFriendReq)
+	h.events.OnGroupMemberChanges(h.handleGroupMemberChanges)
+	h.events.OnAuthExpire(h.handleAuthExpire)
+
+	return h
+}
+
+// Events 获取事件注册表，用于订阅类型化事件或注册新的事件类型
+func (h *Handler) Events() *event.Registry {
+	return h.events
+}
+
+// SSE 获取 SSE 事件中心，WebSocket 等其他推送通道共用同一事件序列
+func (h *Handler) SSE() *sse.Hub {
+	return h.sse
+}
+
+// Guard 获取回调校验器
+func (h *Handler) Guard() *Guard {
+	return h.guard
+}
+
+// Start 启动事件处理管道
+func (h *Handler) Start() {
+	h.pipeline.Start()
+}
+
+// Stop 停止事件处理管道，等待已接收的事件处理完毕
+func (h *Handler) Stop() {
+	h.pipeline.Stop()
+}
+
+// GetMetrics 获取事件管道、SSE、回调校验、微信接口调用耗时与熔断统计信息
+func (h *Handler) GetMetrics(r *ghttp.Request) {
+	data := g.Map{
+		"events":   h.pipeline.Stats(),
+		"sse":      h.sse.Stats(),
+		"dll":      dll.Stats(),
+		"breakers": dll.Breakers(),
 	}
+	if h.guard != nil {
+		data["callback"] = h.guard.Stats()
+	}
+	r.Response.WriteJson(g.Map{
+		"code": 200,
+		"data": data,
+	})
 }
 
 // HandleCallback 处理微信回调事件
@@ -44,8 +115,8 @@ func (h *Handler) HandleCallback(r *ghttp.Request) {
 	body := r.GetBody()
 	g.Log().Debugf(r.Context(), "收到回调请求，原始数据: %s", string(body))
 
-	var event types.CallbackEvent
-	if err := r.Parse(&event); err != nil {
+	var raw types.CallbackEvent
+	if err := r.Parse(&raw); err != nil {
 		g.Log().Errorf(r.Context(), "解析回调事件失败: %v, 原始数据: %s", err, string(body))
 		r.Response.WriteJson(g.Map{
 			"code": 400,
@@ -54,30 +125,30 @@ func (h *Handler) HandleCallback(r *ghttp.Request) {
 		return
 	}
 
-	g.Log().Infof(r.Context(), "收到事件: %s, 描述: %s", event.Type, event.Des)
-
-	// 根据事件类型分发处理
-	switch event.Type {
-	case "injectSuccess":
-		h.handleInjectSuccess(r.Context(), &event)
-	case "loginSuccess":
-		h.handleLoginSuccess(r.Context(), &event)
-	case "recvMsg":
-		h.handleRecvMsg(r.Context(), &event)
-	case "transPay":
-		h.handleTransPay(r.Context(), &event)
-	case "friendReq":
-		h.handleFriendReq(r.Context(), &event)
-	case "groupMemberChanges":
-		h.handleGroupMemberChanges(r.Context(), &event)
-	case "authExpire":
-		h.handleAuthExpire(r.Context(), &event)
-	default:
-		g.Log().Warningf(r.Context(), "未知事件类型: %s", event.Type)
+	// 校验回调来源，拒绝伪造的事件
+	if h.guard != nil {
+		if err := h.guard.Verify(r, &raw); err != nil {
+			r.Response.WriteHeader(http.StatusForbidden)
+			r.Response.WriteJson(g.Map{
+				"code": 403,
+				"msg":  err.Error(),
+			})
+			return
+		}
 	}
 
-	// 广播事件到插件和SSE客户端
-	h.broadcastEvent(event.Type, event)
+	g.Log().Infof(r.Context(), "收到事件: %s, 描述: %s", raw.Type, raw.Des)
+
+	// 解码后放入事件管道异步处理，立即应答 DLL
+	ev, err := h.events.Decode(&raw)
+	if err != nil {
+		if errors.Is(err, event.ErrUnknownType) {
+			g.Log().Warningf(r.Context(), "未知事件类型: %s", raw.Type)
+		} else {
+			g.Log().Errorf(r.Context(), "%v", err)
+		}
+	}
+	h.pipeline.Submit(r.Context(), ev)
 
 	r.Response.WriteJson(g.Map{
 		"code": 200,
@@ -86,126 +157,76 @@ func (h *Handler) HandleCallback(r *ghttp.Request) {
 }
 
 // handleInjectSuccess 处理注入成功事件
-func (h *Handler) handleInjectSuccess(ctx context.Context, event *types.CallbackEvent) {
-	g.Log().Infof(ctx, "注入成功 - 端口: %v, PID: %v", event.Data["port"], event.Data["pid"])
+func (h *Handler) handleInjectSuccess(ctx context.Context, acct event.Account, data *types.InjectSuccess) {
+	g.Log().Infof(ctx, "注入成功 - 端口: %v, PID: %v", data.Port, data.Pid)
+
+	port, pid := InjectedInstance(acct, data)
+	if err := h.accounts.Inject(ctx, port, pid); err != nil {
+		g.Log().Errorf(ctx, "登记微信实例失败: %v", err)
+	}
 }
 
 // handleLoginSuccess 处理登录成功事件
-func (h *Handler) handleLoginSuccess(ctx context.Context, event *types.CallbackEvent) {
-	wxid := gconv.String(event.Data["wxid"])
-	nick := gconv.String(event.Data["nick"])
-	port := event.Port
-	pid := event.Pid
-
-	g.Log().Infof(ctx, "登录成功 - 昵称: %s, wxid: %s, 端口: %d, PID: %d", nick, wxid, port, pid)
+func (h *Handler) handleLoginSuccess(ctx context.Context, acct event.Account, data *types.LoginSuccess) {
+	g.Log().Infof(ctx, "登录成功 - 昵称: %s, wxid: %s, 端口: %d, PID: %d", data.Nick, data.Wxid, acct.Port, acct.Pid)
 
-	// 更新 currentWechat.json
-	h.updateCurrentWechat(ctx, event)
+	// 登记到账号注册表
+	h.updateCurrentWechat(ctx, acct, data)
 }
 
 // handleRecvMsg 处理接收消息事件
-func (h *Handler) handleRecvMsg(ctx context.Context, event *types.CallbackEvent) {
-	msgType := gconv.Int(event.Data["msgType"])
-	fromWxid := gconv.String(event.Data["fromWxid"])
-	msg := gconv.String(event.Data["msg"])
-
-	g.Log().Debugf(ctx, "收到消息 - 类型: %d, 来自: %s, 内容: %s", msgType, fromWxid, msg)
+func (h *Handler) handleRecvMsg(ctx context.Context, acct event.Account, data *types.RecvMsg) {
+	g.Log().Debugf(ctx, "收到消息 - 类型: %d, 来自: %s, 内容: %s", data.MsgType, data.FromWxid, data.Msg)
 }
 
 // handleTransPay 处理转账事件
-func (h *Handler) handleTransPay(ctx context.Context, event *types.CallbackEvent) {
-	fromWxid := gconv.String(event.Data["fromWxid"])
-	money := gconv.String(event.Data["money"])
-	memo := gconv.String(event.Data["memo"])
-
-	g.Log().Infof(ctx, "收到转账 - 来自: %s, 金额: %s, 备注: %s", fromWxid, money, memo)
+func (h *Handler) handleTransPay(ctx context.Context, acct event.Account, data *types.TransPay) {
+	g.Log().Infof(ctx, "收到转账 - 来自: %s, 金额: %s, 备注: %s", data.FromWxid, data.Money, data.Memo)
 }
 
 // handleFriendReq 处理好友请求事件
-func (h *Handler) handleFriendReq(ctx context.Context, event *types.CallbackEvent) {
-	wxid := gconv.String(event.Data["wxid"])
-	nick := gconv.String(event.Data["nick"])
-	content := gconv.String(event.Data["content"])
-
-	g.Log().Infof(ctx, "收到好友请求 - wxid: %s, 昵称: %s, 内容: %s", wxid, nick, content)
+func (h *Handler) handleFriendReq(ctx context.Context, acct event.Account, data *types.FriendReq) {
+	g.Log().Infof(ctx, "收到好友请求 - wxid: %s, 昵称: %s, 内容: %s", data.Wxid, data.Nick, data.Content)
 }
 
 // handleGroupMemberChanges 处理群成员变动事件
-func (h *Handler) handleGroupMemberChanges(ctx context.Context, event *types.CallbackEvent) {
-	fromWxid := gconv.String(event.Data["fromWxid"])
-	eventType := gconv.String(event.Data["eventType"])
-
-	g.Log().Infof(ctx, "群成员变动 - 群: %s, 事件: %s", fromWxid, eventType)
+func (h *Handler) handleGroupMemberChanges(ctx context.Context, acct event.Account, data *types.GroupMemberChanges) {
+	g.Log().Infof(ctx, "群成员变动 - 群: %s, 事件: %d", data.FromWxid, data.EventType)
 }
 
 // handleAuthExpire 处理授权到期事件
-func (h *Handler) handleAuthExpire(ctx context.Context, event *types.CallbackEvent) {
-	wxid := event.Wxid
-	expireTime := gconv.String(event.Data["expireTime"])
+func (h *Handler) handleAuthExpire(ctx context.Context, acct event.Account, data *types.AuthExpire) {
+	g.Log().Warningf(ctx, "授权到期 - wxid: %s, 到期时间: %s", acct.Wxid, data.ExpireTime)
 
-	g.Log().Warningf(ctx, "授权到期 - wxid: %s, 到期时间: %s", wxid, expireTime)
+	if err := h.accounts.Expire(ctx, acct.Port, data.ExpireTime, "收到授权到期事件"); err != nil {
+		g.Log().Errorf(ctx, "更新账号授权信息失败: %v", err)
+	}
 }
 
-// updateCurrentWechat 更新当前微信账号信息
-func (h *Handler) updateCurrentWechat(ctx context.Context, event *types.CallbackEvent) {
-	accountFilePath := "resources/currentWechat.json"
-
-	// 确保目录存在
-	dir := filepath.Dir(accountFilePath)
-	if !gfile.Exists(dir) {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			g.Log().Errorf(ctx, "创建目录失败: %v", err)
-			return
-		}
+// InjectedInstance 注入成功事件的端口和 PID，外层未携带时取 data 中的值
+func InjectedInstance(acct event.Account, data *types.InjectSuccess) (int, int) {
+	port, pid := acct.Port, acct.Pid
+	if port == 0 {
+		port = gconv.Int(data.Port)
 	}
-
-	// 读取现有账号列表
-	var accountList types.WechatAccountList
-	if gfile.Exists(accountFilePath) {
-		content := gfile.GetBytes(accountFilePath)
-		if len(content) > 0 {
-			if err := json.Unmarshal(content, &accountList); err != nil {
-				g.Log().Errorf(ctx, "解析账号文件失败: %v", err)
-				accountList = types.WechatAccountList{List: []types.WechatAccount{}}
-			}
-		}
+	if pid == 0 {
+		pid = gconv.Int(data.Pid)
 	}
+	return port, pid
+}
 
-	// 创建新账号
+// updateCurrentWechat 登记登录成功的账号，账号注册表负责保存并推送账号变化
+func (h *Handler) updateCurrentWechat(ctx context.Context, acct event.Account, data *types.LoginSuccess) {
 	newAccount := types.WechatAccount{
-		Wxid:       gconv.String(event.Data["wxid"]),
-		WxNum:      gconv.String(event.Data["wxNum"]),
-		Nick:       gconv.String(event.Data["nick"]),
-		AvatarUrl:  gconv.String(event.Data["avatarUrl"]),
-		Port:       event.Port,
-		Pid:        event.Pid,
-		ExpireTime: "",
-		IsExpire:   0,
-	}
-
-	// 检查是否已存在
-	found := false
-	for i, acc := range accountList.List {
-		if acc.Wxid == newAccount.Wxid {
-			accountList.List[i] = newAccount
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		accountList.List = append(accountList.List, newAccount)
-	}
-
-	// 写入文件
-	jsonData, err := json.MarshalIndent(accountList, "", "  ")
-	if err != nil {
-		g.Log().Errorf(ctx, "序列化账号数据失败: %v", err)
-		return
+		Wxid:      data.Wxid,
+		WxNum:     data.WxNum,
+		Nick:      data.Nick,
+		AvatarUrl: data.AvatarUrl,
+		Port:      acct.Port,
+		Pid:       acct.Pid,
 	}
-
-	if err := os.WriteFile(accountFilePath, jsonData, 0644); err != nil {
-		g.Log().Errorf(ctx, "写入账号文件失败: %v", err)
+	if err := h.accounts.Login(ctx, newAccount); err != nil {
+		g.Log().Errorf(ctx, "登记账号失败: %v", err)
 		return
 	}
 
@@ -213,86 +234,19 @@ func (h *Handler) updateCurrentWechat(ctx context.Context, event *types.Callback
 }
 
 // broadcastEvent 广播事件到插件和SSE客户端
-func (h *Handler) broadcastEvent(eventType string, eventData interface{}) {
+func (h *Handler) broadcastEvent(ctx context.Context, eventType string, eventData interface{}) {
 	// 广播到插件
 	if h.pluginBroadcaster != nil {
 		h.pluginBroadcaster.BroadcastEventToPlugins(eventType, eventData)
 	}
 
 	// 广播到SSE客户端
-	BroadcastEventToSSE(eventType, eventData)
-}
-
-// BroadcastEventToSSE 广播事件给所有 SSE 客户端
-func BroadcastEventToSSE(eventType string, eventData interface{}) {
-	sseClientsMutex.RLock()
-	defer sseClientsMutex.RUnlock()
-
-	data, err := json.Marshal(map[string]interface{}{
-		"type": eventType,
-		"data": eventData,
-	})
-	if err != nil {
-		g.Log().Errorf(nil, "SSE 事件序列化失败: %v", err)
-		return
-	}
-
-	message := fmt.Sprintf("data: %s\n\n", string(data))
-
-	for client := range sseClients {
-		select {
-		case client <- message:
-		default:
-			// 客户端阻塞，跳过
-		}
+	if _, err := h.sse.Publish(eventType, eventData); err != nil {
+		g.Log().Errorf(ctx, "%v", err)
 	}
 }
 
-// AddSSEClient 添加 SSE 客户端
-func AddSSEClient(client chan string) {
-	sseClientsMutex.Lock()
-	defer sseClientsMutex.Unlock()
-	sseClients[client] = true
-}
-
-// RemoveSSEClient 移除 SSE 客户端
-func RemoveSSEClient(client chan string) {
-	sseClientsMutex.Lock()
-	defer sseClientsMutex.Unlock()
-	delete(sseClients, client)
-	close(client)
-}
-
-// HandleSSEEvents 处理 SSE 事件流
-func HandleSSEEvents(r *ghttp.Request) {
-	r.Response.Header().Set("Content-Type", "text/event-stream")
-	r.Response.Header().Set("Cache-Control", "no-cache")
-	r.Response.Header().Set("Connection", "keep-alive")
-	r.Response.Header().Set("Access-Control-Allow-Origin", "*")
-
-	client := make(chan string, 10)
-	AddSSEClient(client)
-	defer RemoveSSEClient(client)
-
-	// 发送连接成功消息
-	r.Response.Write([]byte("data: " + `{"type":"connected","data":{"message":"连接成功"}}` + "\n\n"))
-	r.Response.Flush()
-
-	ctx := r.Context()
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case message := <-client:
-			r.Response.Write([]byte(message))
-			r.Response.Flush()
-		case <-ticker.C:
-			// 发送心跳
-			r.Response.Write([]byte(": heartbeat\n\n"))
-			r.Response.Flush()
-		}
-	}
+// HandleSSEEvents 处理 SSE 事件流，支持通过 Last-Event-ID 补发断线期间的事件
+func (h *Handler) HandleSSEEvents(r *ghttp.Request) {
+	h.sse.Serve(r)
 }
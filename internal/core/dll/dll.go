@@ -0,0 +1,89 @@
+// Package dll 框架调用本机微信接口共用的客户端。
+//
+// 代理、账号心跳与授权检查共用同一个客户端：所有账号端口共享连接池，
+// 超时按接口配置（dll.timeout / dll.timeouts），调用耗时统一统计。
+// 同一端口连续请求失败后熔断（dll.breaker），代理调用直接失败，不再等待超时。
+package dll
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/naidog/wechat-framework/pkg/client"
+)
+
+const (
+	DefaultTimeout         = client.DefaultTimeout // 未单独配置超时的接口的默认超时（30s）
+	DefaultBreakerFailures = 3                     // 默认连续失败多少次后熔断
+)
+
+var (
+	once   sync.Once
+	shared *client.Client
+)
+
+// LoadConfig 从配置文件读取微信接口客户端配置（dll.timeout / dll.timeouts / dll.breaker）
+func LoadConfig(ctx context.Context) client.Config {
+	cfg := client.Config{
+		Timeout: DefaultTimeout,
+		Breaker: client.BreakerConfig{
+			Failures:    DefaultBreakerFailures,
+			OpenTimeout: client.DefaultOpenTimeout,
+		},
+	}
+	if v, err := g.Cfg().Get(ctx, "dll.timeout"); err == nil && v.String() != "" {
+		if d, err := time.ParseDuration(v.String()); err == nil && d > 0 {
+			cfg.Timeout = d
+		} else {
+			g.Log().Warningf(ctx, "dll.timeout 配置格式错误: %s", v.String())
+		}
+	}
+	if v, err := g.Cfg().Get(ctx, "dll.timeouts"); err == nil && !v.IsNil() {
+		cfg.Timeouts = make(map[string]time.Duration)
+		for apiType, value := range v.MapStrStr() {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				g.Log().Warningf(ctx, "dll.timeouts.%s 配置格式错误: %s", apiType, value)
+				continue
+			}
+			cfg.Timeouts[apiType] = d
+		}
+	}
+	if v, err := g.Cfg().Get(ctx, "dll.breaker.failures"); err == nil && v.Int() > 0 {
+		cfg.Breaker.Failures = v.Int()
+	}
+	if v, err := g.Cfg().Get(ctx, "dll.breaker.openTimeout"); err == nil && v.String() != "" {
+		if d, err := time.ParseDuration(v.String()); err == nil && d > 0 {
+			cfg.Breaker.OpenTimeout = d
+		} else {
+			g.Log().Warningf(ctx, "dll.breaker.openTimeout 配置格式错误: %s", v.String())
+		}
+	}
+	return cfg
+}
+
+// Client 获取共用的微信接口客户端，首次调用时按配置文件创建
+func Client() *client.Client {
+	once.Do(func() {
+		shared = client.New(LoadConfig(gctx.New()))
+	})
+	return shared
+}
+
+// Stats 获取各端口、各接口的调用耗时统计
+func Stats() []client.LatencyStats {
+	return Client().Stats()
+}
+
+// Breakers 获取各端口的熔断状态
+func Breakers() []client.BreakerStats {
+	return Client().Breakers()
+}
+
+// Down 判断端口是否已熔断
+func Down(port int) bool {
+	return Client().BreakerState(port) == client.BreakerOpen
+}
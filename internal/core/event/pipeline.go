@@ -0,0 +1,164 @@
+package event
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	DefaultWorkers   = 4    // 默认工作协程数
+	DefaultQueueSize = 1024 // 默认队列总容量
+)
+
+// PipelineConfig 事件管道配置
+type PipelineConfig struct {
+	Workers   int `json:"workers"`   // 工作协程数
+	QueueSize int `json:"queueSize"` // 队列总容量（平均分配给各工作协程）
+}
+
+// PipelineStats 事件管道统计
+type PipelineStats struct {
+	Workers   int    `json:"workers"`   // 工作协程数
+	Capacity  int    `json:"capacity"`  // 队列总容量
+	Depth     int    `json:"depth"`     // 当前排队事件数
+	Enqueued  uint64 `json:"enqueued"`  // 累计入队事件数
+	Processed uint64 `json:"processed"` // 累计处理事件数
+	Dropped   uint64 `json:"dropped"`   // 累计因队列已满丢弃的事件数
+}
+
+// Pipeline 异步事件管道
+// 每个工作协程拥有独立的有界队列，同一微信实例（端口）的事件总是进入同一队列，从而保证账号内的事件顺序。
+type Pipeline struct {
+	registry  *Registry
+	queues    []chan queuedEvent
+	wg        sync.WaitGroup
+	mu        sync.RWMutex // 保护 stopped 与队列关闭
+	stopped   bool
+	enqueued  atomic.Uint64
+	processed atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// queuedEvent 队列中的事件
+type queuedEvent struct {
+	ctx context.Context
+	ev  *Event
+}
+
+// LoadPipelineConfig 从配置文件读取事件管道配置（event.workers / event.queueSize）
+func LoadPipelineConfig(ctx context.Context) PipelineConfig {
+	cfg := PipelineConfig{
+		Workers:   DefaultWorkers,
+		QueueSize: DefaultQueueSize,
+	}
+	if v, err := g.Cfg().Get(ctx, "event.workers"); err == nil && v.Int() > 0 {
+		cfg.Workers = v.Int()
+	}
+	if v, err := g.Cfg().Get(ctx, "event.queueSize"); err == nil && v.Int() > 0 {
+		cfg.QueueSize = v.Int()
+	}
+	return cfg
+}
+
+// NewPipeline 创建事件管道，事件由 registry 分发给订阅者
+func NewPipeline(registry *Registry, cfg PipelineConfig) *Pipeline {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.QueueSize < cfg.Workers {
+		cfg.QueueSize = cfg.Workers
+	}
+
+	p := &Pipeline{
+		registry: registry,
+		queues:   make([]chan queuedEvent, cfg.Workers),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan queuedEvent, cfg.QueueSize/cfg.Workers)
+	}
+	return p
+}
+
+// Start 启动工作协程
+func (p *Pipeline) Start() {
+	for _, queue := range p.queues {
+		p.wg.Add(1)
+		go p.worker(queue)
+	}
+}
+
+// Stop 停止接收新事件，并等待已入队的事件处理完毕
+func (p *Pipeline) Stop() {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// Submit 将事件放入队列，不阻塞调用方；队列已满或管道已停止时丢弃事件并返回 false
+func (p *Pipeline) Submit(ctx context.Context, ev *Event) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		p.dropped.Add(1)
+		return false
+	}
+
+	queue := p.queues[p.shard(ev)]
+	select {
+	case queue <- queuedEvent{ctx: context.WithoutCancel(ctx), ev: ev}:
+		p.enqueued.Add(1)
+		return true
+	default:
+		p.dropped.Add(1)
+		g.Log().Warningf(ctx, "事件队列已满，丢弃事件 [%s] wxid: %s, 累计丢弃: %d", ev.Type, ev.Account.Wxid, p.dropped.Load())
+		return false
+	}
+}
+
+// Stats 获取管道统计信息
+func (p *Pipeline) Stats() PipelineStats {
+	stats := PipelineStats{
+		Workers:   len(p.queues),
+		Enqueued:  p.enqueued.Load(),
+		Processed: p.processed.Load(),
+		Dropped:   p.dropped.Load(),
+	}
+	for _, queue := range p.queues {
+		stats.Capacity += cap(queue)
+		stats.Depth += len(queue)
+	}
+	return stats
+}
+
+// shard 按端口选择队列。端口在登录前后不变，同一实例登录前（注入成功、登录二维码）
+// 与登录后的事件进入同一队列；按 wxid 选择会让登录前后的事件落到不同队列。
+// 外层未携带端口的事件在 Decode 中已从 data 取出端口
+func (p *Pipeline) shard(ev *Event) int {
+	h := fnv.New32a()
+	h.Write([]byte(strconv.Itoa(ev.Account.Port)))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+// worker 按顺序处理单个队列中的事件
+func (p *Pipeline) worker(queue chan queuedEvent) {
+	defer p.wg.Done()
+	for item := range queue {
+		p.registry.Publish(item.ctx, item.ev)
+		p.processed.Add(1)
+	}
+}
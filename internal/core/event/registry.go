@@ -0,0 +1,249 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// ErrUnknownType 事件类型未注册
+var ErrUnknownType = errors.New("未知事件类型")
+
+// Account 事件所属的微信账号（回调外层字段）
+type Account struct {
+	Wxid string `json:"wxid"` // 微信ID
+	Port int    `json:"port"` // 端口
+	Pid  int    `json:"pid"`  // 进程ID
+}
+
+// Event 解码后的回调事件
+type Event struct {
+	Type    string               // 事件类型
+	Account Account              // 所属账号
+	Time    time.Time            // 外层时间戳
+	Payload interface{}          // 类型化事件数据，未注册的类型为 nil
+	Raw     *types.CallbackEvent // 原始回调事件
+}
+
+// Decoder 将原始回调事件解码为类型化数据
+type Decoder func(raw *types.CallbackEvent) (interface{}, error)
+
+// Handler 事件处理函数
+type Handler func(ctx context.Context, ev *Event)
+
+// Registry 事件注册表，负责解码回调事件并分发给订阅者
+type Registry struct {
+	mu       sync.RWMutex
+	decoders map[string]Decoder   // 事件类型 -> 解码器
+	handlers map[string][]Handler // 事件类型 -> 处理函数
+	any      []Handler            // 订阅全部事件的处理函数
+}
+
+// NewRegistry 创建事件注册表，内置事件类型已注册
+func NewRegistry() *Registry {
+	r := &Registry{
+		decoders: make(map[string]Decoder),
+		handlers: make(map[string][]Handler),
+	}
+
+	r.RegisterType(types.EventInjectSuccess, StructDecoder(func() interface{} { return &types.InjectSuccess{} }))
+	r.RegisterType(types.EventLoginSuccess, StructDecoder(func() interface{} { return &types.LoginSuccess{} }))
+	r.RegisterType(types.EventFriendReq, StructDecoder(func() interface{} { return &types.FriendReq{} }))
+	r.RegisterType(types.EventAuthExpire, StructDecoder(func() interface{} { return &types.AuthExpire{} }))
+	r.RegisterType(types.EventRecvMsg, func(raw *types.CallbackEvent) (interface{}, error) {
+		var msg types.RecvMsg
+		if err := gconv.Struct(raw.Data, &msg); err != nil {
+			return nil, err
+		}
+		msg.Time = types.ParseTimestamp(msg.TimeStamp)
+		return &msg, nil
+	})
+	r.RegisterType(types.EventTransPay, func(raw *types.CallbackEvent) (interface{}, error) {
+		var pay types.TransPay
+		if err := gconv.Struct(raw.Data, &pay); err != nil {
+			return nil, err
+		}
+		pay.InvalidAt = types.ParseTimestamp(pay.Invalidtime)
+		return &pay, nil
+	})
+	r.RegisterType(types.EventGroupMemberChanges, func(raw *types.CallbackEvent) (interface{}, error) {
+		var changes types.GroupMemberChanges
+		if err := gconv.Struct(raw.Data, &changes); err != nil {
+			return nil, err
+		}
+		changes.Time = types.ParseTimestamp(changes.TimeStamp)
+		return &changes, nil
+	})
+
+	return r
+}
+
+// StructDecoder 创建按 json 标签映射到结构体的解码器，newPayload 需返回结构体指针
+func StructDecoder(newPayload func() interface{}) Decoder {
+	return func(raw *types.CallbackEvent) (interface{}, error) {
+		payload := newPayload()
+		if err := gconv.Struct(raw.Data, payload); err != nil {
+			return nil, err
+		}
+		return payload, nil
+	}
+}
+
+// RegisterType 注册事件类型及其解码器，重复注册会覆盖旧的解码器
+func (r *Registry) RegisterType(eventType string, decode Decoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decoders[eventType] = decode
+}
+
+// On 订阅指定类型的事件
+func (r *Registry) On(eventType string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[eventType] = append(r.handlers[eventType], h)
+}
+
+// OnAny 订阅全部事件（包括未注册类型的事件）
+func (r *Registry) OnAny(h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.any = append(r.any, h)
+}
+
+// OnInjectSuccess 订阅注入成功事件
+func (r *Registry) OnInjectSuccess(fn func(ctx context.Context, acct Account, data *types.InjectSuccess)) {
+	r.On(types.EventInjectSuccess, func(ctx context.Context, ev *Event) {
+		if data, ok := ev.Payload.(*types.InjectSuccess); ok {
+			fn(ctx, ev.Account, data)
+		}
+	})
+}
+
+// OnLoginSuccess 订阅登录成功事件
+func (r *Registry) OnLoginSuccess(fn func(ctx context.Context, acct Account, data *types.LoginSuccess)) {
+	r.On(types.EventLoginSuccess, func(ctx context.Context, ev *Event) {
+		if data, ok := ev.Payload.(*types.LoginSuccess); ok {
+			fn(ctx, ev.Account, data)
+		}
+	})
+}
+
+// OnRecvMsg 订阅收到消息事件
+func (r *Registry) OnRecvMsg(fn func(ctx context.Context, acct Account, data *types.RecvMsg)) {
+	r.On(types.EventRecvMsg, func(ctx context.Context, ev *Event) {
+		if data, ok := ev.Payload.(*types.RecvMsg); ok {
+			fn(ctx, ev.Account, data)
+		}
+	})
+}
+
+// OnTransPay 订阅转账事件
+func (r *Registry) OnTransPay(fn func(ctx context.Context, acct Account, data *types.TransPay)) {
+	r.On(types.EventTransPay, func(ctx context.Context, ev *Event) {
+		if data, ok := ev.Payload.(*types.TransPay); ok {
+			fn(ctx, ev.Account, data)
+		}
+	})
+}
+
+// OnFriendReq 订阅好友请求事件
+func (r *Registry) OnFriendReq(fn func(ctx context.Context, acct Account, data *types.FriendReq)) {
+	r.On(types.EventFriendReq, func(ctx context.Context, ev *Event) {
+		if data, ok := ev.Payload.(*types.FriendReq); ok {
+			fn(ctx, ev.Account, data)
+		}
+	})
+}
+
+// OnGroupMemberChanges 订阅群成员变动事件
+func (r *Registry) OnGroupMemberChanges(fn func(ctx context.Context, acct Account, data *types.GroupMemberChanges)) {
+	r.On(types.EventGroupMemberChanges, func(ctx context.Context, ev *Event) {
+		if data, ok := ev.Payload.(*types.GroupMemberChanges); ok {
+			fn(ctx, ev.Account, data)
+		}
+	})
+}
+
+// OnAuthExpire 订阅授权到期事件
+func (r *Registry) OnAuthExpire(fn func(ctx context.Context, acct Account, data *types.AuthExpire)) {
+	r.On(types.EventAuthExpire, func(ctx context.Context, ev *Event) {
+		if data, ok := ev.Payload.(*types.AuthExpire); ok {
+			fn(ctx, ev.Account, data)
+		}
+	})
+}
+
+// Decode 解码原始回调事件，未注册的类型返回 ErrUnknownType，但仍返回可分发的事件
+func (r *Registry) Decode(raw *types.CallbackEvent) (*Event, error) {
+	ev := &Event{
+		Type: raw.Type,
+		Account: Account{
+			Wxid: raw.Wxid,
+			Port: raw.Port,
+			Pid:  raw.Pid,
+		},
+		Time: types.ParseTimestamp(raw.Timestamp),
+		Raw:  raw,
+	}
+	// 注入成功等事件外层可能不带端口和 PID，只在 data 中，解码时统一取出，
+	// 保证同一实例的事件按端口进入同一队列
+	if ev.Account.Port == 0 {
+		ev.Account.Port = gconv.Int(raw.Data["port"])
+	}
+	if ev.Account.Pid == 0 {
+		ev.Account.Pid = gconv.Int(raw.Data["pid"])
+	}
+
+	r.mu.RLock()
+	decode, ok := r.decoders[raw.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return ev, ErrUnknownType
+	}
+
+	payload, err := decode(raw)
+	if err != nil {
+		return ev, fmt.Errorf("解码事件 %s 失败: %v", raw.Type, err)
+	}
+	ev.Payload = payload
+	return ev, nil
+}
+
+// Publish 将已解码的事件分发给订阅者，全部事件的订阅者先于类型订阅者执行
+// 单个处理函数 panic 不影响其他订阅者
+func (r *Registry) Publish(ctx context.Context, ev *Event) {
+	r.mu.RLock()
+	handlers := make([]Handler, 0, len(r.handlers[ev.Type])+len(r.any))
+	handlers = append(handlers, r.any...)
+	if ev.Payload != nil {
+		handlers = append(handlers, r.handlers[ev.Type]...)
+	}
+	r.mu.RUnlock()
+
+	for _, h := range handlers {
+		r.call(ctx, h, ev)
+	}
+}
+
+// Dispatch 解码并分发回调事件
+func (r *Registry) Dispatch(ctx context.Context, raw *types.CallbackEvent) (*Event, error) {
+	ev, err := r.Decode(raw)
+	r.Publish(ctx, ev)
+	return ev, err
+}
+
+// call 调用单个处理函数并捕获 panic
+func (r *Registry) call(ctx context.Context, h Handler, ev *Event) {
+	defer func() {
+		if p := recover(); p != nil {
+			g.Log().Errorf(ctx, "事件处理函数异常 [%s]: %v", ev.Type, p)
+		}
+	}()
+	h(ctx, ev)
+}
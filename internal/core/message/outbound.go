@@ -0,0 +1,75 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// 发送接口对应的消息类型
+var outboundMsgTypes = map[string]int{
+	"sendText":         1,
+	"sendText2":        1,
+	"sendReferText":    1,
+	"sendAtText":       1,
+	"sendImage":        3,
+	"sendCard":         42,
+	"sendVideo":        43,
+	"sendGif":          47,
+	"sendEmoji":        47,
+	"sendLocationInfo": 48,
+	"sendLocation":     48,
+}
+
+// IsOutbound 判断接口是否为发送消息接口
+func IsOutbound(apiType string) bool {
+	return strings.HasPrefix(apiType, "send")
+}
+
+// Outbound 根据发送接口的请求体与响应构造消息记录
+// account 为发送账号 wxid，resp 为 DLL 原始响应，响应中没有消息ID时生成本地ID
+func Outbound(account, apiType string, data map[string]interface{}, resp []byte) *Message {
+	msg := &Message{
+		Account:   account,
+		Chat:      gconv.String(data["wxid"]),
+		Sender:    account,
+		MsgType:   outboundMsgTypes[apiType],
+		Direction: DirectionOut,
+		Time:      time.Now(),
+	}
+	if msg.MsgType == 0 {
+		msg.MsgType = 49
+	}
+	if strings.HasSuffix(msg.Chat, "@chatroom") {
+		msg.FromType = 2
+	} else {
+		msg.FromType = 1
+	}
+
+	// 文本类接口直接记录内容，其余接口记录请求参数
+	if text, ok := data["msg"].(string); ok {
+		msg.Content = text
+	} else if content, err := json.Marshal(data); err == nil {
+		msg.Content = string(content)
+	}
+
+	// 优先使用 DLL 返回的消息ID；sendId 与 recvMsg 回显中的 sendId 相同，用于去重
+	var result struct {
+		Result map[string]interface{} `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &result); err == nil && result.Result != nil {
+		msg.SendId = gconv.String(result.Result["sendId"])
+		msg.MsgId = gconv.String(result.Result["msgId"])
+	}
+	if msg.MsgId == "" {
+		msg.MsgId = msg.SendId
+	}
+	if msg.MsgId == "" {
+		msg.MsgId = fmt.Sprintf("out_%s_%d", apiType, msg.Time.UnixNano())
+	}
+
+	return msg
+}
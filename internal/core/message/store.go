@@ -0,0 +1,324 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/naidog/wechat-framework/internal/core/event"
+	"github.com/naidog/wechat-framework/pkg/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	DefaultStorePath = "resources/messages.db" // 默认消息库路径
+	DefaultLimit     = 50                      // 默认查询条数
+	MaxLimit         = 500                     // 单次查询最大条数
+)
+
+// 消息方向
+const (
+	DirectionIn  = "in"  // 收到的消息
+	DirectionOut = "out" // 通过代理发出的消息
+)
+
+var (
+	bucketMessages  = []byte("messages")   // msgId -> 消息 JSON
+	bucketByChat    = []byte("by_chat")    // account \x00 chat \x00 时间 msgId -> msgId
+	bucketByAccount = []byte("by_account") // account \x00 时间 msgId -> msgId
+	bucketByTime    = []byte("by_time")    // 时间 msgId -> msgId
+	bucketBySend    = []byte("by_send")    // sendId -> msgId，用于合并发出的消息与其回显
+)
+
+// Message 持久化的消息记录
+type Message struct {
+	MsgId     string    `json:"msgId"`            // 消息ID
+	Account   string    `json:"account"`          // 所属账号 wxid
+	Chat      string    `json:"chat"`             // 会话 wxid（私聊对方或群聊）
+	Sender    string    `json:"sender"`           // 发送人 wxid（群聊为 finalFromWxid）
+	FromType  int       `json:"fromType"`         // 来源类型：1私聊 2群聊 3公众号
+	MsgType   int       `json:"msgType"`          // 消息类型
+	Content   string    `json:"content"`          // 消息内容
+	Direction string    `json:"direction"`        // 消息方向：in 收到 / out 发出
+	SendId    string    `json:"sendId,omitempty"` // 消息发送请求ID，发出的消息与其 recvMsg 回显相同
+	Time      time.Time `json:"time"`             // 消息时间
+}
+
+// Query 消息查询条件
+type Query struct {
+	Account string    // 所属账号 wxid，可为空
+	Chat    string    // 会话 wxid，可为空
+	Before  time.Time // 只返回早于该时间的消息，零值表示不限制
+	Limit   int       // 返回条数
+}
+
+// Store 基于 bbolt 的消息存储
+type Store struct {
+	db *bolt.DB
+}
+
+// LoadStorePath 从配置文件读取消息库路径（message.path）
+func LoadStorePath(ctx context.Context) string {
+	if v, err := g.Cfg().Get(ctx, "message.path"); err == nil && v.String() != "" {
+		return v.String()
+	}
+	return DefaultStorePath
+}
+
+// Open 打开（或创建）消息库
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建消息库目录失败: %v", err)
+	}
+
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("打开消息库失败: %v", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketMessages, bucketByChat, bucketByAccount, bucketByTime, bucketBySend} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化消息库失败: %v", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close 关闭消息库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Attach 订阅收到消息事件，将每条消息写入消息库
+func (s *Store) Attach(registry *event.Registry) {
+	registry.OnRecvMsg(func(ctx context.Context, acct event.Account, data *types.RecvMsg) {
+		if err := s.Save(FromRecvMsg(acct, data)); err != nil {
+			g.Log().Warningf(ctx, "保存消息失败 (msgId:%s): %v", data.MsgId, err)
+		}
+	})
+}
+
+// Save 保存消息，相同 msgId 的消息会被覆盖。
+// 通过代理发出的消息与其 recvMsg 回显带有相同的 sendId，先保存的一条生效，后到的一条被忽略
+func (s *Store) Save(msg *Message) error {
+	if msg.MsgId == "" {
+		return fmt.Errorf("msgId 为空")
+	}
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+
+	value, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		messages := tx.Bucket(bucketMessages)
+		id := []byte(msg.MsgId)
+
+		bySend := tx.Bucket(bucketBySend)
+		if msg.SendId != "" {
+			if existing := bySend.Get([]byte(msg.SendId)); existing != nil && !bytes.Equal(existing, id) && messages.Get(existing) != nil {
+				return nil
+			}
+		}
+
+		// 覆盖前先移除旧索引（含旧的 sendId 索引），再写入新索引
+		if old := messages.Get(id); old != nil {
+			var oldMsg Message
+			if err := json.Unmarshal(old, &oldMsg); err == nil {
+				if err := deleteIndexes(tx, &oldMsg); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := messages.Put(id, value); err != nil {
+			return err
+		}
+		if msg.SendId != "" {
+			if err := bySend.Put([]byte(msg.SendId), id); err != nil {
+				return err
+			}
+		}
+		if err := tx.Bucket(bucketByChat).Put(chatKey(msg), id); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketByAccount).Put(accountKey(msg), id); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketByTime).Put(timeKey(msg.Time, msg.MsgId), id)
+	})
+}
+
+// Get 根据 msgId 获取消息，不存在时返回 nil
+func (s *Store) Get(msgId string) (*Message, error) {
+	var msg *Message
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(bucketMessages).Get([]byte(msgId))
+		if value == nil {
+			return nil
+		}
+		msg = &Message{}
+		return json.Unmarshal(value, msg)
+	})
+	return msg, err
+}
+
+// Query 按时间倒序查询消息
+func (s *Store) Query(q Query) ([]Message, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+
+	// 选择最精确的索引
+	bucket, prefix := bucketByTime, []byte(nil)
+	switch {
+	case q.Account != "" && q.Chat != "":
+		bucket, prefix = bucketByChat, []byte(q.Account+"\x00"+q.Chat+"\x00")
+	case q.Account != "":
+		bucket, prefix = bucketByAccount, []byte(q.Account+"\x00")
+	}
+
+	result := make([]Message, 0, q.Limit)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		messages := tx.Bucket(bucketMessages)
+		c := tx.Bucket(bucket).Cursor()
+
+		var k, v []byte
+		if q.Before.IsZero() {
+			k, v = seekLastWithPrefix(c, prefix)
+		} else {
+			k, v = c.Seek(append(append([]byte{}, prefix...), timeBytes(q.Before)...))
+			if k == nil {
+				k, v = c.Last()
+			} else {
+				k, v = c.Prev()
+			}
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, prefix) && len(result) < q.Limit; k, v = c.Prev() {
+			value := messages.Get(v)
+			if value == nil {
+				continue
+			}
+			var msg Message
+			if err := json.Unmarshal(value, &msg); err != nil {
+				continue
+			}
+			// 只按会话过滤时没有对应索引，在时间索引上过滤
+			if q.Chat != "" && msg.Chat != q.Chat {
+				continue
+			}
+			result = append(result, msg)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// FromRecvMsg 将收到消息事件转换为消息记录
+func FromRecvMsg(acct event.Account, data *types.RecvMsg) *Message {
+	sender := data.FinalFromWxid
+	if sender == "" {
+		if data.MsgSource == 1 {
+			sender = acct.Wxid
+		} else {
+			sender = data.FromWxid
+		}
+	}
+
+	// 自己发送的消息（含通过代理发出的消息的回显）记为发出
+	direction := DirectionIn
+	if data.MsgSource == 1 {
+		direction = DirectionOut
+	}
+
+	msgTime := data.Time
+	if msgTime.IsZero() {
+		msgTime = time.Now()
+	}
+
+	return &Message{
+		MsgId:     data.MsgId,
+		Account:   acct.Wxid,
+		Chat:      data.FromWxid,
+		Sender:    sender,
+		FromType:  data.FromType,
+		MsgType:   data.MsgType,
+		Content:   data.Msg,
+		Direction: direction,
+		SendId:    data.SendId,
+		Time:      msgTime,
+	}
+}
+
+// seekLastWithPrefix 定位到带前缀的最后一个键
+func seekLastWithPrefix(c *bolt.Cursor, prefix []byte) ([]byte, []byte) {
+	if len(prefix) == 0 {
+		return c.Last()
+	}
+	// 前缀以 \x00 结尾，将其替换为 \x01 即得到紧随其后的键
+	upper := append(append([]byte{}, prefix[:len(prefix)-1]...), 0x01)
+	k, _ := c.Seek(upper)
+	if k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
+
+// deleteIndexes 删除消息的全部索引
+func deleteIndexes(tx *bolt.Tx, msg *Message) error {
+	if err := tx.Bucket(bucketByChat).Delete(chatKey(msg)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketByAccount).Delete(accountKey(msg)); err != nil {
+		return err
+	}
+	if msg.SendId != "" {
+		if err := tx.Bucket(bucketBySend).Delete([]byte(msg.SendId)); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(bucketByTime).Delete(timeKey(msg.Time, msg.MsgId))
+}
+
+// chatKey 会话索引键
+func chatKey(msg *Message) []byte {
+	return append([]byte(msg.Account+"\x00"+msg.Chat+"\x00"), timeKey(msg.Time, msg.MsgId)...)
+}
+
+// accountKey 账号索引键
+func accountKey(msg *Message) []byte {
+	return append([]byte(msg.Account+"\x00"), timeKey(msg.Time, msg.MsgId)...)
+}
+
+// timeKey 时间索引键：8 字节大端毫秒时间戳 + msgId
+func timeKey(t time.Time, msgId string) []byte {
+	return append(timeBytes(t), msgId...)
+}
+
+// timeBytes 8 字节大端毫秒时间戳，保证按字节序即按时间排序
+func timeBytes(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixMilli()))
+	return b
+}
@@ -0,0 +1,126 @@
+package message
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "messages.db"))
+	if err != nil {
+		t.Fatalf("打开消息库失败: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStoreSaveDedupBySendId(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	msg := func(msgId, sendId string, offset int) *Message {
+		return &Message{
+			MsgId:     msgId,
+			Account:   "wxid_self",
+			Chat:      "wxid_friend",
+			Content:   msgId,
+			Direction: DirectionOut,
+			SendId:    sendId,
+			Time:      base.Add(time.Duration(offset) * time.Second),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		saves []*Message
+		want  []string // 按时间倒序的 msgId
+	}{
+		{
+			name:  "发出记录后收到回显",
+			saves: []*Message{msg("m1", "s1", 0), msg("m1", "s1", 1)},
+			want:  []string{"m1"},
+		},
+		{
+			name:  "同一 msgId 重复保存后再收到不同 msgId 的回显",
+			saves: []*Message{msg("m1", "s1", 0), msg("m1", "s1", 1), msg("m2", "s1", 2)},
+			want:  []string{"m1"},
+		},
+		{
+			name:  "以 sendId 作为 msgId 的发出记录与回显",
+			saves: []*Message{msg("s1", "s1", 0), msg("m1", "s1", 1)},
+			want:  []string{"s1"},
+		},
+		{
+			name:  "不同 sendId 各自保存",
+			saves: []*Message{msg("m1", "s1", 0), msg("m2", "s2", 1)},
+			want:  []string{"m2", "m1"},
+		},
+		{
+			name:  "同一 msgId 改为新的 sendId 后旧 sendId 不再去重",
+			saves: []*Message{msg("m1", "s1", 0), msg("m1", "s2", 1), msg("m2", "s1", 2)},
+			want:  []string{"m2", "m1"},
+		},
+		{
+			name:  "不带 sendId 的消息不去重",
+			saves: []*Message{msg("m1", "", 0), msg("m2", "", 1)},
+			want:  []string{"m2", "m1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t)
+			for _, m := range tt.saves {
+				if err := s.Save(m); err != nil {
+					t.Fatalf("保存 %s 失败: %v", m.MsgId, err)
+				}
+			}
+
+			list, err := s.Query(Query{Account: "wxid_self", Chat: "wxid_friend"})
+			if err != nil {
+				t.Fatalf("查询失败: %v", err)
+			}
+			got := make([]string, 0, len(list))
+			for _, m := range list {
+				got = append(got, m.MsgId)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("消息 = %v, 期望 %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("消息 = %v, 期望 %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreResaveMovesIndexes(t *testing.T) {
+	s := openTestStore(t)
+	first := &Message{MsgId: "m1", Account: "wxid_self", Chat: "wxid_a", Time: time.Unix(100, 0)}
+	if err := s.Save(first); err != nil {
+		t.Fatal(err)
+	}
+	moved := &Message{MsgId: "m1", Account: "wxid_self", Chat: "wxid_b", Time: time.Unix(200, 0)}
+	if err := s.Save(moved); err != nil {
+		t.Fatal(err)
+	}
+
+	for chat, want := range map[string]int{"wxid_a": 0, "wxid_b": 1} {
+		list, err := s.Query(Query{Account: "wxid_self", Chat: chat})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != want {
+			t.Errorf("会话 %s 消息数 = %d, 期望 %d", chat, len(list), want)
+		}
+	}
+	all, err := s.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 {
+		t.Errorf("消息总数 = %d, 期望 1", len(all))
+	}
+}
@@ -14,40 +14,55 @@ import (
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/naidog/wechat-framework/internal/config"
+	"github.com/naidog/wechat-framework/internal/core/access"
+	"github.com/naidog/wechat-framework/internal/core/account"
 	"github.com/naidog/wechat-framework/pkg/logger"
 	"github.com/naidog/wechat-framework/pkg/types"
-	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
 const (
 	PluginDir = "plugins"
 )
 
+// WindowHost 插件窗口宿主，由 Wails 应用提供，无界面运行时为 nil
+type WindowHost interface {
+	OpenWindow(title, url string) (Window, error)
+}
+
+// Window 插件窗口
+type Window interface {
+	Close()
+	EmitEvent(name string, data ...any)
+}
+
 // Manager 插件管理器
 type Manager struct {
-	app           *application.App
-	pluginWindows map[string]*application.WebviewWindow // pluginID -> window
-	windowMutex   sync.RWMutex                          // 保护 pluginWindows 的锁
-	logService    *logger.Service                       // 日志服务引用
-	pluginCache   []types.PluginInfo                    // 插件缓存
-	cacheMutex    sync.RWMutex                          // 保护插件缓存的锁
+	host          WindowHost
+	pluginWindows map[string]Window  // pluginID -> window
+	windowMutex   sync.RWMutex       // 保护 pluginWindows 的锁
+	logService    *logger.Service    // 日志服务引用
+	pluginCache   []types.PluginInfo // 插件缓存
+	cacheMutex    sync.RWMutex       // 保护插件缓存的锁
+	access        *access.Manager    // 插件权限授权与令牌签发
 }
 
 // NewManager 创建插件管理器实例
-func NewManager(app *application.App, logService *logger.Service) *Manager {
+func NewManager(host WindowHost, logService *logger.Service, accessManager *access.Manager) *Manager {
 	return &Manager{
-		app:           app,
-		pluginWindows: make(map[string]*application.WebviewWindow),
+		host:          host,
+		pluginWindows: make(map[string]Window),
 		logService:    logService,
 		pluginCache:   make([]types.PluginInfo, 0),
+		access:        accessManager,
 	}
 }
 
-// SetApp 设置应用实例
-func (m *Manager) SetApp(app *application.App) {
-	m.app = app
+// SetHost 设置插件窗口宿主
+func (m *Manager) SetHost(host WindowHost) {
+	m.host = host
 	if m.pluginWindows == nil {
-		m.pluginWindows = make(map[string]*application.WebviewWindow)
+		m.pluginWindows = make(map[string]Window)
 	}
 }
 
@@ -130,12 +145,15 @@ func (m *Manager) scanPluginsFromDisk() ([]types.PluginInfo, error) {
 			iconURL = fmt.Sprintf("http://localhost:9001/plugins/%s/%s", entry.Name(), metadata.Icon)
 		}
 
+		permissions := access.PluginPermissions(metadata.Permissions)
 		pluginInfo := types.PluginInfo{
-			Metadata: metadata,
-			Path:     pluginPath,
-			Enabled:  true,
-			IconURL:  iconURL,
-			EntryURL: fmt.Sprintf("/plugins/%s/%s", entry.Name(), metadata.Entry),
+			Metadata:    metadata,
+			Path:        pluginPath,
+			Enabled:     true,
+			IconURL:     iconURL,
+			EntryURL:    fmt.Sprintf("/plugins/%s/%s", entry.Name(), metadata.Entry),
+			Permissions: permissions,
+			Approved:    m.access.Approved(metadata.ID, permissions),
 		}
 
 		plugins = append(plugins, pluginInfo)
@@ -146,8 +164,8 @@ func (m *Manager) scanPluginsFromDisk() ([]types.PluginInfo, error) {
 
 // OpenPlugin 在新窗口中打开插件
 func (m *Manager) OpenPlugin(ctx context.Context, pluginID string) error {
-	if m.app == nil {
-		return fmt.Errorf("应用实例未初始化")
+	if m.host == nil {
+		return fmt.Errorf("当前运行模式不支持插件窗口")
 	}
 
 	// 获取插件信息
@@ -180,27 +198,20 @@ func (m *Manager) OpenPlugin(ctx context.Context, pluginID string) error {
 	}
 	m.windowMutex.Unlock()
 
+	// 签发插件令牌，插件通过 URL 中的 token 参数调用接口
+	token, err := m.access.IssuePlugin(pluginID, targetPlugin.Permissions)
+	if err != nil {
+		return err
+	}
+
 	// 构建插件 URL
 	pluginURL := fmt.Sprintf("http://localhost:9001%s", targetPlugin.EntryURL)
 
 	// 创建新窗口
-	window := m.app.Window.NewWithOptions(application.WebviewWindowOptions{
-		Title:               targetPlugin.Metadata.Name,
-		Width:               520,
-		Height:              380,
-		URL:                 pluginURL,
-		MaximiseButtonState: application.ButtonDisabled,
-		DevToolsEnabled:     false,
-		Mac: application.MacWindow{
-			Backdrop: application.MacBackdropTranslucent,
-			TitleBar: application.MacTitleBarDefault,
-		},
-		BackgroundColour: application.NewRGB(255, 255, 255),
-		Windows:          application.WindowsWindow{Theme: 0},
-	})
-
-	if window == nil {
-		return fmt.Errorf("创建窗口失败")
+	window, err := m.host.OpenWindow(targetPlugin.Metadata.Name, pluginURL+"?"+access.TokenParam+"="+token.Token)
+	if err != nil {
+		m.access.RevokePlugin(pluginID, false)
+		return err
 	}
 
 	// 保存窗口引用
@@ -212,6 +223,36 @@ func (m *Manager) OpenPlugin(ctx context.Context, pluginID string) error {
 	return nil
 }
 
+// ApprovePlugin 授权插件申请的权限
+func (m *Manager) ApprovePlugin(ctx context.Context, pluginID string) error {
+	plugins, err := m.ScanPlugins()
+	if err != nil {
+		return err
+	}
+
+	for _, plugin := range plugins {
+		if plugin.Metadata.ID != pluginID {
+			continue
+		}
+		if err := m.access.Approve(pluginID, plugin.Permissions); err != nil {
+			return fmt.Errorf("保存插件授权失败: %v", err)
+		}
+
+		// 更新缓存中的授权状态
+		m.cacheMutex.Lock()
+		for i := range m.pluginCache {
+			if m.pluginCache[i].Metadata.ID == pluginID {
+				m.pluginCache[i].Approved = true
+			}
+		}
+		m.cacheMutex.Unlock()
+
+		g.Log().Infof(ctx, "已授权插件 %s 的权限: %v", plugin.Metadata.Name, plugin.Permissions)
+		return nil
+	}
+	return fmt.Errorf("插件不存在: %s", pluginID)
+}
+
 // ClosePlugin 关闭插件窗口并清理引用
 func (m *Manager) ClosePlugin(ctx context.Context, pluginID string) error {
 	m.windowMutex.Lock()
@@ -224,6 +265,8 @@ func (m *Manager) ClosePlugin(ctx context.Context, pluginID string) error {
 	}
 	m.windowMutex.Unlock()
 
+	m.access.RevokePlugin(pluginID, false)
+
 	if !exists {
 		return fmt.Errorf("插件未打开: %s", pluginID)
 	}
@@ -337,24 +380,22 @@ func unzip(src, dest string) error {
 	return nil
 }
 
-// GetConfigYaml 获取 config.yaml 文件内容
+// GetConfigYaml 获取 config.yaml 文件内容，令牌与密钥已隐藏
 func (m *Manager) GetConfigYaml() (string, error) {
 	configPath := "configs/config.yaml"
 	if !gfile.Exists(configPath) {
 		return "", fmt.Errorf("配置文件不存在")
 	}
-	content := gfile.GetContents(configPath)
-	return content, nil
+	return config.Redact(gfile.GetBytes(configPath))
 }
 
-// GetCurrentWechat 获取 currentWechat.json 文件内容
+// GetCurrentWechat 获取当前已登录的微信账号列表（JSON，格式同 currentWechat.json）
 func (m *Manager) GetCurrentWechat() (string, error) {
-	currentWechatPath := "resources/currentWechat.json"
-	if !gfile.Exists(currentWechatPath) {
-		return "", fmt.Errorf("当前微信账号文件不存在")
+	data, err := json.MarshalIndent(types.WechatAccountList{List: account.Default().List()}, "", "  ")
+	if err != nil {
+		return "", err
 	}
-	content := gfile.GetContents(currentWechatPath)
-	return content, nil
+	return string(data), nil
 }
 
 // SendPluginLog 插件发送日志到主程序
@@ -373,6 +414,10 @@ func (m *Manager) BroadcastEventToPlugins(eventType string, eventData interface{
 	defer m.windowMutex.RUnlock()
 
 	for pluginID, window := range m.pluginWindows {
+		// 只向有该事件权限的插件广播
+		if token, ok := m.access.PluginToken(pluginID); ok && !token.AllowEvent(eventType) {
+			continue
+		}
 		if window != nil {
 			// 向插件窗口发送事件
 			window.EmitEvent("wechat:event", map[string]interface{}{
@@ -447,6 +492,11 @@ func (m *Manager) UninstallPlugin(ctx context.Context, pluginID string) error {
 	m.pluginCache = newCache
 	m.cacheMutex.Unlock()
 
+	// 吊销令牌并清除授权，重新安装时需再次授权
+	if err := m.access.RevokePlugin(pluginID, true); err != nil {
+		g.Log().Warningf(ctx, "清除插件授权失败 %s: %v", pluginID, err)
+	}
+
 	g.Log().Infof(ctx, "插件已卸载: %s", pluginID)
 	return nil
 }
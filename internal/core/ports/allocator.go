@@ -0,0 +1,248 @@
+// Package ports 为框架启动的微信实例分配端口。
+//
+// 端口从配置的范围（ports.min ~ ports.max）中选取，分配前检查端口是否已被占用，
+// 已分配给托管进程的端口在进程结束前保留，不会重复分配。账号登录后记录 wxid 与端口的对应关系，
+// 该账号下次启动时优先使用原端口，外部工具可按固定端口访问。
+package ports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/internal/core/account"
+)
+
+const (
+	DefaultMin  = 19000                  // 默认端口范围下限
+	DefaultMax  = 19999                  // 默认端口范围上限
+	DefaultPath = "resources/ports.json" // 默认账号常用端口保存路径
+	maxPort     = 65535
+)
+
+var (
+	defaultOnce      sync.Once
+	defaultAllocator *Allocator
+)
+
+// Config 端口分配配置（ports.*）
+type Config struct {
+	Min  int    `json:"min"`  // 端口范围下限
+	Max  int    `json:"max"`  // 端口范围上限，不超过 65535
+	Path string `json:"path"` // 账号常用端口（wxid -> 端口）保存路径
+}
+
+// LoadConfig 从配置文件读取端口分配配置（ports.*）
+func LoadConfig(ctx context.Context) Config {
+	var cfg Config
+	if v, err := g.Cfg().Get(ctx, "ports"); err == nil && !v.IsNil() {
+		if err := gconv.Struct(v.Map(), &cfg); err != nil {
+			g.Log().Warningf(ctx, "ports 配置解析失败: %v", err)
+		}
+	}
+	return cfg
+}
+
+// Stats 端口分配状态
+type Stats struct {
+	Min       int            `json:"min"`       // 端口范围下限
+	Max       int            `json:"max"`       // 端口范围上限
+	Reserved  []int          `json:"reserved"`  // 已保留的端口
+	Preferred map[string]int `json:"preferred"` // 账号常用端口
+}
+
+// Allocator 端口分配器
+type Allocator struct {
+	cfg       Config
+	mu        sync.Mutex
+	reserved  map[int]bool
+	preferred map[string]int // wxid -> 端口
+}
+
+// Default 获取框架共用的端口分配器，配置读取自 ports.*，并在账号登录时记录常用端口
+func Default() *Allocator {
+	defaultOnce.Do(func() {
+		ctx := gctx.New()
+		defaultAllocator = New(ctx, LoadConfig(ctx))
+		defaultAllocator.Attach(account.Default())
+	})
+	return defaultAllocator
+}
+
+// New 创建端口分配器，从 cfg.Path 恢复账号常用端口，未配置的项使用默认值
+func New(ctx context.Context, cfg Config) *Allocator {
+	if cfg.Min <= 0 || cfg.Min > maxPort {
+		cfg.Min = DefaultMin
+	}
+	if cfg.Max > maxPort {
+		g.Log().Warningf(ctx, "ports.max 超过 %d，已按 %d 处理", maxPort, maxPort)
+		cfg.Max = maxPort
+	}
+	if cfg.Max < cfg.Min {
+		cfg.Max = max(DefaultMax, cfg.Min)
+	}
+	if cfg.Path == "" {
+		cfg.Path = DefaultPath
+	}
+
+	a := &Allocator{
+		cfg:       cfg,
+		reserved:  make(map[int]bool),
+		preferred: make(map[string]int),
+	}
+
+	data, err := os.ReadFile(cfg.Path)
+	if err != nil || len(data) == 0 {
+		return a
+	}
+	if err := json.Unmarshal(data, &a.preferred); err != nil {
+		g.Log().Warningf(ctx, "解析账号常用端口失败，忽略已保存的端口: %v", err)
+		a.preferred = make(map[string]int)
+	}
+	return a
+}
+
+// Attach 订阅账号注册表，账号登录成功时记录其端口
+func (a *Allocator) Attach(registry *account.Registry) {
+	registry.SubscribeState(func(ctx context.Context, t account.Transition) {
+		if t.To != account.StateOnline || t.Wxid == "" {
+			return
+		}
+		if err := a.Remember(t.Wxid, t.Port); err != nil {
+			g.Log().Warningf(ctx, "保存账号常用端口失败: %v", err)
+		}
+	})
+}
+
+// Allocate 分配并保留端口。wxid 不为空且其常用端口可用时使用常用端口；
+// 否则从端口范围内随机位置开始查找，优先跳过其他账号的常用端口
+func (a *Allocator) Allocate(wxid string) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if port, ok := a.preferred[wxid]; ok && wxid != "" {
+		if !a.reserved[port] && Available(port) {
+			a.reserved[port] = true
+			return port, nil
+		}
+		g.Log().Infof(gctx.New(), "账号 %s 的常用端口 %d 已被占用，重新分配", wxid, port)
+	}
+
+	taken := make(map[int]bool, len(a.preferred))
+	for id, port := range a.preferred {
+		if id != wxid {
+			taken[port] = true
+		}
+	}
+
+	size := a.cfg.Max - a.cfg.Min + 1
+	offset := rand.Intn(size)
+	for _, skipPreferred := range []bool{true, false} {
+		for i := 0; i < size; i++ {
+			port := a.cfg.Min + (offset+i)%size
+			if a.reserved[port] || (skipPreferred && taken[port]) || !Available(port) {
+				continue
+			}
+			a.reserved[port] = true
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("端口范围 %d-%d 内没有可用端口", a.cfg.Min, a.cfg.Max)
+}
+
+// Reserve 保留端口，不再分配给其他实例
+func (a *Allocator) Reserve(port int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reserved[port] = true
+}
+
+// Release 释放保留的端口
+func (a *Allocator) Release(port int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.reserved, port)
+}
+
+// Preferred 获取账号的常用端口
+func (a *Allocator) Preferred(wxid string) (int, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	port, ok := a.preferred[wxid]
+	return port, ok
+}
+
+// Remember 记录账号的常用端口，其他账号记录的相同端口被移除
+func (a *Allocator) Remember(wxid string, port int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.preferred[wxid] == port {
+		return nil
+	}
+	for id, p := range a.preferred {
+		if p == port {
+			delete(a.preferred, id)
+		}
+	}
+	a.preferred[wxid] = port
+	return a.persist()
+}
+
+// Stats 获取端口分配状态
+func (a *Allocator) Stats() Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := Stats{
+		Min:       a.cfg.Min,
+		Max:       a.cfg.Max,
+		Reserved:  make([]int, 0, len(a.reserved)),
+		Preferred: make(map[string]int, len(a.preferred)),
+	}
+	for port := range a.reserved {
+		stats.Reserved = append(stats.Reserved, port)
+	}
+	sort.Ints(stats.Reserved)
+	for wxid, port := range a.preferred {
+		stats.Preferred[wxid] = port
+	}
+	return stats
+}
+
+// persist 保存账号常用端口，先写临时文件再替换，调用方需持有锁
+func (a *Allocator) persist() error {
+	data, err := json.MarshalIndent(a.preferred, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.cfg.Path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	tmp := a.cfg.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	return os.Rename(tmp, a.cfg.Path)
+}
+
+// Available 判断本机端口是否未被占用
+func Available(port int) bool {
+	for _, addr := range []string{fmt.Sprintf(":%d", port), fmt.Sprintf("127.0.0.1:%d", port)} {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return false
+		}
+		ln.Close()
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package ports
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func newTestAllocator(t *testing.T, min, max int) *Allocator {
+	t.Helper()
+	return New(context.Background(), Config{Min: min, Max: max, Path: filepath.Join(t.TempDir(), "ports.json")})
+}
+
+func TestAllocateSkipsReservedAndBusyPorts(t *testing.T) {
+	a := newTestAllocator(t, 19601, 19603)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:19602")
+	if err != nil {
+		t.Skipf("端口 19602 已被占用: %v", err)
+	}
+	defer ln.Close()
+
+	got := make(map[int]bool)
+	for i := 0; i < 2; i++ {
+		port, err := a.Allocate("")
+		if err != nil {
+			t.Fatalf("第 %d 次分配失败: %v", i+1, err)
+		}
+		if port == 19602 {
+			t.Fatal("分配了已被占用的端口 19602")
+		}
+		if got[port] {
+			t.Fatalf("端口 %d 重复分配", port)
+		}
+		got[port] = true
+	}
+	if _, err := a.Allocate(""); err == nil {
+		t.Fatal("端口用尽时应返回错误")
+	}
+
+	a.Release(19601)
+	if port, err := a.Allocate(""); err != nil || port != 19601 {
+		t.Fatalf("释放后分配 = %d, %v, 期望 19601", port, err)
+	}
+}
+
+func TestAllocatePrefersRememberedPort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ports.json")
+	cfg := Config{Min: 19611, Max: 19615, Path: path}
+	a := New(context.Background(), cfg)
+	if err := a.Remember("wxid_a", 19613); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Remember("wxid_b", 19614); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新创建时从文件恢复常用端口
+	a = New(context.Background(), cfg)
+	if port, err := a.Allocate("wxid_a"); err != nil || port != 19613 {
+		t.Fatalf("wxid_a 分配 = %d, %v, 期望常用端口 19613", port, err)
+	}
+	for i := 0; i < 3; i++ {
+		port, err := a.Allocate("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if port == 19614 {
+			t.Fatal("仍有空闲端口时分配了其他账号的常用端口")
+		}
+	}
+
+	// 常用端口已被保留时重新分配
+	a.Reserve(19614)
+	if port, err := a.Allocate("wxid_b"); err == nil && port == 19614 {
+		t.Fatal("分配了已保留的端口")
+	}
+}
+
+func TestRememberMovesPortBetweenAccounts(t *testing.T) {
+	a := newTestAllocator(t, 19621, 19625)
+	if err := a.Remember("wxid_a", 19621); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Remember("wxid_b", 19621); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.Preferred("wxid_a"); ok {
+		t.Fatal("端口被其他账号使用后，原账号的常用端口应移除")
+	}
+	if port, ok := a.Preferred("wxid_b"); !ok || port != 19621 {
+		t.Fatalf("wxid_b 常用端口 = %d, 期望 19621", port)
+	}
+}
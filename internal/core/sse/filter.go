@@ -0,0 +1,138 @@
+package sse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/internal/core/access"
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// Meta 用于订阅过滤的事件字段
+type Meta struct {
+	Wxid     string // 所属账号 wxid
+	Chat     string // 会话 wxid（data.fromWxid）
+	FromType int    // 来源类型，0 表示事件不含该字段
+	MsgType  int    // 消息类型，0 表示事件不含该字段
+}
+
+// Filter SSE 订阅过滤条件，同一条件内的多个值为“或”，不同条件之间为“且”
+// 设置了某个条件而事件不含对应字段时，事件不匹配
+type Filter struct {
+	Types     map[string]bool             // 事件类型
+	Wxids     map[string]bool             // 账号 wxid
+	Chats     map[string]bool             // 会话 wxid
+	FromTypes map[int]bool                // 来源类型
+	MsgTypes  map[int]bool                // 消息类型
+	Allow     func(eventType string) bool // 令牌的事件权限，nil 表示不限制
+}
+
+// ParseFilter 从查询参数解析过滤条件（types、wxid、chat、fromType、msgType，多个值用逗号分隔）
+// 请求携带插件令牌时只推送其有权接收的事件，未设置任何条件时返回 nil
+func ParseFilter(r *ghttp.Request) (*Filter, error) {
+	f := &Filter{
+		Types: stringSet(r.Get("types").String()),
+		Wxids: stringSet(r.Get("wxid").String()),
+		Chats: stringSet(r.Get("chat").String()),
+	}
+
+	var err error
+	if f.FromTypes, err = intSet("fromType", r.Get("fromType").String()); err != nil {
+		return nil, err
+	}
+	if f.MsgTypes, err = intSet("msgType", r.Get("msgType").String()); err != nil {
+		return nil, err
+	}
+
+	if t := access.FromRequest(r); t != nil && t.Permissions != nil {
+		f.Allow = t.AllowEvent
+	}
+
+	if f.Types == nil && f.Wxids == nil && f.Chats == nil && f.FromTypes == nil && f.MsgTypes == nil && f.Allow == nil {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// Match 判断事件是否满足过滤条件，nil 过滤条件匹配全部事件
+func (f *Filter) Match(frame *Frame) bool {
+	if f == nil {
+		return true
+	}
+	if f.Types != nil && !f.Types[frame.Type] {
+		return false
+	}
+	if f.Wxids != nil && !f.Wxids[frame.Meta.Wxid] {
+		return false
+	}
+	if f.Chats != nil && !f.Chats[frame.Meta.Chat] {
+		return false
+	}
+	if f.FromTypes != nil && !f.FromTypes[frame.Meta.FromType] {
+		return false
+	}
+	if f.MsgTypes != nil && !f.MsgTypes[frame.Meta.MsgType] {
+		return false
+	}
+	if f.Allow != nil && !f.Allow(frame.Type) {
+		return false
+	}
+	return true
+}
+
+// metaOf 提取事件的过滤字段，仅识别回调事件
+func metaOf(eventData interface{}) Meta {
+	var raw *types.CallbackEvent
+	switch v := eventData.(type) {
+	case types.CallbackEvent:
+		raw = &v
+	case *types.CallbackEvent:
+		raw = v
+	default:
+		return Meta{}
+	}
+	if raw == nil {
+		return Meta{}
+	}
+
+	return Meta{
+		Wxid:     raw.Wxid,
+		Chat:     gconv.String(raw.Data["fromWxid"]),
+		FromType: gconv.Int(raw.Data["fromType"]),
+		MsgType:  gconv.Int(raw.Data["msgType"]),
+	}
+}
+
+// stringSet 解析逗号分隔的字符串集合，为空时返回 nil
+func stringSet(value string) map[string]bool {
+	var set map[string]bool
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		if set == nil {
+			set = make(map[string]bool)
+		}
+		set[item] = true
+	}
+	return set
+}
+
+// intSet 解析逗号分隔的整数集合，为空时返回 nil
+func intSet(name, value string) (map[int]bool, error) {
+	var set map[int]bool
+	for item := range stringSet(value) {
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s 参数格式错误: %s", name, item)
+		}
+		if set == nil {
+			set = make(map[int]bool)
+		}
+		set[n] = true
+	}
+	return set, nil
+}
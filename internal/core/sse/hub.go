@@ -0,0 +1,318 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+const (
+	DefaultBufferSize = 1000             // 默认环形缓冲区容量
+	heartbeatInterval = 30 * time.Second // 心跳间隔
+	writeBatch        = 100              // 单次从缓冲区读取的事件数
+)
+
+// Frame 带序号的 SSE 事件
+type Frame struct {
+	ID   uint64 // 事件序号，单调递增
+	Type string // 事件类型
+	Data []byte // 序列化后的 {type, data}
+	Meta Meta   // 订阅过滤字段
+}
+
+// Gap 客户端错过的事件序号区间（已被环形缓冲区淘汰）
+type Gap struct {
+	From   uint64 `json:"from"`   // 第一个错过的序号
+	To     uint64 `json:"to"`     // 最后一个错过的序号
+	Missed uint64 `json:"missed"` // 错过的事件数
+}
+
+// Stats SSE 统计
+type Stats struct {
+	Clients  int    `json:"clients"`  // 当前连接数
+	LastID   uint64 `json:"lastId"`   // 最新事件序号
+	Buffered int    `json:"buffered"` // 缓冲区中的事件数
+	Capacity int    `json:"capacity"` // 缓冲区容量
+	Gaps     uint64 `json:"gaps"`     // 累计发送的缺口通知数
+}
+
+// Hub SSE 事件中心
+// 事件写入环形缓冲区并分配序号，每个客户端按自己的进度从缓冲区读取，
+// 重连时通过 Last-Event-ID 补发错过的事件，进度落后超过缓冲区容量时发送缺口通知。
+type Hub struct {
+	mu      sync.RWMutex
+	ring    []Frame                   // 环形缓冲区
+	head    int                       // 下一个写入位置
+	count   int                       // 缓冲区中的事件数
+	lastID  uint64                    // 最新事件序号
+	clients map[chan struct{}]*Filter // 客户端唤醒通道 -> 订阅过滤条件
+	gaps    atomic.Uint64
+}
+
+// LoadBufferSize 从配置文件读取缓冲区容量（event.sseBuffer）
+func LoadBufferSize(ctx context.Context) int {
+	if v, err := g.Cfg().Get(ctx, "event.sseBuffer"); err == nil && v.Int() > 0 {
+		return v.Int()
+	}
+	return DefaultBufferSize
+}
+
+// NewHub 创建 SSE 事件中心，size 为环形缓冲区容量
+func NewHub(size int) *Hub {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Hub{
+		ring:    make([]Frame, size),
+		clients: make(map[chan struct{}]*Filter),
+	}
+}
+
+// Publish 发布事件，返回分配的序号
+func (h *Hub) Publish(eventType string, eventData interface{}) (uint64, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"type": eventType,
+		"data": eventData,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("SSE 事件序列化失败: %v", err)
+	}
+
+	frame := Frame{Type: eventType, Data: data, Meta: metaOf(eventData)}
+
+	h.mu.Lock()
+	h.lastID++
+	frame.ID = h.lastID
+	h.ring[h.head] = frame
+	h.head = (h.head + 1) % len(h.ring)
+	if h.count < len(h.ring) {
+		h.count++
+	}
+	h.mu.Unlock()
+
+	// 只唤醒订阅了该事件的客户端，已有未处理的唤醒时跳过
+	h.mu.RLock()
+	for notify, filter := range h.clients {
+		if !filter.Match(&frame) {
+			continue
+		}
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+	h.mu.RUnlock()
+
+	return frame.ID, nil
+}
+
+// Since 获取序号大于 lastID 的事件，最多 limit 条
+// 若部分事件已被缓冲区淘汰，返回对应的缺口
+func (h *Hub) Since(lastID uint64, limit int) ([]Frame, *Gap) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if lastID >= h.lastID || h.count == 0 {
+		return nil, nil
+	}
+
+	oldest := h.lastID - uint64(h.count) + 1
+	var gap *Gap
+	if lastID+1 < oldest {
+		gap = &Gap{From: lastID + 1, To: oldest - 1, Missed: oldest - 1 - lastID}
+		lastID = oldest - 1
+	}
+
+	n := int(h.lastID - lastID)
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	frames := make([]Frame, 0, n)
+	start := h.head - int(h.lastID-lastID)
+	for i := 0; i < n; i++ {
+		idx := ((start+i)%len(h.ring) + len(h.ring)) % len(h.ring)
+		frames = append(frames, h.ring[idx])
+	}
+	return frames, gap
+}
+
+// LastID 获取最新事件序号
+func (h *Hub) LastID() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastID
+}
+
+// Stats 获取统计信息
+func (h *Hub) Stats() Stats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return Stats{
+		Clients:  len(h.clients),
+		LastID:   h.lastID,
+		Buffered: h.count,
+		Capacity: len(h.ring),
+		Gaps:     h.gaps.Load(),
+	}
+}
+
+// Stream 单个客户端的事件订阅，按自己的进度从缓冲区读取事件
+type Stream struct {
+	hub    *Hub
+	notify chan struct{}
+	filter *Filter
+	lastID uint64
+}
+
+// Subscribe 订阅 lastID 之后满足过滤条件的事件，filter 为 nil 时订阅全部事件
+func (h *Hub) Subscribe(lastID uint64, filter *Filter) *Stream {
+	st := &Stream{
+		hub:    h,
+		notify: make(chan struct{}, 1),
+		filter: filter,
+		lastID: lastID,
+	}
+	h.mu.Lock()
+	h.clients[st.notify] = filter
+	h.mu.Unlock()
+
+	// 已有待读取的事件时立即唤醒；通道已登记，Publish 可能已先写入，不能阻塞
+	if lastID < h.LastID() {
+		select {
+		case st.notify <- struct{}{}:
+		default:
+		}
+	}
+	return st
+}
+
+// Notify 有新事件时可读的通道
+func (st *Stream) Notify() <-chan struct{} {
+	return st.notify
+}
+
+// LastID 已读取到的事件序号
+func (st *Stream) LastID() uint64 {
+	return st.lastID
+}
+
+// Next 读取下一批满足过滤条件的事件，最多检查 limit 条；返回 false 表示暂无更多事件
+// 若部分事件已被缓冲区淘汰，第一帧为类型 gap 的缺口通知
+func (st *Stream) Next(limit int) ([]Frame, bool) {
+	frames, gap := st.hub.Since(st.lastID, limit)
+
+	result := make([]Frame, 0, len(frames)+1)
+	if gap != nil {
+		st.hub.gaps.Add(1)
+		notice, _ := json.Marshal(map[string]interface{}{
+			"type": "gap",
+			"data": gap,
+		})
+		result = append(result, Frame{ID: gap.To, Type: "gap", Data: notice})
+		st.lastID = gap.To
+	}
+	for i := range frames {
+		st.lastID = frames[i].ID
+		if st.filter.Match(&frames[i]) {
+			result = append(result, frames[i])
+		}
+	}
+	return result, gap != nil || len(frames) > 0
+}
+
+// Close 取消订阅
+func (st *Stream) Close() {
+	st.hub.mu.Lock()
+	delete(st.hub.clients, st.notify)
+	st.hub.mu.Unlock()
+}
+
+// ResumeID 解析客户端的 Last-Event-ID 请求头（或 lastEventId 查询参数），未携带或无效时返回最新序号
+func (h *Hub) ResumeID(r *ghttp.Request) uint64 {
+	lastID := h.LastID()
+	resume := r.Header.Get("Last-Event-ID")
+	if resume == "" {
+		resume = r.Get("lastEventId").String()
+	}
+	if resume != "" {
+		if id, err := strconv.ParseUint(resume, 10, 64); err == nil && id <= lastID {
+			return id
+		}
+	}
+	return lastID
+}
+
+// Serve 处理 SSE 连接
+// 客户端可通过 Last-Event-ID 请求头（或 lastEventId 查询参数）从指定序号之后继续接收，
+// 通过查询参数设置订阅过滤条件（见 ParseFilter）
+func (h *Hub) Serve(r *ghttp.Request) {
+	filter, err := ParseFilter(r)
+	if err != nil {
+		r.Response.WriteHeader(http.StatusBadRequest)
+		r.Response.WriteJson(g.Map{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	r.Response.Header().Set("Content-Type", "text/event-stream")
+	r.Response.Header().Set("Cache-Control", "no-cache")
+	r.Response.Header().Set("Connection", "keep-alive")
+
+	// 未携带 Last-Event-ID 时只接收新事件
+	ctx := r.Context()
+	st := h.Subscribe(h.ResumeID(r), filter)
+	defer st.Close()
+
+	g.Log().Infof(ctx, "SSE 客户端已连接，当前总数: %d，起始序号: %d", h.Stats().Clients, st.LastID())
+
+	// 发送连接成功消息
+	connected, _ := json.Marshal(map[string]interface{}{
+		"type": "connected",
+		"msg":  "连接成功",
+		"data": map[string]interface{}{
+			"lastEventId": st.LastID(),
+		},
+	})
+	r.Response.Write("data: " + string(connected) + "\n\n")
+	r.Response.Flush()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			g.Log().Infof(ctx, "SSE 客户端已断开，当前总数: %d", h.Stats().Clients-1)
+			return
+		case <-st.Notify():
+			// 写出全部待读取的事件
+			for {
+				frames, more := st.Next(writeBatch)
+				for _, f := range frames {
+					if f.Type == "gap" {
+						g.Log().Warningf(ctx, "SSE 客户端落后，发送缺口通知，续传序号: %d", f.ID)
+					}
+					r.Response.Writef("id: %d\ndata: %s\n\n", f.ID, f.Data)
+				}
+				if !more {
+					break
+				}
+			}
+			r.Response.Flush()
+		case <-ticker.C:
+			// 心跳，保持连接
+			r.Response.Write(": heartbeat\n\n")
+			r.Response.Flush()
+		}
+	}
+}
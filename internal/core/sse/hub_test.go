@@ -0,0 +1,144 @@
+package sse
+
+import (
+	"testing"
+	"time"
+)
+
+func publishN(t *testing.T, h *Hub, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := h.Publish("recvMsg", map[string]interface{}{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func frameIDs(frames []Frame) []uint64 {
+	ids := make([]uint64, 0, len(frames))
+	for _, f := range frames {
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHubSinceAcrossWrappedRing(t *testing.T) {
+	h := NewHub(5)
+	publishN(t, h, 12) // 缓冲区中为 8..12
+
+	tests := []struct {
+		name   string
+		lastID uint64
+		limit  int
+		want   []uint64
+		gap    *Gap
+	}{
+		{name: "最新", lastID: 12, want: nil},
+		{name: "缓冲区内续传", lastID: 9, want: []uint64{10, 11, 12}},
+		{name: "从最旧的前一条续传", lastID: 7, want: []uint64{8, 9, 10, 11, 12}},
+		{name: "落后超过容量", lastID: 3, want: []uint64{8, 9, 10, 11, 12}, gap: &Gap{From: 4, To: 7, Missed: 4}},
+		{name: "从头续传", lastID: 0, want: []uint64{8, 9, 10, 11, 12}, gap: &Gap{From: 1, To: 7, Missed: 7}},
+		{name: "限制条数", lastID: 3, limit: 2, want: []uint64{8, 9}, gap: &Gap{From: 4, To: 7, Missed: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, gap := h.Since(tt.lastID, tt.limit)
+			if got := frameIDs(frames); !equalIDs(got, tt.want) {
+				t.Errorf("事件 = %v, 期望 %v", got, tt.want)
+			}
+			switch {
+			case tt.gap == nil && gap != nil:
+				t.Errorf("缺口 = %+v, 期望无缺口", *gap)
+			case tt.gap != nil && (gap == nil || *gap != *tt.gap):
+				t.Errorf("缺口 = %+v, 期望 %+v", gap, *tt.gap)
+			}
+		})
+	}
+}
+
+func TestStreamResumeAfterWrapSendsGapFirst(t *testing.T) {
+	h := NewHub(4)
+	publishN(t, h, 3)
+	st := h.Subscribe(2, nil)
+	defer st.Close()
+	publishN(t, h, 7) // 共 10 条，缓冲区中为 7..10
+
+	var got []uint64
+	var types []string
+	for {
+		frames, more := st.Next(2)
+		for _, f := range frames {
+			got = append(got, f.ID)
+			types = append(types, f.Type)
+		}
+		if !more {
+			break
+		}
+	}
+
+	if want := []uint64{6, 7, 8, 9, 10}; !equalIDs(got, want) {
+		t.Fatalf("事件 = %v, 期望 %v", got, want)
+	}
+	if types[0] != "gap" {
+		t.Fatalf("第一帧类型 = %s, 期望 gap", types[0])
+	}
+	if st.LastID() != 10 {
+		t.Fatalf("续传序号 = %d, 期望 10", st.LastID())
+	}
+	if h.Stats().Gaps != 1 {
+		t.Fatalf("缺口通知数 = %d, 期望 1", h.Stats().Gaps)
+	}
+}
+
+func TestSubscribeWithPendingEventsDoesNotBlock(t *testing.T) {
+	h := NewHub(8)
+	publishN(t, h, 3)
+
+	done := make(chan *Stream, 1)
+	go func() { done <- h.Subscribe(0, nil) }()
+
+	select {
+	case st := <-done:
+		defer st.Close()
+		select {
+		case <-st.Notify():
+		default:
+			t.Fatal("有待读取的事件时应立即唤醒")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe 阻塞")
+	}
+}
+
+func TestStreamSkipsFilteredFramesButAdvances(t *testing.T) {
+	h := NewHub(8)
+	st := h.Subscribe(0, &Filter{Types: map[string]bool{"loginSuccess": true}})
+	defer st.Close()
+
+	if _, err := h.Publish("recvMsg", map[string]interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Publish("loginSuccess", map[string]interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	frames, _ := st.Next(10)
+	if got := frameIDs(frames); !equalIDs(got, []uint64{2}) {
+		t.Fatalf("事件 = %v, 期望 [2]", got)
+	}
+	if st.LastID() != 2 {
+		t.Fatalf("续传序号 = %d, 期望 2", st.LastID())
+	}
+}
@@ -0,0 +1,513 @@
+// Package supervisor 托管框架启动的微信进程。
+//
+// 每个进程按端口登记，记录 PID、启动与退出历史；进程退出后按重启策略（supervisor.*）
+// 用相同的端口与缓存目录自动重新启动，也可按需停止或重启。启动、退出同步登记到
+// 账号注册表与回调校验器，托管期间端口在端口分配器中保留。
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/internal/core/account"
+	"github.com/naidog/wechat-framework/internal/core/callback"
+	"github.com/naidog/wechat-framework/internal/core/ports"
+)
+
+// 自动重启策略
+const (
+	RestartNever     = "never"     // 不自动重启
+	RestartOnFailure = "onFailure" // 退出码非 0（崩溃）时重启
+	RestartAlways    = "always"    // 非手动停止的退出都重启
+)
+
+// 进程状态
+const (
+	StateStarting   = "starting"   // 正在启动
+	StateRunning    = "running"    // 运行中
+	StateRestarting = "restarting" // 已退出，等待自动重启
+	StateStopped    = "stopped"    // 已手动停止
+	StateExited     = "exited"     // 已退出，不再自动重启
+)
+
+// 历史记录类型
+const (
+	RecordLaunch       = "launch"       // 启动
+	RecordLaunchFailed = "launchFailed" // 启动失败
+	RecordExit         = "exit"         // 退出
+)
+
+const (
+	DefaultBackoff      = 5 * time.Second        // 默认首次自动重启前的等待时间
+	DefaultMaxBackoff   = 5 * time.Minute        // 默认最大等待时间
+	DefaultMaxRestarts  = 5                      // 默认 Window 内最多自动重启次数
+	DefaultWindow       = 30 * time.Minute       // 默认统计重启次数的时间窗口
+	DefaultStartupCheck = 2 * time.Second        // 默认启动检查时间
+	MaxHistory          = 50                     // 每个进程保留的最近启动与退出记录条数
+	stopTimeout         = 10 * time.Second       // 停止进程后等待退出的时间
+	injectTimeout       = 30 * time.Second       // 启动后等待注入成功的最长时间，超时后允许启动下一个实例
+	injectPoll          = 200 * time.Millisecond // 检查注入状态的间隔
+)
+
+var (
+	defaultOnce       sync.Once
+	defaultSupervisor *Supervisor
+)
+
+// Config 进程托管配置（supervisor.*）
+type Config struct {
+	Restart      string        `json:"restart"`      // 自动重启策略: never/onFailure/always
+	Backoff      time.Duration `json:"backoff"`      // 首次自动重启前的等待时间，之后每次翻倍
+	MaxBackoff   time.Duration `json:"maxBackoff"`   // 最大等待时间
+	MaxRestarts  int           `json:"maxRestarts"`  // Window 内最多自动重启次数，超过后不再重启，为 0 时不限制
+	Window       time.Duration `json:"window"`       // 统计重启次数的时间窗口
+	StartupCheck time.Duration `json:"startupCheck"` // 启动后多久内退出视为启动失败
+}
+
+// LoadConfig 从配置文件读取进程托管配置（supervisor.*）
+func LoadConfig(ctx context.Context) Config {
+	cfg := Config{MaxRestarts: DefaultMaxRestarts}
+	if v, err := g.Cfg().Get(ctx, "supervisor"); err == nil && !v.IsNil() {
+		if err := gconv.Struct(v.Map(), &cfg); err != nil {
+			g.Log().Warningf(ctx, "supervisor 配置解析失败: %v", err)
+		}
+	}
+	return cfg
+}
+
+// Spec 微信进程的启动参数，自动重启时保持不变
+type Spec struct {
+	Port      int    `json:"port"`      // 微信端口
+	CachePath string `json:"cachePath"` // 缓存目录
+}
+
+// Starter 按启动参数准备配置并启动微信进程，返回已 Start 的命令
+type Starter func(ctx context.Context, spec Spec) (*exec.Cmd, error)
+
+// Record 一次启动或退出记录
+type Record struct {
+	Type     string    `json:"type"`               // launch、launchFailed 或 exit
+	Pid      int       `json:"pid,omitempty"`      // 进程ID
+	ExitCode *int      `json:"exitCode,omitempty"` // 退出码
+	Reason   string    `json:"reason"`             // 原因
+	At       time.Time `json:"at"`                 // 时间
+}
+
+// Process 托管的微信进程
+type Process struct {
+	Port      int        `json:"port"`                // 微信端口
+	Pid       int        `json:"pid,omitempty"`       // 当前进程ID，未运行时为空
+	CachePath string     `json:"cachePath"`           // 缓存目录
+	State     string     `json:"state"`               // running、restarting、stopped 或 exited
+	Reason    string     `json:"reason"`              // 进入当前状态的原因
+	StartedAt *time.Time `json:"startedAt,omitempty"` // 当前进程的启动时间
+	NextStart *time.Time `json:"nextStart,omitempty"` // 等待自动重启时的重启时间
+	Restarts  int        `json:"restarts"`            // 累计自动重启次数
+	History   []Record   `json:"history"`             // 最近的启动与退出记录，最多 MaxHistory 条
+}
+
+// process 单个端口的托管状态
+type process struct {
+	spec      Spec
+	start     Starter
+	cmd       *exec.Cmd
+	done      chan struct{} // 当前进程退出后关闭
+	state     string
+	reason    string
+	startedAt time.Time
+	nextStart time.Time
+	timer     *time.Timer
+	restarts  int
+	recent    []time.Time // Window 内的自动重启时间
+	auto      bool        // 当前进程由自动重启启动
+	stopping  bool        // 已手动停止，退出后不重启
+	history   []Record
+}
+
+// active 是否正在启动、运行或等待自动重启，调用方需持有锁
+func (p *process) active() bool {
+	return p.state == StateStarting || p.state == StateRunning || p.state == StateRestarting
+}
+
+// record 添加历史记录，调用方需持有锁
+func (p *process) record(r Record) {
+	p.history = append(p.history, r)
+	if len(p.history) > MaxHistory {
+		p.history = p.history[len(p.history)-MaxHistory:]
+	}
+}
+
+// Supervisor 微信进程托管
+type Supervisor struct {
+	cfg      Config
+	accounts *account.Registry
+	guard    *callback.Guard
+	ports    *ports.Allocator
+	mu       sync.Mutex
+	startMu  sync.Mutex // 多个实例共用安装目录下的 config.json，从写入配置到 DLL 注入成功（已读取配置）串行执行
+	procs    map[int]*process
+}
+
+// Default 获取框架共用的进程托管，配置读取自 supervisor.*
+func Default() *Supervisor {
+	defaultOnce.Do(func() {
+		defaultSupervisor = New(LoadConfig(gctx.New()), account.Default(), callback.DefaultGuard(), ports.Default())
+	})
+	return defaultSupervisor
+}
+
+// New 创建进程托管，未配置的项使用默认值
+func New(cfg Config, accounts *account.Registry, guard *callback.Guard, allocator *ports.Allocator) *Supervisor {
+	switch cfg.Restart {
+	case RestartNever, RestartAlways:
+	default:
+		cfg.Restart = RestartOnFailure
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = DefaultBackoff
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = max(DefaultMaxBackoff, cfg.Backoff)
+	}
+	if cfg.MaxRestarts < 0 {
+		cfg.MaxRestarts = 0
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.StartupCheck <= 0 {
+		cfg.StartupCheck = DefaultStartupCheck
+	}
+
+	return &Supervisor{
+		cfg:      cfg,
+		accounts: accounts,
+		guard:    guard,
+		ports:    allocator,
+		procs:    make(map[int]*process),
+	}
+}
+
+// Config 获取生效的进程托管配置
+func (s *Supervisor) Config() Config {
+	return s.cfg
+}
+
+// Launch 启动并托管微信进程，返回进程ID。
+// 启动后 StartupCheck 内退出视为启动失败，返回错误且不自动重启
+func (s *Supervisor) Launch(ctx context.Context, spec Spec, start Starter) (int, error) {
+	s.mu.Lock()
+	p, ok := s.procs[spec.Port]
+	if ok && p.active() {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("端口 %d 上已有托管的微信进程", spec.Port)
+	}
+	if !ok {
+		p = &process{}
+		s.procs[spec.Port] = p
+	}
+	p.state = StateStarting
+	p.spec = spec
+	p.start = start
+	p.auto = false
+	p.stopping = false
+	p.recent = nil
+	s.mu.Unlock()
+
+	s.ports.Reserve(spec.Port)
+	return s.launch(ctx, p, "启动微信")
+}
+
+// launch 启动进程并等待启动检查。
+// 启动锁保持到实例注入成功、进程退出或等待注入超时，避免下一个实例在 DLL 读取前覆盖 config.json
+func (s *Supervisor) launch(ctx context.Context, p *process, reason string) (int, error) {
+	s.startMu.Lock()
+	cmd, err := s.start(ctx, p)
+
+	port := p.spec.Port
+	s.mu.Lock()
+	if err != nil {
+		s.startMu.Unlock()
+		p.record(Record{Type: RecordLaunchFailed, Reason: err.Error(), At: time.Now()})
+		if p.auto && !p.stopping {
+			s.schedule(p, fmt.Sprintf("重启失败: %v", err))
+		} else {
+			s.finish(p, StateExited, fmt.Sprintf("启动失败: %v", err))
+		}
+		s.mu.Unlock()
+		return 0, err
+	}
+
+	pid := cmd.Process.Pid
+	done := make(chan struct{})
+	p.cmd = cmd
+	p.done = done
+	p.state = StateRunning
+	p.reason = reason
+	p.startedAt = time.Now()
+	p.nextStart = time.Time{}
+	p.record(Record{Type: RecordLaunch, Pid: pid, Reason: reason, At: p.startedAt})
+	stopping := p.stopping
+	s.mu.Unlock()
+
+	g.Log().Infof(ctx, "微信进程已启动 (端口:%d, PID:%d): %s", port, pid, reason)
+
+	// 登记实例，用于按 port/pid 校验回调来源
+	s.guard.Register(port, pid)
+	if err := s.accounts.Launch(ctx, port, pid); err != nil {
+		g.Log().Warningf(ctx, "登记微信实例失败: %v", err)
+	}
+	go s.wait(p, cmd, done)
+	go func() {
+		s.awaitInject(port, done)
+		s.startMu.Unlock()
+	}()
+
+	if stopping {
+		// 等待重启期间已手动停止
+		_ = cmd.Process.Kill()
+	}
+
+	select {
+	case <-done:
+		return 0, fmt.Errorf("微信进程 PID %d 已退出，可能是配置错误、权限问题或DLL文件损坏", pid)
+	case <-time.After(s.cfg.StartupCheck):
+		return pid, nil
+	}
+}
+
+// start 调用启动函数，启动函数 panic 时转为错误，保证启动锁能被释放
+func (s *Supervisor) start(ctx context.Context, p *process) (cmd *exec.Cmd, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			cmd, err = nil, fmt.Errorf("启动微信时发生异常: %v", r)
+		}
+	}()
+	return p.start(ctx, p.spec)
+}
+
+// awaitInject 等待实例注入成功（账号状态离开 launched）、进程退出或超时
+func (s *Supervisor) awaitInject(port int, done chan struct{}) {
+	timeout := time.NewTimer(max(s.cfg.StartupCheck, injectTimeout))
+	defer timeout.Stop()
+	ticker := time.NewTicker(injectPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-timeout.C:
+			return
+		case <-ticker.C:
+			if state := s.accounts.State(port); state != "" && state != account.StateLaunched {
+				return
+			}
+		}
+	}
+}
+
+// wait 等待进程退出，登记退出并按重启策略决定是否重启
+func (s *Supervisor) wait(p *process, cmd *exec.Cmd, done chan struct{}) {
+	_ = cmd.Wait()
+	ctx := gctx.New()
+
+	code := -1
+	if cmd.ProcessState != nil {
+		code = cmd.ProcessState.ExitCode()
+	}
+	pid := cmd.Process.Pid
+	port := p.spec.Port
+
+	s.mu.Lock()
+	reason := fmt.Sprintf("微信进程已退出 (退出码 %d)", code)
+	if p.stopping {
+		reason = "微信进程已手动停止"
+	}
+	p.cmd = nil
+	p.record(Record{Type: RecordExit, Pid: pid, ExitCode: &code, Reason: reason, At: time.Now()})
+	s.mu.Unlock()
+
+	g.Log().Infof(ctx, "%s (端口:%d, PID:%d)", reason, port, pid)
+	s.guard.Unregister(port)
+	if err := s.accounts.Exit(ctx, port, reason); err != nil {
+		g.Log().Warningf(ctx, "登记微信实例退出失败: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(done)
+
+	switch {
+	case p.stopping:
+		s.finish(p, StateStopped, reason)
+	case !p.auto && time.Since(p.startedAt) < s.cfg.StartupCheck:
+		s.finish(p, StateExited, "微信进程启动后立即退出")
+	case s.cfg.Restart == RestartNever, s.cfg.Restart == RestartOnFailure && code == 0:
+		s.finish(p, StateExited, reason)
+	default:
+		s.schedule(p, reason)
+	}
+}
+
+// schedule 按退避安排自动重启，Window 内重启次数达到 MaxRestarts 时不再重启，调用方需持有锁
+func (s *Supervisor) schedule(p *process, reason string) {
+	now := time.Now()
+	p.recent = pruneBefore(p.recent, now.Add(-s.cfg.Window))
+	if s.cfg.MaxRestarts > 0 && len(p.recent) >= s.cfg.MaxRestarts {
+		s.finish(p, StateExited, fmt.Sprintf("%s，%s 内已自动重启 %d 次，不再重启", reason, s.cfg.Window, len(p.recent)))
+		g.Log().Warningf(gctx.New(), "微信进程 (端口:%d) %s", p.spec.Port, p.reason)
+		return
+	}
+
+	delay := s.cfg.Backoff
+	for i := 0; i < len(p.recent) && delay < s.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.cfg.MaxBackoff {
+		delay = s.cfg.MaxBackoff
+	}
+
+	p.state = StateRestarting
+	p.reason = fmt.Sprintf("%s，%s 后自动重启", reason, delay)
+	p.nextStart = now.Add(delay)
+	p.timer = time.AfterFunc(delay, func() { s.relaunch(p) })
+}
+
+// finish 进程不再运行且不会自动重启，释放保留的端口，调用方需持有锁
+func (s *Supervisor) finish(p *process, state, reason string) {
+	p.state = state
+	p.reason = reason
+	p.nextStart = time.Time{}
+	s.ports.Release(p.spec.Port)
+}
+
+// relaunch 自动重启，使用相同的启动参数
+func (s *Supervisor) relaunch(p *process) {
+	s.mu.Lock()
+	if p.state != StateRestarting || p.stopping {
+		s.mu.Unlock()
+		return
+	}
+	p.timer = nil
+	p.state = StateStarting
+	p.restarts++
+	p.recent = append(p.recent, time.Now())
+	p.auto = true
+	reason := fmt.Sprintf("自动重启（第 %d 次）", p.restarts)
+	s.mu.Unlock()
+
+	ctx := gctx.New()
+	if _, err := s.launch(ctx, p, reason); err != nil {
+		g.Log().Warningf(ctx, "自动重启微信失败 (端口:%d): %v", p.spec.Port, err)
+	}
+}
+
+// Stop 停止端口上托管的微信进程，停止后不再自动重启
+func (s *Supervisor) Stop(ctx context.Context, port int) error {
+	s.mu.Lock()
+	p, ok := s.procs[port]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("端口 %d 上没有托管的微信进程", port)
+	}
+	if p.state == StateStarting {
+		s.mu.Unlock()
+		return fmt.Errorf("端口 %d 上的微信进程正在启动，请稍后再试", port)
+	}
+	p.stopping = true
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	cmd, done := p.cmd, p.done
+	if cmd == nil {
+		s.finish(p, StateStopped, "已手动停止")
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("结束微信进程失败: %v", err)
+	}
+	select {
+	case <-done:
+		return nil
+	case <-time.After(stopTimeout):
+		return fmt.Errorf("等待微信进程 PID %d 退出超时", cmd.Process.Pid)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Restart 停止并用相同的启动参数重新启动端口上托管的微信进程，返回新的进程ID
+func (s *Supervisor) Restart(ctx context.Context, port int) (int, error) {
+	if err := s.Stop(ctx, port); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	p := s.procs[port]
+	if p.active() {
+		// 并发的启动或重启已抢先启动了新进程
+		s.mu.Unlock()
+		return 0, fmt.Errorf("端口 %d 上的微信进程正在启动", port)
+	}
+	p.state = StateStarting
+	p.auto = false
+	p.stopping = false
+	p.recent = nil
+	s.mu.Unlock()
+
+	s.ports.Reserve(port)
+	return s.launch(ctx, p, "手动重启")
+}
+
+// Processes 获取托管的微信进程，按端口排序
+func (s *Supervisor) Processes() []Process {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Process, 0, len(s.procs))
+	for port, p := range s.procs {
+		item := Process{
+			Port:      port,
+			CachePath: p.spec.CachePath,
+			State:     p.state,
+			Reason:    p.reason,
+			Restarts:  p.restarts,
+			History:   append([]Record(nil), p.history...),
+		}
+		if p.cmd != nil {
+			item.Pid = p.cmd.Process.Pid
+			startedAt := p.startedAt
+			item.StartedAt = &startedAt
+		}
+		if !p.nextStart.IsZero() {
+			nextStart := p.nextStart
+			item.NextStart = &nextStart
+		}
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Port < list[j].Port })
+	return list
+}
+
+// pruneBefore 移除早于 t 的时间
+func pruneBefore(list []time.Time, t time.Time) []time.Time {
+	i := 0
+	for i < len(list) && list[i].Before(t) {
+		i++
+	}
+	return list[i:]
+}
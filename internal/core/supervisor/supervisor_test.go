@@ -0,0 +1,105 @@
+package supervisor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/naidog/wechat-framework/internal/core/account"
+	"github.com/naidog/wechat-framework/internal/core/callback"
+	"github.com/naidog/wechat-framework/internal/core/ports"
+)
+
+// TestHelperProcess 作为被托管的进程运行，持续到被结束
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("SUPERVISOR_HELPER_PROCESS") != "1" {
+		return
+	}
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func helperStarter(ctx context.Context, spec Spec) (*exec.Cmd, error) {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), "SUPERVISOR_HELPER_PROCESS=1")
+	return cmd, cmd.Start()
+}
+
+func newTestSupervisor(t *testing.T) *Supervisor {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+	guard, err := callback.NewGuard(callback.GuardConfig{Mode: callback.AuthOff}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(
+		Config{Restart: RestartNever, StartupCheck: 100 * time.Millisecond},
+		account.NewRegistry(ctx, filepath.Join(dir, "accounts.json")),
+		guard,
+		ports.New(ctx, ports.Config{Path: filepath.Join(dir, "ports.json")}),
+	)
+	t.Cleanup(func() {
+		for _, p := range s.Processes() {
+			_ = s.Stop(context.Background(), p.Port)
+		}
+	})
+	return s
+}
+
+func TestLaunchRejectsConcurrentLaunchOnSamePort(t *testing.T) {
+	s := newTestSupervisor(t)
+	spec := Spec{Port: 19501}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started, failed := 0, 0
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Launch(context.Background(), spec, helperStarter)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+			} else {
+				started++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if started != 1 || failed != 3 {
+		t.Fatalf("启动成功 %d 次、失败 %d 次，期望 1 次成功、3 次失败", started, failed)
+	}
+}
+
+func TestLaunchReleasesStartLockAfterPanic(t *testing.T) {
+	s := newTestSupervisor(t)
+
+	panics := func(ctx context.Context, spec Spec) (*exec.Cmd, error) {
+		panic("starter panic")
+	}
+	if _, err := s.Launch(context.Background(), Spec{Port: 19502}, panics); err == nil {
+		t.Fatal("启动函数 panic 时应返回错误")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Launch(context.Background(), Spec{Port: 19503}, helperStarter)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("启动失败: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("启动函数 panic 后启动锁未释放")
+	}
+}
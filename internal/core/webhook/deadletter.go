@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DeadLetter 死信文件，每行一条 JSON 格式的投递记录
+type DeadLetter struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewDeadLetter 创建死信文件
+func NewDeadLetter(path string) *DeadLetter {
+	return &DeadLetter{path: path}
+}
+
+// Append 追加一条死信
+func (dl *DeadLetter) Append(item *Delivery) error {
+	line, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(dl.path), 0755); err != nil {
+		return fmt.Errorf("创建死信目录失败: %v", err)
+	}
+	f, err := os.OpenFile(dl.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// List 读取全部死信
+func (dl *DeadLetter) List() ([]Delivery, error) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	return dl.read()
+}
+
+// Take 取出指定ID的死信并从文件中移除，ids 为空时取出全部
+func (dl *DeadLetter) Take(ids []string) ([]Delivery, error) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	list, err := dl.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var taken, kept []Delivery
+	for _, item := range list {
+		if len(ids) == 0 || contains(ids, item.ID) {
+			taken = append(taken, item)
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	if len(taken) == 0 {
+		return nil, nil
+	}
+
+	if err := dl.write(kept); err != nil {
+		return nil, err
+	}
+	return taken, nil
+}
+
+// read 读取死信文件，跳过无法解析的行
+func (dl *DeadLetter) read() ([]Delivery, error) {
+	content, err := os.ReadFile(dl.path)
+	if os.IsNotExist(err) {
+		return []Delivery{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Delivery, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var item Delivery
+		if err := json.Unmarshal(line, &item); err != nil {
+			continue
+		}
+		list = append(list, item)
+	}
+	return list, scanner.Err()
+}
+
+// write 先写临时文件再替换，避免写入中断导致死信丢失
+func (dl *DeadLetter) write(list []Delivery) error {
+	var buf bytes.Buffer
+	for _, item := range list {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := dl.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dl.path)
+}
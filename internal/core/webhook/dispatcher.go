@@ -0,0 +1,436 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/naidog/wechat-framework/internal/core/event"
+)
+
+const (
+	DefaultMaxRetries     = 5                                    // 默认最大重试次数
+	DefaultBackoff        = time.Second                          // 默认首次重试间隔
+	DefaultMaxBackoff     = time.Minute                          // 默认最大重试间隔
+	DefaultTimeout        = 10 * time.Second                     // 默认单次投递超时
+	DefaultDeadLetterPath = "resources/webhook_deadletter.jsonl" // 默认死信文件路径
+	DefaultWorkers        = 4                                    // 默认投递协程数
+	queueSize             = 1024                                 // 投递队列容量
+)
+
+// 签名相关请求头
+const (
+	HeaderEvent     = "X-Ndog-Event"     // 事件类型
+	HeaderDelivery  = "X-Ndog-Delivery"  // 投递ID，重试时保持不变
+	HeaderTimestamp = "X-Ndog-Timestamp" // 10位时间戳
+	HeaderSignature = "X-Ndog-Signature" // sha256=HMAC-SHA256(secret, 时间戳 + "." + 请求体)
+)
+
+// Subscription webhook 订阅
+type Subscription struct {
+	Name     string   `json:"name"`     // 订阅名称，必填且不可重复
+	URL      string   `json:"url"`      // 投递地址
+	Events   []string `json:"events"`   // 事件类型，为空表示全部
+	Accounts []string `json:"accounts"` // 账号 wxid，为空表示全部
+	Secret   string   `json:"secret"`   // 签名密钥，为空时不签名
+}
+
+// Config webhook 配置
+type Config struct {
+	MaxRetries    int            `json:"maxRetries"`    // 最大重试次数
+	Backoff       time.Duration  `json:"backoff"`       // 首次重试间隔，之后每次翻倍
+	MaxBackoff    time.Duration  `json:"maxBackoff"`    // 最大重试间隔
+	Timeout       time.Duration  `json:"timeout"`       // 单次投递超时
+	Workers       int            `json:"workers"`       // 投递协程数
+	DeadLetter    string         `json:"deadLetter"`    // 死信文件路径
+	Subscriptions []Subscription `json:"subscriptions"` // 订阅列表
+}
+
+// Stats 投递统计
+type Stats struct {
+	Delivered    uint64 `json:"delivered"`    // 投递成功数
+	Failed       uint64 `json:"failed"`       // 投递失败次数（含重试）
+	Retried      uint64 `json:"retried"`      // 重试次数
+	DeadLettered uint64 `json:"deadLettered"` // 进入死信的投递数
+	Pending      int    `json:"pending"`      // 队列中待投递数
+}
+
+// Delivery 单次事件投递
+type Delivery struct {
+	ID           string          `json:"id"`           // 投递ID
+	Subscription string          `json:"subscription"` // 订阅名称
+	URL          string          `json:"url"`          // 投递地址
+	Type         string          `json:"type"`         // 事件类型
+	Body         json.RawMessage `json:"body"`         // 请求体
+	Attempts     int             `json:"attempts"`     // 已尝试次数
+	LastError    string          `json:"lastError"`    // 最后一次错误
+	FailedAt     time.Time       `json:"failedAt"`     // 进入死信的时间
+
+	secret string // 签名密钥，创建投递时取自订阅，不写入死信文件
+}
+
+// Dispatcher webhook 投递器
+// 订阅回调事件，按订阅条件签名后 POST 到外部服务，失败时按指数退避重试，
+// 超过最大重试次数或投递器已停止时写入死信文件，可通过 Redrive 重新投递。
+type Dispatcher struct {
+	cfg        Config
+	client     *http.Client
+	deadLetter *DeadLetter
+	queue      chan *Delivery
+	wg         sync.WaitGroup
+	mu         sync.RWMutex // 保护 stopped、retrying 与队列关闭
+	stopped    bool
+	retrying   map[*Delivery]*time.Timer // 等待重试的投递
+	seq        atomic.Uint64
+
+	delivered    atomic.Uint64
+	failed       atomic.Uint64
+	retried      atomic.Uint64
+	deadLettered atomic.Uint64
+}
+
+// LoadConfig 从配置文件读取 webhook 配置（webhook.*）
+func LoadConfig(ctx context.Context) Config {
+	var cfg Config
+	if v, err := g.Cfg().Get(ctx, "webhook"); err == nil && !v.IsNil() {
+		if err := gconv.Struct(v.Map(), &cfg); err != nil {
+			g.Log().Warningf(ctx, "webhook 配置解析失败: %v", err)
+		}
+	}
+	return cfg
+}
+
+// New 创建 webhook 投递器，订阅名称为空或重复时返回错误
+func New(cfg Config) (*Dispatcher, error) {
+	names := make(map[string]bool, len(cfg.Subscriptions))
+	for i, sub := range cfg.Subscriptions {
+		if sub.Name == "" {
+			return nil, fmt.Errorf("webhook 第 %d 个订阅未设置 name", i+1)
+		}
+		if names[sub.Name] {
+			return nil, fmt.Errorf("webhook 订阅名称重复: %s", sub.Name)
+		}
+		names[sub.Name] = true
+	}
+
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = DefaultBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.DeadLetter == "" {
+		cfg.DeadLetter = DefaultDeadLetterPath
+	}
+
+	return &Dispatcher{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		deadLetter: NewDeadLetter(cfg.DeadLetter),
+		queue:      make(chan *Delivery, queueSize),
+		retrying:   make(map[*Delivery]*time.Timer),
+	}, nil
+}
+
+// Attach 订阅全部回调事件
+func (d *Dispatcher) Attach(registry *event.Registry) {
+	registry.OnAny(d.handleEvent)
+}
+
+// Start 启动投递协程
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.cfg.Workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+}
+
+// Stop 停止投递，等待进行中的投递完成；尚未投递和等待重试的事件写入死信
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	close(d.queue)
+	retrying := d.retrying
+	d.retrying = make(map[*Delivery]*time.Timer)
+	d.mu.Unlock()
+
+	d.wg.Wait()
+
+	ctx := context.Background()
+	for item, timer := range retrying {
+		if timer.Stop() {
+			d.bury(ctx, item)
+		}
+	}
+}
+
+// Subscriptions 获取订阅列表（不含密钥）
+func (d *Dispatcher) Subscriptions() []Subscription {
+	list := make([]Subscription, 0, len(d.cfg.Subscriptions))
+	for _, sub := range d.cfg.Subscriptions {
+		if sub.Secret != "" {
+			sub.Secret = "******"
+		}
+		list = append(list, sub)
+	}
+	return list
+}
+
+// Stats 获取投递统计
+func (d *Dispatcher) Stats() Stats {
+	return Stats{
+		Delivered:    d.delivered.Load(),
+		Failed:       d.failed.Load(),
+		Retried:      d.retried.Load(),
+		DeadLettered: d.deadLettered.Load(),
+		Pending:      len(d.queue),
+	}
+}
+
+// DeadLetters 获取死信列表
+func (d *Dispatcher) DeadLetters() ([]Delivery, error) {
+	return d.deadLetter.List()
+}
+
+// Redrive 将死信重新放入投递队列，ids 为空时重投全部，返回重投数量。
+// 死信不保存签名密钥，按订阅名称重新取得；订阅已删除的死信重新写回死信文件
+func (d *Dispatcher) Redrive(ids []string) (int, error) {
+	list, err := d.deadLetter.Take(ids)
+	if err != nil {
+		return 0, err
+	}
+	ctx := context.Background()
+	count := 0
+	for i := range list {
+		item := list[i]
+		sub := d.subscription(item.Subscription)
+		if sub == nil {
+			item.LastError = "订阅不存在"
+			d.bury(ctx, &item)
+			continue
+		}
+		item.Attempts = 0
+		item.LastError = ""
+		item.FailedAt = time.Time{}
+		item.secret = sub.Secret
+		d.enqueue(ctx, &item)
+		count++
+	}
+	return count, nil
+}
+
+// handleEvent 为匹配的订阅创建投递
+func (d *Dispatcher) handleEvent(ctx context.Context, ev *event.Event) {
+	var body []byte
+	for _, sub := range d.cfg.Subscriptions {
+		if !sub.match(ev) {
+			continue
+		}
+		id := fmt.Sprintf("%d-%d", time.Now().UnixMilli(), d.seq.Add(1))
+		if body == nil {
+			var err error
+			body, err = json.Marshal(map[string]interface{}{
+				"type": ev.Type,
+				"data": ev.Raw,
+			})
+			if err != nil {
+				g.Log().Errorf(ctx, "webhook 事件序列化失败: %v", err)
+				return
+			}
+		}
+		d.enqueue(ctx, &Delivery{
+			ID:           id,
+			Subscription: sub.Name,
+			URL:          sub.URL,
+			Type:         ev.Type,
+			Body:         body,
+			secret:       sub.Secret,
+		})
+	}
+}
+
+// match 判断事件是否满足订阅条件
+func (sub *Subscription) match(ev *event.Event) bool {
+	if sub.URL == "" {
+		return false
+	}
+	if len(sub.Events) > 0 && !contains(sub.Events, ev.Type) {
+		return false
+	}
+	if len(sub.Accounts) > 0 && !contains(sub.Accounts, ev.Account.Wxid) {
+		return false
+	}
+	return true
+}
+
+// enqueue 放入投递队列，队列已满或已停止时写入死信
+func (d *Dispatcher) enqueue(ctx context.Context, item *Delivery) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if !d.stopped {
+		select {
+		case d.queue <- item:
+			return
+		default:
+			item.LastError = "投递队列已满"
+		}
+	} else if item.LastError == "" {
+		item.LastError = "投递器已停止"
+	}
+	d.bury(ctx, item)
+}
+
+// worker 处理投递队列
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for item := range d.queue {
+		d.deliver(item)
+	}
+}
+
+// deliver 执行一次投递，失败时安排重试
+func (d *Dispatcher) deliver(item *Delivery) {
+	ctx := context.Background()
+	item.Attempts++
+
+	err := d.post(ctx, item)
+	if err == nil {
+		d.delivered.Add(1)
+		return
+	}
+
+	d.failed.Add(1)
+	item.LastError = err.Error()
+
+	// 首次投递不计入重试次数
+	if item.Attempts > d.cfg.MaxRetries {
+		g.Log().Warningf(ctx, "webhook 投递失败，已写入死信 [%s] %s: %v", item.Subscription, item.ID, err)
+		d.bury(ctx, item)
+		return
+	}
+
+	delay := d.backoff(item.Attempts)
+	g.Log().Debugf(ctx, "webhook 投递失败，%v 后重试 [%s] %s: %v", delay, item.Subscription, item.ID, err)
+	d.retried.Add(1)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		d.bury(ctx, item)
+		return
+	}
+	d.retrying[item] = time.AfterFunc(delay, func() {
+		d.mu.Lock()
+		delete(d.retrying, item)
+		d.mu.Unlock()
+		d.enqueue(ctx, item)
+	})
+}
+
+// backoff 第 attempt 次失败后的重试间隔
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.Backoff
+	for i := 1; i < attempt && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.MaxBackoff {
+		delay = d.cfg.MaxBackoff
+	}
+	return delay
+}
+
+// post 发送签名后的请求，2xx 视为成功
+func (d *Dispatcher) post(ctx context.Context, item *Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", item.URL, bytes.NewReader(item.Body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, item.Type)
+	req.Header.Set(HeaderDelivery, item.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if item.secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(item.secret, timestamp, item.Body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// subscription 按名称查找订阅，不存在时返回 nil
+func (d *Dispatcher) subscription(name string) *Subscription {
+	for i := range d.cfg.Subscriptions {
+		if d.cfg.Subscriptions[i].Name == name {
+			return &d.cfg.Subscriptions[i]
+		}
+	}
+	return nil
+}
+
+// bury 写入死信文件
+func (d *Dispatcher) bury(ctx context.Context, item *Delivery) {
+	item.FailedAt = time.Now()
+	if err := d.deadLetter.Append(item); err != nil {
+		g.Log().Errorf(ctx, "webhook 死信写入失败 [%s] %s: %v", item.Subscription, item.ID, err)
+		return
+	}
+	d.deadLettered.Add(1)
+}
+
+// Sign 计算签名：hex(HMAC-SHA256(secret, 时间戳 + "." + 请求体))
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// contains 判断切片是否包含指定值
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gorilla/websocket"
+	"github.com/naidog/wechat-framework/internal/core/access"
+	"github.com/naidog/wechat-framework/internal/core/sse"
+)
+
+const (
+	pingInterval = 30 * time.Second // 心跳间隔
+	writeTimeout = 10 * time.Second // 单次写入超时
+	readLimit    = 1 << 20          // 单条命令最大字节数
+	eventBatch   = 100              // 单次从缓冲区读取的事件数
+)
+
+// Caller 微信API调用方，action 即代理的接口类型（如 sendText）
+type Caller interface {
+	Call(ctx context.Context, port int, apiType string, data map[string]interface{}) ([]byte, error)
+	Resolve(ctx context.Context, port int, key string) (int, error)
+}
+
+// Command 客户端发来的命令
+type Command struct {
+	ID      string                 `json:"id"`      // 请求ID，原样返回用于关联响应
+	Action  string                 `json:"action"`  // 接口类型，如 sendText
+	Port    int                    `json:"port"`    // 微信端口
+	Account string                 `json:"account"` // 账号 wxid 或别名，未指定端口时使用
+	Data    map[string]interface{} `json:"data"`    // 接口参数
+}
+
+// Response 命令响应
+type Response struct {
+	Type string          `json:"type"`           // 固定为 response
+	ID   string          `json:"id"`             // 对应命令的请求ID
+	OK   bool            `json:"ok"`             // 是否成功调用微信服务
+	Msg  string          `json:"msg,omitempty"`  // 错误信息
+	Data json.RawMessage `json:"data,omitempty"` // 微信服务的原始响应
+}
+
+// Server WebSocket 事件与命令服务
+// 推送与 SSE 相同的事件（支持相同的过滤参数和 lastEventId 续传），并接受微信API调用命令。
+type Server struct {
+	hub      *sse.Hub
+	caller   Caller
+	upgrader websocket.Upgrader
+}
+
+// NewServer 创建 WebSocket 服务，事件来自 hub，命令交给 caller 执行
+func NewServer(hub *sse.Hub, caller Caller) *Server {
+	return &Server{
+		hub:    hub,
+		caller: caller,
+		upgrader: websocket.Upgrader{
+			// 跨域来源已由访问控制中间件校验
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// conn 单个 WebSocket 连接，写操作需串行
+type conn struct {
+	ws    *websocket.Conn
+	token *access.Token // 握手时校验通过的令牌
+	mu    sync.Mutex
+}
+
+// write 写入一条 JSON 消息
+func (c *conn) write(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.ws.WriteJSON(v)
+}
+
+// ping 发送心跳
+func (c *conn) ping() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+}
+
+// Serve 处理 WebSocket 连接
+func (s *Server) Serve(r *ghttp.Request) {
+	filter, err := sse.ParseFilter(r)
+	if err != nil {
+		r.Response.WriteHeader(http.StatusBadRequest)
+		r.Response.WriteJson(g.Map{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	ws, err := s.upgrader.Upgrade(r.Response.Writer, r.Request, nil)
+	if err != nil {
+		g.Log().Warningf(r.Context(), "WebSocket 握手失败: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	defer cancel()
+
+	c := &conn{ws: ws, token: access.FromRequest(r)}
+	st := s.hub.Subscribe(s.hub.ResumeID(r), filter)
+	defer st.Close()
+
+	g.Log().Infof(ctx, "WebSocket 客户端已连接，起始序号: %d", st.LastID())
+
+	if err := c.write(g.Map{
+		"type": "connected",
+		"msg":  "连接成功",
+		"data": g.Map{
+			"lastEventId": st.LastID(),
+		},
+	}); err != nil {
+		return
+	}
+
+	// 读取命令，连接断开时取消上下文
+	go func() {
+		defer cancel()
+		s.readCommands(ctx, c)
+	}()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			g.Log().Infof(ctx, "WebSocket 客户端已断开")
+			return
+		case <-st.Notify():
+			if err := s.writeEvents(c, st); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvents 写出全部待读取的事件，每条事件附带序号 id
+func (s *Server) writeEvents(c *conn, st *sse.Stream) error {
+	for {
+		frames, more := st.Next(eventBatch)
+		for _, f := range frames {
+			var msg map[string]json.RawMessage
+			if err := json.Unmarshal(f.Data, &msg); err != nil {
+				continue
+			}
+			msg["id"], _ = json.Marshal(f.ID)
+			if err := c.write(msg); err != nil {
+				return err
+			}
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// readCommands 循环读取命令，每条命令在独立协程中执行
+func (s *Server) readCommands(ctx context.Context, c *conn) {
+	c.ws.SetReadLimit(readLimit)
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd Command
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			c.write(Response{Type: "response", Msg: "命令格式错误: " + err.Error()})
+			continue
+		}
+		go s.execute(ctx, c, cmd)
+	}
+}
+
+// execute 执行命令并写回响应
+func (s *Server) execute(ctx context.Context, c *conn, cmd Command) {
+	resp := Response{Type: "response", ID: cmd.ID}
+
+	switch {
+	case cmd.Action == "":
+		resp.Msg = "缺少 action 参数"
+	case c.token != nil && !c.token.AllowAPI(cmd.Action):
+		resp.Msg = "缺少权限: " + cmd.Action
+	default:
+		port, err := s.caller.Resolve(ctx, cmd.Port, cmd.Account)
+		if err != nil {
+			resp.Msg = err.Error()
+			break
+		}
+		if cmd.Data == nil {
+			cmd.Data = make(map[string]interface{})
+		}
+		body, err := s.caller.Call(ctx, port, cmd.Action, cmd.Data)
+		if err != nil {
+			resp.Msg = err.Error()
+		} else {
+			resp.OK = true
+			resp.Data = body
+		}
+	}
+
+	if err := c.write(resp); err != nil {
+		g.Log().Warningf(ctx, "WebSocket 响应写入失败 [%s]: %v", cmd.ID, err)
+	}
+}
@@ -6,9 +6,14 @@ import (
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/naidog/wechat-framework/internal/api/message"
 	"github.com/naidog/wechat-framework/internal/api/plugin"
+	"github.com/naidog/wechat-framework/internal/api/webhook"
 	"github.com/naidog/wechat-framework/internal/api/wechat"
+	"github.com/naidog/wechat-framework/internal/core/access"
 	"github.com/naidog/wechat-framework/internal/core/callback"
+	"github.com/naidog/wechat-framework/internal/core/ws"
 )
 
 // HTTPServer HTTP服务器
@@ -17,6 +22,10 @@ type HTTPServer struct {
 	callbackHandler   *callback.Handler
 	wechatProxy       *wechat.Proxy
 	pluginAPI         *plugin.API
+	messageAPI        *message.API
+	wsServer          *ws.Server
+	webhookAPI        *webhook.API
+	access            *access.Manager
 	callbackURLSuffix string
 }
 
@@ -25,11 +34,19 @@ func NewHTTPServer(
 	callbackHandler *callback.Handler,
 	wechatProxy *wechat.Proxy,
 	pluginAPI *plugin.API,
+	messageAPI *message.API,
+	wsServer *ws.Server,
+	webhookAPI *webhook.API,
+	accessManager *access.Manager,
 ) *HTTPServer {
 	return &HTTPServer{
 		callbackHandler: callbackHandler,
 		wechatProxy:     wechatProxy,
 		pluginAPI:       pluginAPI,
+		messageAPI:      messageAPI,
+		wsServer:        wsServer,
+		webhookAPI:      webhookAPI,
+		access:          accessManager,
 	}
 }
 
@@ -54,14 +71,18 @@ func (s *HTTPServer) Start() error {
 	s.server = g.Server()
 	s.server.SetAddr(address.String())
 
-	// 配置CORS
-	s.server.Use(ghttp.MiddlewareCORS)
+	// 访问控制：CORS来源、IP白名单与API令牌，微信回调和插件静态文件免令牌
+	s.access.Exempt("/"+s.callbackURLSuffix, "/plugins/")
+	s.server.Use(s.access.Middleware)
 
 	// 注册路由
 	s.registerRoutes()
 
+	// 启动回调事件管道
+	s.callbackHandler.Start()
+
 	g.Log().Infof(ctx, "HTTP服务器启动在: %s", address.String())
-	g.Log().Infof(ctx, "回调地址: http://localhost%s/%s", address.String(), s.callbackURLSuffix)
+	g.Log().Infof(ctx, "回调地址: %s", callback.RedactURL(s.GetCallbackURL()))
 
 	// 启动服务器（非阻塞）
 	go func() {
@@ -76,8 +97,11 @@ func (s *HTTPServer) Start() error {
 // Stop 停止HTTP服务
 func (s *HTTPServer) Stop() error {
 	if s.server != nil {
-		return s.server.Shutdown()
+		if err := s.server.Shutdown(); err != nil {
+			return err
+		}
 	}
+	s.callbackHandler.Stop()
 	return nil
 }
 
@@ -91,81 +115,49 @@ func (s *HTTPServer) registerRoutes() {
 	{
 		pluginGroup.GET("/config", s.pluginAPI.GetConfig)
 		pluginGroup.GET("/wechat", s.pluginAPI.GetWechat)
+		pluginGroup.GET("/wechat/states", s.pluginAPI.GetWechatStates)
+		pluginGroup.GET("/wechat/health", s.pluginAPI.GetWechatHealth)
 		pluginGroup.POST("/log", s.pluginAPI.SendLog)
 		pluginGroup.POST("/upload", s.pluginAPI.UploadFile)
-		pluginGroup.GET("/events", callback.HandleSSEEvents)
+		pluginGroup.GET("/events", s.callbackHandler.HandleSSEEvents)
+		pluginGroup.GET("/metrics", s.callbackHandler.GetMetrics)
+		pluginGroup.GET("/ws", s.wsServer.Serve)
+	}
+
+	// 消息历史路由
+	s.server.BindHandler("GET:/api/messages", s.messageAPI.Query)
+
+	// webhook 管理路由组
+	webhookGroup := s.server.Group("/api/webhooks")
+	{
+		webhookGroup.GET("/", s.webhookAPI.List)
+		webhookGroup.GET("/deadletter", s.webhookAPI.DeadLetters)
+		webhookGroup.POST("/deadletter/redrive", s.webhookAPI.Redrive)
 	}
 
-	// 插件静态文件服务
+	// 插件静态文件服务，目录不存在时先创建（如首次以无界面模式部署）
+	if !gfile.Exists("plugins") {
+		gfile.Mkdir("plugins")
+	}
 	s.server.AddStaticPath("/plugins", "plugins")
 
-	// 微信API代理路由组
+	// 微信API代理路由组，接口名与别名见 /api/wechat/_routes
 	wechatGroup := s.server.Group("/api/wechat")
 	{
-		// 基础接口
-		wechatGroup.POST("/changeVersion", s.wechatProxy.ChangeVersion)
-		wechatGroup.POST("/getLoginStatus", s.wechatProxy.GetLoginStatus)
-		wechatGroup.POST("/getLoginQrCode", s.wechatProxy.GetLoginQrCode)
-		wechatGroup.POST("/getSelfInfo", s.wechatProxy.GetSelfInfo)
-		wechatGroup.POST("/getAuthInfo", s.wechatProxy.GetAuthInfo)
-		wechatGroup.POST("/logout", s.wechatProxy.Logout)
-		wechatGroup.POST("/getWechatVer", s.wechatProxy.GetWechatVer)
-
-		// 消息发送接口
-		wechatGroup.POST("/sendText", s.wechatProxy.SendText)
-		wechatGroup.POST("/sendImage", s.wechatProxy.SendImage)
-		wechatGroup.POST("/sendFile", s.wechatProxy.SendFile)
-		wechatGroup.POST("/sendVideo", s.wechatProxy.SendVideo)
-		wechatGroup.POST("/sendEmoji", s.wechatProxy.SendEmoji)
-		wechatGroup.POST("/sendCard", s.wechatProxy.SendCard)
-		wechatGroup.POST("/sendLink", s.wechatProxy.SendLink)
-		wechatGroup.POST("/sendMiniProgram", s.wechatProxy.SendMiniProgram)
-		wechatGroup.POST("/sendMusic", s.wechatProxy.SendMusic)
-		wechatGroup.POST("/sendLocation", s.wechatProxy.SendLocation)
-		wechatGroup.POST("/forwardMsg", s.wechatProxy.ForwardMsg)
-		wechatGroup.POST("/sendAtText", s.wechatProxy.SendAtText)
-		wechatGroup.POST("/revokeMsg", s.wechatProxy.RevokeMsg)
-
-		// 信息获取接口
-		wechatGroup.POST("/getFriendList", s.wechatProxy.GetFriendList)
-		wechatGroup.POST("/getGroupList", s.wechatProxy.GetGroupList)
-		wechatGroup.POST("/getGroupMembers", s.wechatProxy.GetGroupMembers)
-		wechatGroup.POST("/getContactProfile", s.wechatProxy.GetContactProfile)
-		wechatGroup.POST("/getDbNames", s.wechatProxy.GetDbNames)
-
-		// 好友管理接口
-		wechatGroup.POST("/addFriend", s.wechatProxy.AddFriend)
-		wechatGroup.POST("/acceptFriend", s.wechatProxy.AcceptFriend)
-		wechatGroup.POST("/deleteFriend", s.wechatProxy.DeleteFriend)
-		wechatGroup.POST("/setRemark", s.wechatProxy.SetRemark)
-		wechatGroup.POST("/topContact", s.wechatProxy.TopContact)
-		wechatGroup.POST("/setBlacklist", s.wechatProxy.SetBlacklist)
-		wechatGroup.POST("/searchFriend", s.wechatProxy.SearchFriend)
-
-		// 群聊管理接口
-		wechatGroup.POST("/createGroup", s.wechatProxy.CreateGroup)
-		wechatGroup.POST("/addGroupMember", s.wechatProxy.AddGroupMember)
-		wechatGroup.POST("/deleteGroupMember", s.wechatProxy.DeleteGroupMember)
-		wechatGroup.POST("/quitGroup", s.wechatProxy.QuitGroup)
-		wechatGroup.POST("/modifyGroupName", s.wechatProxy.ModifyGroupName)
-		wechatGroup.POST("/modifyGroupNotice", s.wechatProxy.ModifyGroupNotice)
-		wechatGroup.POST("/modifyNickInGroup", s.wechatProxy.ModifyNickInGroup)
-		wechatGroup.POST("/inviteIntoGroup", s.wechatProxy.InviteIntoGroup)
-		wechatGroup.POST("/getGroupQrCode", s.wechatProxy.GetGroupQrCode)
-
-		// 其他接口
-		wechatGroup.POST("/receiveTransfer", s.wechatProxy.ReceiveTransfer)
-		wechatGroup.POST("/openBrowser", s.wechatProxy.OpenBrowser)
-		wechatGroup.POST("/downloadImage", s.wechatProxy.DownloadImage)
-		wechatGroup.POST("/downloadFile", s.wechatProxy.DownloadFile)
-		wechatGroup.POST("/executeSql", s.wechatProxy.ExecuteSql)
-		wechatGroup.POST("/callCloudFunc", s.wechatProxy.CallCloudFunc)
+		wechatGroup.GET("/_routes", s.wechatProxy.Routes)
+		wechatGroup.POST("/_batch", s.wechatProxy.Batch)
+		wechatGroup.GET("/_batch/{id}", s.wechatProxy.BatchStatus)
+		wechatGroup.ALL("/{type}", s.wechatProxy.Handle)
 	}
 }
 
-// GetCallbackURL 获取回调URL
+// GetCallbackURL 获取回调URL，启用密钥校验时附带密钥
 func (s *HTTPServer) GetCallbackURL() string {
 	ctx := gctx.New()
 	address, _ := g.Cfg().Get(ctx, "server.address")
-	return fmt.Sprintf("http://localhost%s/%s", address.String(), s.callbackURLSuffix)
+	url := fmt.Sprintf("http://localhost%s/%s", address.String(), s.callbackURLSuffix)
+	if guard := s.callbackHandler.Guard(); guard != nil {
+		url = guard.CallbackURL(url)
+	}
+	return url
 }
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/naidog/wechat-framework/internal/core/plugin"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// PluginWindowHost 基于 Wails 的插件窗口宿主
+type PluginWindowHost struct {
+	app *application.App
+}
+
+// NewPluginWindowHost 创建插件窗口宿主
+func NewPluginWindowHost(app *application.App) *PluginWindowHost {
+	return &PluginWindowHost{app: app}
+}
+
+// OpenWindow 创建插件窗口
+func (h *PluginWindowHost) OpenWindow(title, url string) (plugin.Window, error) {
+	window := h.app.Window.NewWithOptions(application.WebviewWindowOptions{
+		Title:               title,
+		Width:               520,
+		Height:              380,
+		URL:                 url,
+		MaximiseButtonState: application.ButtonDisabled,
+		DevToolsEnabled:     false,
+		Mac: application.MacWindow{
+			Backdrop: application.MacBackdropTranslucent,
+			TitleBar: application.MacTitleBarDefault,
+		},
+		BackgroundColour: application.NewRGB(255, 255, 255),
+		Windows:          application.WindowsWindow{Theme: 0},
+	})
+	if window == nil {
+		return nil, fmt.Errorf("创建窗口失败")
+	}
+	return window, nil
+}
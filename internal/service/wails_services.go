@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/naidog/wechat-framework/internal/config"
+	"github.com/naidog/wechat-framework/internal/core/access"
 	"github.com/naidog/wechat-framework/internal/core/account"
 	"github.com/naidog/wechat-framework/internal/core/plugin"
 	"github.com/naidog/wechat-framework/internal/utils"
@@ -91,6 +92,16 @@ func (s *AccountService) GetAccounts(ctx context.Context) []types.WechatAccount
 	return s.accountManager.GetAccounts(ctx)
 }
 
+// GetInstances 获取各微信实例的生命周期状态
+func (s *AccountService) GetInstances(ctx context.Context) []account.Instance {
+	return s.accountManager.GetInstances(ctx)
+}
+
+// GetHealth 获取各微信实例的心跳检测统计
+func (s *AccountService) GetHealth(ctx context.Context) []account.Health {
+	return s.accountManager.GetHealth(ctx)
+}
+
 // LogService Wails日志服务适配器
 type LogService struct {
 	logService *logger.Service
@@ -131,6 +142,11 @@ func (s *PluginService) OpenPlugin(ctx context.Context, pluginID string) error {
 	return s.pluginManager.OpenPlugin(ctx, pluginID)
 }
 
+// ApprovePlugin 授权插件权限
+func (s *PluginService) ApprovePlugin(ctx context.Context, pluginID string) error {
+	return s.pluginManager.ApprovePlugin(ctx, pluginID)
+}
+
 // ClosePlugin 关闭插件
 func (s *PluginService) ClosePlugin(ctx context.Context, pluginID string) error {
 	return s.pluginManager.ClosePlugin(ctx, pluginID)
@@ -145,3 +161,38 @@ func (s *PluginService) UninstallPlugin(ctx context.Context, pluginID string) er
 func (s *PluginService) WriteFile(filePath string, base64Data string) error {
 	return s.pluginManager.WriteFile(filePath, base64Data)
 }
+
+// AccessService Wails令牌管理服务适配器
+type AccessService struct {
+	accessManager *access.Manager
+}
+
+// NewAccessService 创建令牌管理服务
+func NewAccessService(accessManager *access.Manager) *AccessService {
+	return &AccessService{accessManager: accessManager}
+}
+
+// ListTokens 获取令牌列表，令牌值已隐藏
+func (s *AccessService) ListTokens() []access.Token {
+	return s.accessManager.List()
+}
+
+// IssueToken 签发令牌，令牌值只在签发时返回一次
+func (s *AccessService) IssueToken(name string, scopes []string) (access.Token, error) {
+	return s.accessManager.Issue(name, scopes)
+}
+
+// RevokeToken 吊销令牌
+func (s *AccessService) RevokeToken(name string) error {
+	return s.accessManager.Revoke(name)
+}
+
+// GetAppToken 获取主界面令牌
+func (s *AccessService) GetAppToken() string {
+	return s.accessManager.AppToken()
+}
+
+// GetScopes 获取可分配的权限范围
+func (s *AccessService) GetScopes() []string {
+	return access.Scopes
+}
@@ -6,7 +6,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"syscall"
 
 	"github.com/naidog/wechat-framework/internal/config"
 )
@@ -22,7 +21,7 @@ func NewWechatPathService() *WechatPathService {
 // GetInstallPath 获取微信安装目录
 func (s *WechatPathService) GetInstallPath() (string, error) {
 	cmd := exec.Command("cmd", "/c", "reg", "query", "HKEY_CURRENT_USER\\Software\\Tencent\\Weixin", "/v", "InstallPath")
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	hideWindow(cmd)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -118,7 +117,7 @@ func (s *WechatUpdateService) DisableAutoUpdate() error {
 
 	// 拒绝所有人访问
 	cmd := exec.Command("icacls", "C:\\Users\\Administrator\\AppData\\Roaming\\Tencent\\xwechat\\update\\download", "/deny", "Everyone:F")
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	hideWindow(cmd)
 
 	if _, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("执行禁止自动更新失败: %v", err)
@@ -131,7 +130,7 @@ func (s *WechatUpdateService) DisableAutoUpdate() error {
 func (s *WechatUpdateService) enableAutoUpdate() error {
 	// 移除拒绝规则
 	cmd := exec.Command("icacls", "C:\\Users\\Administrator\\AppData\\Roaming\\Tencent\\xwechat\\update\\download", "/remove:d", "Everyone")
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	hideWindow(cmd)
 
 	if _, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("执行关闭微信自动更新失败: %v", err)
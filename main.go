@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/naidog/wechat-framework/service/access"
 	"github.com/naidog/wechat-framework/service/config"
 	"github.com/naidog/wechat-framework/service/http_callback"
 	"github.com/naidog/wechat-framework/service/plugin"
@@ -62,6 +63,7 @@ func main() {
 			application.NewService(accountService),
 			application.NewService(logService),
 			application.NewService(pluginService),
+			application.NewService(&access.AccessService{}),
 		},
 		Assets: application.AssetOptions{
 			Handler: application.AssetFileServerFS(assets),
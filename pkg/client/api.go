@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+
+	"github.com/naidog/wechat-framework/pkg/types"
+)
+
+// 接口类型
+const (
+	TypeGetAuthInfo    = "getAuthInfo"
+	TypeGetLoginStatus = "getLoginStatus"
+	TypeGetSelfInfo    = "getSelfInfo"
+	TypeSendText       = "sendText"
+	TypeSendImage      = "sendImage"
+	TypeSendFile       = "sendFile"
+	TypeSendGif        = "sendGif"
+	TypeGetFriendList  = "getFriendList"
+	TypeGetGroupList   = "getGroupList"
+	TypeGetPublicList  = "getPublicList"
+	TypeGetMemberList  = "getMemberList"
+	TypeQueryObj       = "queryObj"
+	TypeAgreeFriendReq = "agreeFriendReq"
+	TypeDelFriend      = "delFriend"
+	TypeQuitGroup      = "quitGroup"
+	TypeConfirmTrans   = "confirmTrans"
+	TypeReturnTrans    = "returnTrans"
+)
+
+// GetAuthInfo 获取授权信息
+func (c *Client) GetAuthInfo(ctx context.Context, port int) (*types.AuthInfo, error) {
+	var info types.AuthInfo
+	if err := c.Call(ctx, port, TypeGetAuthInfo, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Ping 检查微信是否在线：直接调用时只要接口能返回 HTTP 200 即认为在线；
+// 通过框架调用时框架会把连接失败包装为错误码，因此还需校验返回码
+func (c *Client) Ping(ctx context.Context, port int) error {
+	if c.baseURL != "" {
+		return c.Call(ctx, port, TypeGetAuthInfo, nil, nil)
+	}
+	_, err := c.Raw(ctx, port, TypeGetAuthInfo, nil)
+	return err
+}
+
+// GetLoginStatus 获取登录状态
+func (c *Client) GetLoginStatus(ctx context.Context, port int) (*LoginStatus, error) {
+	var status LoginStatus
+	if err := c.Call(ctx, port, TypeGetLoginStatus, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// GetSelfInfo 获取当前登录账号信息
+func (c *Client) GetSelfInfo(ctx context.Context, port int) (*SelfInfo, error) {
+	var info SelfInfo
+	if err := c.Call(ctx, port, TypeGetSelfInfo, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// SendText 发送文本消息
+func (c *Client) SendText(ctx context.Context, port int, wxid, msg string) (*SendResult, error) {
+	return c.send(ctx, port, TypeSendText, SendTextRequest{Wxid: wxid, Msg: msg})
+}
+
+// SendImage 发送图片
+func (c *Client) SendImage(ctx context.Context, port int, wxid, path string) (*SendResult, error) {
+	return c.send(ctx, port, TypeSendImage, SendFileRequest{Wxid: wxid, Path: path})
+}
+
+// SendFile 发送文件
+func (c *Client) SendFile(ctx context.Context, port int, wxid, path string) (*SendResult, error) {
+	return c.send(ctx, port, TypeSendFile, SendFileRequest{Wxid: wxid, Path: path})
+}
+
+// SendGif 发送动图
+func (c *Client) SendGif(ctx context.Context, port int, wxid, path string) (*SendResult, error) {
+	return c.send(ctx, port, TypeSendGif, SendFileRequest{Wxid: wxid, Path: path})
+}
+
+// GetFriendList 获取好友列表，refresh 为 true 时重新遍历
+func (c *Client) GetFriendList(ctx context.Context, port int, refresh bool) ([]Contact, error) {
+	return c.list(ctx, port, TypeGetFriendList, refresh)
+}
+
+// GetGroupList 获取群聊列表，refresh 为 true 时重新遍历
+func (c *Client) GetGroupList(ctx context.Context, port int, refresh bool) ([]Contact, error) {
+	return c.list(ctx, port, TypeGetGroupList, refresh)
+}
+
+// GetPublicList 获取公众号列表，refresh 为 true 时重新遍历
+func (c *Client) GetPublicList(ctx context.Context, port int, refresh bool) ([]Contact, error) {
+	return c.list(ctx, port, TypeGetPublicList, refresh)
+}
+
+// GetMemberList 获取群成员列表
+func (c *Client) GetMemberList(ctx context.Context, port int, groupWxid string) ([]Member, error) {
+	var members []Member
+	if err := c.Call(ctx, port, TypeGetMemberList, WxidRequest{Wxid: groupWxid}, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// QueryObj 查询好友、群聊或陌生人信息
+func (c *Client) QueryObj(ctx context.Context, port int, wxid string) (*Contact, error) {
+	var contact Contact
+	if err := c.Call(ctx, port, TypeQueryObj, WxidRequest{Wxid: wxid}, &contact); err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// AgreeFriendReq 同意好友请求，参数取自好友请求事件
+func (c *Client) AgreeFriendReq(ctx context.Context, port int, req AgreeFriendReqRequest) error {
+	return c.Call(ctx, port, TypeAgreeFriendReq, req, nil)
+}
+
+// DelFriend 删除好友
+func (c *Client) DelFriend(ctx context.Context, port int, wxid string) error {
+	return c.Call(ctx, port, TypeDelFriend, WxidRequest{Wxid: wxid}, nil)
+}
+
+// QuitGroup 退出群聊
+func (c *Client) QuitGroup(ctx context.Context, port int, groupWxid string) error {
+	return c.Call(ctx, port, TypeQuitGroup, WxidRequest{Wxid: groupWxid}, nil)
+}
+
+// ConfirmTrans 确认收款
+func (c *Client) ConfirmTrans(ctx context.Context, port int, wxid, transferid string) error {
+	return c.Call(ctx, port, TypeConfirmTrans, TransRequest{Wxid: wxid, Transferid: transferid}, nil)
+}
+
+// ReturnTrans 退还转账
+func (c *Client) ReturnTrans(ctx context.Context, port int, wxid, transferid string) error {
+	return c.Call(ctx, port, TypeReturnTrans, TransRequest{Wxid: wxid, Transferid: transferid}, nil)
+}
+
+// send 调用发送类接口
+func (c *Client) send(ctx context.Context, port int, apiType string, req interface{}) (*SendResult, error) {
+	var result SendResult
+	if err := c.Call(ctx, port, apiType, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// list 调用联系人列表接口
+func (c *Client) list(ctx context.Context, port int, apiType string, refresh bool) ([]Contact, error) {
+	req := ListRequest{Type: ListCached}
+	if refresh {
+		req.Type = ListRefresh
+	}
+	var contacts []Contact
+	if err := c.Call(ctx, port, apiType, req, &contacts); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"    // 正常调用
+	BreakerOpen     = "open"      // 已熔断，调用直接失败
+	BreakerHalfOpen = "half-open" // 熔断到期，放行一次探测调用
+)
+
+// DefaultOpenTimeout 熔断后到放行探测调用的默认间隔
+const DefaultOpenTimeout = 10 * time.Second
+
+// ErrUnavailable 微信实例已熔断，可用 errors.Is 判断
+var ErrUnavailable = errors.New("微信实例暂不可用")
+
+// BreakerConfig 按端口熔断的配置，Failures 为 0 时不熔断
+type BreakerConfig struct {
+	Failures    int           // 连续请求失败多少次后熔断（超时、连接失败，不含接口返回的错误码）
+	OpenTimeout time.Duration // 熔断后多久放行一次探测调用，默认 10 秒
+}
+
+// UnavailableError 微信实例已熔断
+type UnavailableError struct {
+	Port  int           // 微信端口
+	Retry time.Duration // 距下次探测的时间
+}
+
+// Error 实现 error 接口
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("微信实例 (端口:%d) 连续请求失败，暂不可用，%s 后重试", e.Port, e.Retry.Round(time.Second))
+}
+
+// Unwrap 支持 errors.Is(err, ErrUnavailable)
+func (e *UnavailableError) Unwrap() error {
+	return ErrUnavailable
+}
+
+// BreakerStats 单个端口的熔断状态
+type BreakerStats struct {
+	Port     int        `json:"port"`               // 微信端口
+	State    string     `json:"state"`              // closed、open 或 half-open
+	Failures int        `json:"failures"`           // 当前连续失败次数
+	Trips    uint64     `json:"trips"`              // 累计熔断次数
+	OpenedAt *time.Time `json:"openedAt,omitempty"` // 最近一次熔断时间
+}
+
+// breaker 单个端口的熔断器
+type breaker struct {
+	state    string
+	failures int
+	trips    uint64
+	openedAt time.Time
+	probing  bool // 半开状态下是否已有探测调用
+}
+
+// breakers 按端口的熔断器
+type breakers struct {
+	cfg   BreakerConfig
+	mu    sync.Mutex
+	ports map[int]*breaker
+}
+
+// get 获取端口的熔断器，调用方需持有锁
+func (b *breakers) get(port int) *breaker {
+	if b.ports == nil {
+		b.ports = make(map[int]*breaker)
+	}
+	br, ok := b.ports[port]
+	if !ok {
+		br = &breaker{state: BreakerClosed}
+		b.ports[port] = br
+	}
+	return br
+}
+
+// allow 判断能否调用，熔断到期后只放行一次探测调用
+func (b *breakers) allow(port int) error {
+	if b.cfg.Failures <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.get(port)
+	switch br.state {
+	case BreakerOpen:
+		if wait := b.cfg.OpenTimeout - time.Since(br.openedAt); wait > 0 {
+			return &UnavailableError{Port: port, Retry: wait}
+		}
+		br.state = BreakerHalfOpen
+		br.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if br.probing {
+			return &UnavailableError{Port: port, Retry: b.cfg.OpenTimeout}
+		}
+		br.probing = true
+	}
+	return nil
+}
+
+// record 记录调用结果，连续失败达到阈值或探测失败时熔断
+func (b *breakers) record(port int, failed bool) {
+	if b.cfg.Failures <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.get(port)
+	br.probing = false
+	if !failed {
+		br.state = BreakerClosed
+		br.failures = 0
+		return
+	}
+	br.failures++
+	if br.state == BreakerHalfOpen || br.failures >= b.cfg.Failures {
+		if br.state != BreakerOpen {
+			br.trips++
+		}
+		br.state = BreakerOpen
+		br.openedAt = time.Now()
+	}
+}
+
+// release 调用方取消了调用，不计入结果，只结束探测
+func (b *breakers) release(port int) {
+	if b.cfg.Failures <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.get(port).probing = false
+}
+
+// reset 清除端口的熔断状态，端口上启动了新的微信进程时调用
+func (b *breakers) reset(port int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.ports, port)
+}
+
+// state 获取端口的熔断状态，熔断到期但尚未探测时视为半开
+func (b *breakers) state(port int) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br, ok := b.ports[port]
+	if !ok {
+		return BreakerClosed
+	}
+	if br.state == BreakerOpen && time.Since(br.openedAt) >= b.cfg.OpenTimeout {
+		return BreakerHalfOpen
+	}
+	return br.state
+}
+
+// snapshot 按端口排序输出熔断状态
+func (b *breakers) snapshot() []BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	list := make([]BreakerStats, 0, len(b.ports))
+	for port, br := range b.ports {
+		stats := BreakerStats{
+			Port:     port,
+			State:    br.state,
+			Failures: br.failures,
+			Trips:    br.trips,
+		}
+		if !br.openedAt.IsZero() {
+			openedAt := br.openedAt
+			stats.OpenedAt = &openedAt
+		}
+		list = append(list, stats)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Port < list[j].Port })
+	return list
+}
+
+// BreakerState 获取端口的熔断状态，未启用熔断时总是 closed
+func (c *Client) BreakerState(port int) string {
+	return c.breakers.state(port)
+}
+
+// ResetBreaker 清除端口的熔断状态，端口上换了新的微信进程时，旧进程的失败不再计入
+func (c *Client) ResetBreaker(port int) {
+	c.breakers.reset(port)
+}
+
+// Breakers 获取各端口的熔断状态
+func (c *Client) Breakers() []BreakerStats {
+	return c.breakers.snapshot()
+}
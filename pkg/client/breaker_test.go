@@ -0,0 +1,125 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestBreakers(failures int, openTimeout time.Duration) *breakers {
+	return &breakers{cfg: BreakerConfig{Failures: failures, OpenTimeout: openTimeout}}
+}
+
+// fail 放行并记录一次失败的调用
+func fail(t *testing.T, b *breakers, port int) {
+	t.Helper()
+	if err := b.allow(port); err != nil {
+		t.Fatalf("熔断前调用被拒绝: %v", err)
+	}
+	b.record(port, true)
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b := newTestBreakers(3, time.Minute)
+
+	fail(t, b, 19088)
+	fail(t, b, 19088)
+	b.record(19088, false) // 成功调用清零连续失败次数
+	fail(t, b, 19088)
+	fail(t, b, 19088)
+	if got := b.state(19088); got != BreakerClosed {
+		t.Fatalf("连续失败 2 次后状态 = %s, 期望 %s", got, BreakerClosed)
+	}
+
+	fail(t, b, 19088)
+	if got := b.state(19088); got != BreakerOpen {
+		t.Fatalf("连续失败 3 次后状态 = %s, 期望 %s", got, BreakerOpen)
+	}
+
+	err := b.allow(19088)
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("熔断后调用错误 = %v, 期望 *UnavailableError", err)
+	}
+	if unavailable.Port != 19088 || unavailable.Retry <= 0 {
+		t.Fatalf("熔断错误 = %+v", *unavailable)
+	}
+
+	if err := b.allow(19089); err != nil {
+		t.Fatalf("其他端口不应熔断: %v", err)
+	}
+}
+
+func TestBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		failed bool
+		want   string
+	}{
+		{name: "探测成功后恢复", failed: false, want: BreakerClosed},
+		{name: "探测失败后重新熔断", failed: true, want: BreakerOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBreakers(1, 20*time.Millisecond)
+			fail(t, b, 19088)
+			time.Sleep(30 * time.Millisecond)
+
+			if got := b.state(19088); got != BreakerHalfOpen {
+				t.Fatalf("熔断到期后状态 = %s, 期望 %s", got, BreakerHalfOpen)
+			}
+			if err := b.allow(19088); err != nil {
+				t.Fatalf("熔断到期后应放行探测调用: %v", err)
+			}
+			if err := b.allow(19088); !errors.Is(err, ErrUnavailable) {
+				t.Fatalf("探测期间的第二个调用错误 = %v, 期望 ErrUnavailable", err)
+			}
+
+			b.record(19088, tt.failed)
+			if got := b.state(19088); got != tt.want {
+				t.Fatalf("探测后状态 = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerReleaseEndsProbe(t *testing.T) {
+	b := newTestBreakers(1, 20*time.Millisecond)
+	fail(t, b, 19088)
+	time.Sleep(30 * time.Millisecond)
+
+	if err := b.allow(19088); err != nil {
+		t.Fatal(err)
+	}
+	b.release(19088)
+	if err := b.allow(19088); err != nil {
+		t.Fatalf("取消的探测调用结束后应再放行一次: %v", err)
+	}
+}
+
+func TestBreakerReset(t *testing.T) {
+	b := newTestBreakers(1, time.Minute)
+	fail(t, b, 19088)
+	fail(t, b, 19089)
+
+	b.reset(19088)
+	if got := b.state(19088); got != BreakerClosed {
+		t.Fatalf("重置后状态 = %s, 期望 %s", got, BreakerClosed)
+	}
+	if err := b.allow(19088); err != nil {
+		t.Fatalf("重置后调用被拒绝: %v", err)
+	}
+	if got := b.state(19089); got != BreakerOpen {
+		t.Fatalf("其他端口状态 = %s, 期望 %s", got, BreakerOpen)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := newTestBreakers(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		fail(t, b, 19088)
+	}
+	if got := b.state(19088); got != BreakerClosed {
+		t.Fatalf("未启用熔断时状态 = %s, 期望 %s", got, BreakerClosed)
+	}
+}
@@ -0,0 +1,239 @@
+// Package client 微信 HTTP API 的 Go 客户端。
+//
+// 默认直接调用本机微信的 /wechat/httpapi 接口；配置 BaseURL 后改为通过框架的
+// /api/wechat/{接口名} 代理调用，供框架外部的 Go 服务使用，此时可按端口、wxid 或别名指定账号。
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout 默认请求超时
+const DefaultTimeout = 30 * time.Second
+
+// CodeOK 接口成功返回码
+const CodeOK = 200
+
+// Config 客户端配置
+type Config struct {
+	BaseURL string        // 框架地址，如 http://127.0.0.1:9001；为空时直接调用本机微信端口
+	Token   string        // 框架 API 令牌，通过框架调用且开启访问控制时需要
+	Account string        // 通过框架调用时按 wxid 或别名指定账号，此时 port 可传 0
+	Host    string        // 直接调用时的微信主机，默认 127.0.0.1
+	Timeout time.Duration // 未单独配置超时的接口的请求超时，默认 30 秒
+
+	// Timeouts 按接口名配置超时，覆盖 DefaultTimeouts
+	Timeouts map[string]time.Duration
+	// Transport 自定义传输层，默认所有客户端共用同一个连接池
+	Transport http.RoundTripper
+	// Breaker 按端口熔断，默认不启用
+	Breaker BreakerConfig
+}
+
+// Client 微信 HTTP API 客户端，可在多个 goroutine 中共用
+type Client struct {
+	baseURL        string
+	token          string
+	account        string
+	host           string
+	http           *http.Client
+	defaultTimeout time.Duration
+	timeouts       map[string]time.Duration
+	latency        latencyRecorder
+	breakers       breakers
+}
+
+// Response 接口响应
+type Response struct {
+	Code   int             `json:"code"`   // 返回码，200 为成功
+	Msg    string          `json:"msg"`    // 提示信息
+	Result json.RawMessage `json:"result"` // 结果数据
+}
+
+// APIError 接口返回的错误
+type APIError struct {
+	Type   string // 接口类型
+	Status int    // HTTP 状态码
+	Code   int    // 接口返回码
+	Msg    string // 提示信息
+}
+
+// Error 实现 error 接口
+func (e *APIError) Error() string {
+	if e.Status != http.StatusOK {
+		return fmt.Sprintf("微信接口 %s 返回 HTTP %d: %s", e.Type, e.Status, e.Msg)
+	}
+	return fmt.Sprintf("微信接口 %s 返回错误 (code:%d): %s", e.Type, e.Code, e.Msg)
+}
+
+// New 创建客户端
+func New(cfg Config) *Client {
+	if cfg.Host == "" {
+		cfg.Host = "127.0.0.1"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = sharedTransport
+	}
+	if cfg.Breaker.OpenTimeout <= 0 {
+		cfg.Breaker.OpenTimeout = DefaultOpenTimeout
+	}
+	timeouts := make(map[string]time.Duration, len(cfg.Timeouts))
+	for apiType, d := range cfg.Timeouts {
+		timeouts[Canonical(apiType)] = d
+	}
+
+	// 超时按接口通过 context 控制，调用方的 context 取消时请求随之中止
+	return &Client{
+		baseURL:        strings.TrimSuffix(cfg.BaseURL, "/"),
+		token:          cfg.Token,
+		account:        cfg.Account,
+		host:           cfg.Host,
+		http:           &http.Client{Transport: cfg.Transport},
+		defaultTimeout: cfg.Timeout,
+		timeouts:       timeouts,
+		breakers:       breakers{cfg: cfg.Breaker},
+	}
+}
+
+// Raw 调用接口并返回原始响应体，HTTP 状态码不为 200 时返回 *APIError，端口已熔断时返回 *UnavailableError
+func (c *Client) Raw(ctx context.Context, port int, apiType string, data interface{}) ([]byte, error) {
+	target, body, err := c.request(port, apiType, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.breakers.allow(port); err != nil {
+		return nil, err
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, c.timeout(apiType))
+	defer cancel()
+
+	// 接口返回的 HTTP 错误不计入请求失败
+	start := time.Now()
+	respBody, err := c.do(ctx, apiType, target, body)
+	failure := err
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		failure = nil
+	}
+	c.latency.record(port, Canonical(apiType), time.Since(start), failure)
+
+	// 调用方取消的请求不计入熔断
+	if failure != nil && parent.Err() != nil {
+		c.breakers.release(port)
+	} else {
+		c.breakers.record(port, failure != nil)
+	}
+	return respBody, err
+}
+
+// do 发送请求并读取响应体，HTTP 状态码不为 200 时返回 *APIError
+func (c *Client) do(ctx context.Context, apiType, target string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.baseURL != "" {
+		// 客户端按微信接口的原始格式解析响应
+		req.Header.Set(FormatHeader, FormatRaw)
+	}
+	if c.token != "" {
+		req.Header.Set("X-Api-Token", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求微信服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return respBody, &APIError{
+			Type:   apiType,
+			Status: resp.StatusCode,
+			Msg:    strings.TrimSpace(string(respBody)),
+		}
+	}
+	return respBody, nil
+}
+
+// Call 调用接口并将 result 解析到 out，返回码不为 200 时返回 *APIError；out 为 nil 时忽略结果
+func (c *Client) Call(ctx context.Context, port int, apiType string, data interface{}, out interface{}) error {
+	body, err := c.Raw(ctx, port, apiType, data)
+	if err != nil {
+		return err
+	}
+
+	var resp Response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("响应解析失败: %v", err)
+	}
+	if resp.Code != CodeOK {
+		return &APIError{Type: apiType, Status: http.StatusOK, Code: resp.Code, Msg: resp.Msg}
+	}
+	if out == nil || len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		return fmt.Errorf("微信接口 %s 结果解析失败: %v", apiType, err)
+	}
+	return nil
+}
+
+// request 构建请求地址和请求体：直接调用时为 {"type": 接口名, "data": 参数}，
+// 通过框架调用时请求体即为参数
+func (c *Client) request(port int, apiType string, data interface{}) (string, []byte, error) {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	var (
+		target  string
+		payload interface{}
+	)
+	if c.baseURL == "" {
+		target = fmt.Sprintf("http://%s:%d/wechat/httpapi", c.host, port)
+		payload = map[string]interface{}{
+			"type": apiType,
+			"data": data,
+		}
+	} else {
+		query := url.Values{}
+		if port != 0 {
+			query.Set("port", strconv.Itoa(port))
+		}
+		if c.account != "" {
+			query.Set("wxid", c.account)
+		}
+		target = fmt.Sprintf("%s/api/wechat/%s", c.baseURL, url.PathEscape(apiType))
+		if len(query) > 0 {
+			target += "?" + query.Encode()
+		}
+		payload = data
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("请求序列化失败: %v", err)
+	}
+	return target, body, nil
+}
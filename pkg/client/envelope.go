@@ -0,0 +1,47 @@
+package client
+
+import "encoding/json"
+
+// 代理响应格式，通过 FormatHeader 请求头或配置 proxy.responseFormat 指定
+const (
+	FormatHeader    = "X-Response-Format" // 响应格式请求头
+	RequestIDHeader = "X-Request-Id"      // 请求ID请求头，未指定时由框架生成并在响应头中返回
+
+	FormatRaw      = "raw"      // 原样返回微信接口的响应，框架错误为 {"code": 400, "msg": "..."}
+	FormatEnvelope = "envelope" // 统一为 Envelope 格式
+)
+
+// 错误来源
+const (
+	SourceFramework = "framework" // 框架在调用微信前或调用过程中出错
+	SourceWechat    = "wechat"    // 微信接口的响应
+)
+
+// 统一响应的错误码
+const (
+	ErrCodeOK               = "OK"                  // 成功
+	ErrCodeUnknownAPI       = "UNKNOWN_API"         // 接口名不存在
+	ErrCodeBadRequest       = "BAD_REQUEST"         // 请求体无法解析或缺少 port
+	ErrCodeForbidden        = "FORBIDDEN"           // 令牌缺少调用该接口的权限（批量调用）
+	ErrCodeNotFound         = "NOT_FOUND"           // 批量调用任务不存在或已过期
+	ErrCodeValidation       = "VALIDATION_ERROR"    // 接口参数校验失败，data.errors 为各参数的错误
+	ErrCodeNoAccount        = "NO_ACCOUNT"          // 当前没有已登录的账号
+	ErrCodeAmbiguousAccount = "AMBIGUOUS_ACCOUNT"   // 已登录多个账号且未指定
+	ErrCodeAccountOffline   = "ACCOUNT_OFFLINE"     // 指定的账号不在线
+	ErrCodeUnavailable      = "ACCOUNT_UNAVAILABLE" // 微信实例连续请求失败已熔断，稍后重试
+	ErrCodeTimeout          = "TIMEOUT"             // 调用微信接口超时
+	ErrCodeUnreachable      = "WECHAT_UNREACHABLE"  // 无法连接微信端口
+	ErrCodeBadResponse      = "BAD_RESPONSE"        // 微信接口的响应无法解析
+	ErrCodeWechat           = "WECHAT_ERROR"        // 微信接口返回错误，wechatCode 为原始返回码
+)
+
+// Envelope 代理的统一响应
+type Envelope struct {
+	OK         bool            `json:"ok"`                   // 是否成功
+	Code       string          `json:"code"`                 // 错误码，成功时为 OK
+	Message    string          `json:"message"`              // 提示信息
+	Data       json.RawMessage `json:"data"`                 // 成功时为微信接口的 result，失败时为错误详情
+	Source     string          `json:"source"`               // framework 或 wechat
+	WechatCode int             `json:"wechatCode,omitempty"` // 微信接口的原始返回码
+	RequestID  string          `json:"requestId"`            // 请求ID
+}
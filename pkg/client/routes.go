@@ -0,0 +1,119 @@
+package client
+
+// 接口分类
+const (
+	GroupBasic   = "basic"   // 基础接口
+	GroupMessage = "message" // 消息发送
+	GroupContact = "contact" // 信息获取与好友管理
+	GroupGroup   = "group"   // 群聊管理
+	GroupOther   = "other"   // 转账与其他
+)
+
+// Route 微信接口，Type 为发送给微信的规范名称，Aliases 为兼容的其它名称
+type Route struct {
+	Type    string   `json:"type"`              // 规范名称
+	Aliases []string `json:"aliases,omitempty"` // 别名
+	Group   string   `json:"group"`             // 分类
+	Desc    string   `json:"desc"`              // 说明
+}
+
+// Routes 全部微信接口。旧版入口与新版入口的同义接口合并为一条，任一名称均可调用
+var Routes = []Route{
+	// 基础接口
+	{Type: "editVersion", Aliases: []string{"changeVersion"}, Group: GroupBasic, Desc: "修改微信版本号"},
+	{Type: "getLoginStatus", Group: GroupBasic, Desc: "获取登录状态"},
+	{Type: "getLoginQRCode", Aliases: []string{"getLoginQrCode"}, Group: GroupBasic, Desc: "获取登录二维码"},
+	{Type: "getSelfInfo", Group: GroupBasic, Desc: "获取个人信息"},
+	{Type: "getAuthInfo", Group: GroupBasic, Desc: "查询授权信息"},
+	{Type: "checkWeChat", Group: GroupBasic, Desc: "微信状态检测"},
+	{Type: "getWechatVer", Group: GroupBasic, Desc: "获取微信版本"},
+	{Type: "logout", Group: GroupBasic, Desc: "退出登录"},
+	{Type: "setDownloadImage", Group: GroupBasic, Desc: "设置下载图片时间"},
+	{Type: "authCami", Group: GroupBasic, Desc: "使用授权卡密"},
+
+	// 消息发送
+	{Type: "sendText", Group: GroupMessage, Desc: "发送文本消息"},
+	{Type: "sendText2", Group: GroupMessage, Desc: "发送文本消息2"},
+	{Type: "sendAtText", Group: GroupMessage, Desc: "发送@消息"},
+	{Type: "sendReferText", Group: GroupMessage, Desc: "发送引用回复文本"},
+	{Type: "sendImage", Group: GroupMessage, Desc: "发送图片"},
+	{Type: "sendFile", Group: GroupMessage, Desc: "发送文件"},
+	{Type: "sendVideo", Group: GroupMessage, Desc: "发送视频"},
+	{Type: "sendGif", Aliases: []string{"sendEmoji"}, Group: GroupMessage, Desc: "发送动态表情"},
+	{Type: "sendShareUrl", Aliases: []string{"sendLink"}, Group: GroupMessage, Desc: "发送分享链接"},
+	{Type: "sendApplet", Aliases: []string{"sendMiniProgram"}, Group: GroupMessage, Desc: "发送小程序"},
+	{Type: "sendMusic", Group: GroupMessage, Desc: "发送音乐分享"},
+	{Type: "sendChatLog", Group: GroupMessage, Desc: "发送聊天记录"},
+	{Type: "sendCard", Group: GroupMessage, Desc: "发送名片消息"},
+	{Type: "sendXml", Group: GroupMessage, Desc: "发送XML"},
+	{Type: "sendLocationInfo", Aliases: []string{"sendLocation"}, Group: GroupMessage, Desc: "发送位置信息"},
+	{Type: "forwardMsg", Group: GroupMessage, Desc: "转发消息"},
+	{Type: "revokeMyMsg", Aliases: []string{"revokeMsg"}, Group: GroupMessage, Desc: "撤回消息"},
+	{Type: "setReadStatus", Group: GroupMessage, Desc: "标记已读未读"},
+
+	// 信息获取与好友管理
+	{Type: "getLabelList", Group: GroupContact, Desc: "获取标签列表"},
+	{Type: "getFriendList", Group: GroupContact, Desc: "获取好友列表"},
+	{Type: "getGroupList", Group: GroupContact, Desc: "获取群聊列表"},
+	{Type: "getPublicList", Group: GroupContact, Desc: "获取公众号列表"},
+	{Type: "queryObj", Aliases: []string{"getContactProfile"}, Group: GroupContact, Desc: "查询对象信息"},
+	{Type: "queryNewFriend", Aliases: []string{"searchFriend"}, Group: GroupContact, Desc: "查询陌生人信息"},
+	{Type: "agreeFriendReq", Aliases: []string{"acceptFriend"}, Group: GroupContact, Desc: "同意好友请求"},
+	{Type: "addFriendByV3", Aliases: []string{"addFriend"}, Group: GroupContact, Desc: "添加好友_通过v3"},
+	{Type: "addFriendByGroupWxid", Group: GroupContact, Desc: "添加好友_通过群wxid"},
+	{Type: "delFriend", Aliases: []string{"deleteFriend"}, Group: GroupContact, Desc: "删除好友"},
+	{Type: "editObjRemark", Aliases: []string{"setRemark"}, Group: GroupContact, Desc: "修改对象备注"},
+	{Type: "topContact", Group: GroupContact, Desc: "置顶联系人"},
+	{Type: "setBlacklist", Group: GroupContact, Desc: "设置黑名单"},
+	{Type: "getDbNames", Group: GroupContact, Desc: "获取数据库名称"},
+	{Type: "executeSql", Group: GroupContact, Desc: "执行SQL"},
+
+	// 群聊管理
+	{Type: "createGroup", Group: GroupGroup, Desc: "创建群聊"},
+	{Type: "quitGroup", Group: GroupGroup, Desc: "退出群聊"},
+	{Type: "queryGroup", Group: GroupGroup, Desc: "查询群聊信息"},
+	{Type: "getMemberList", Aliases: []string{"getGroupMembers"}, Group: GroupGroup, Desc: "获取群成员列表"},
+	{Type: "getMemberNick", Group: GroupGroup, Desc: "获取群成员昵称"},
+	{Type: "addMembers", Aliases: []string{"addGroupMember"}, Group: GroupGroup, Desc: "添加群成员"},
+	{Type: "inviteMembers", Aliases: []string{"inviteIntoGroup"}, Group: GroupGroup, Desc: "邀请群成员"},
+	{Type: "delMembers", Aliases: []string{"deleteGroupMember"}, Group: GroupGroup, Desc: "移除群成员"},
+	{Type: "editSelfMemberNick", Aliases: []string{"modifyNickInGroup"}, Group: GroupGroup, Desc: "修改自己群昵称"},
+	{Type: "modifyGroupName", Group: GroupGroup, Desc: "修改群名称"},
+	{Type: "modifyGroupNotice", Group: GroupGroup, Desc: "修改群公告"},
+	{Type: "getGroupQrCode", Group: GroupGroup, Desc: "获取群二维码"},
+
+	// 转账与其他
+	{Type: "confirmTrans", Aliases: []string{"receiveTransfer"}, Group: GroupOther, Desc: "确认收款"},
+	{Type: "returnTrans", Group: GroupOther, Desc: "退还收款"},
+	{Type: "openBrowser", Group: GroupOther, Desc: "打开浏览器"},
+	{Type: "runCloudFunction", Aliases: []string{"callCloudFunc"}, Group: GroupOther, Desc: "执行云函数"},
+	{Type: "decryptImage", Group: GroupOther, Desc: "解密dat图片"},
+	{Type: "downloadImage", Group: GroupOther, Desc: "下载图片"},
+	{Type: "downloadFile", Group: GroupOther, Desc: "下载文件"},
+}
+
+// routeIndex 名称（含别名）到接口的索引
+var routeIndex = func() map[string]Route {
+	index := make(map[string]Route, len(Routes)*2)
+	for _, route := range Routes {
+		index[route.Type] = route
+		for _, alias := range route.Aliases {
+			index[alias] = route
+		}
+	}
+	return index
+}()
+
+// Lookup 按规范名称或别名查找接口
+func Lookup(name string) (Route, bool) {
+	route, ok := routeIndex[name]
+	return route, ok
+}
+
+// Canonical 获取接口的规范名称，未知接口原样返回
+func Canonical(name string) string {
+	if route, ok := routeIndex[name]; ok {
+		return route.Type
+	}
+	return name
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 参数类型
+const (
+	KindString   = "string"   // 非空字符串
+	KindChatroom = "chatroom" // 群聊 wxid，以 @chatroom 结尾
+	KindID       = "id"       // 消息ID、转账ID等数字标识，可为数字或数字字符串
+)
+
+// 校验失败原因
+const (
+	ReasonRequired = "required" // 缺少必填参数
+	ReasonType     = "type"     // 参数类型错误
+	ReasonFormat   = "format"   // 参数格式错误
+	ReasonEnum     = "enum"     // 参数不在可选值中
+)
+
+// Param 接口参数
+type Param struct {
+	Name     string   `json:"name"`           // 参数名
+	Kind     string   `json:"kind"`           // 参数类型
+	Required bool     `json:"required"`       // 是否必填
+	Enum     []string `json:"enum,omitempty"` // 可选值
+	Desc     string   `json:"desc"`           // 说明
+}
+
+// FieldError 单个参数的校验错误
+type FieldError struct {
+	Field  string `json:"field"`  // 参数名
+	Reason string `json:"reason"` // 失败原因
+	Msg    string `json:"msg"`    // 提示信息
+}
+
+// ValidationError 请求参数校验失败
+type ValidationError struct {
+	Type   string       // 接口类型
+	Errors []FieldError // 各参数的错误
+}
+
+// Error 实现 error 接口
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Msg)
+	}
+	return fmt.Sprintf("微信接口 %s 参数错误: %s", e.Type, strings.Join(msgs, "; "))
+}
+
+// 常用参数
+var (
+	paramWxid       = Param{Name: "wxid", Kind: KindString, Required: true, Desc: "好友或群聊wxid"}
+	paramGroupWxid  = Param{Name: "wxid", Kind: KindChatroom, Required: true, Desc: "群聊wxid"}
+	paramMsg        = Param{Name: "msg", Kind: KindString, Required: true, Desc: "消息内容"}
+	paramPath       = Param{Name: "path", Kind: KindString, Required: true, Desc: "本地文件路径"}
+	paramMsgId      = Param{Name: "msgId", Kind: KindID, Required: true, Desc: "消息ID"}
+	paramTransferid = Param{Name: "transferid", Kind: KindID, Required: true, Desc: "转账事件中的 transferid"}
+	paramListType   = Param{Name: "type", Kind: KindString, Enum: []string{ListCached, ListRefresh}, Desc: "获取方式：1从缓存获取 2重新遍历"}
+)
+
+// schemas 各接口的参数定义，按规范名称索引；未定义的接口不做校验
+var schemas = map[string][]Param{
+	"editVersion":      {{Name: "version", Kind: KindString, Required: true, Desc: "微信版本号"}},
+	"sendText":         {paramWxid, paramMsg},
+	"sendText2":        {paramWxid, paramMsg},
+	"sendAtText":       {paramGroupWxid, paramMsg},
+	"sendReferText":    {paramWxid, paramMsg, paramMsgId},
+	"sendImage":        {paramWxid, paramPath},
+	"sendFile":         {paramWxid, paramPath},
+	"sendVideo":        {paramWxid, paramPath},
+	"sendGif":          {paramWxid, paramPath},
+	"sendShareUrl":     {paramWxid},
+	"sendApplet":       {paramWxid},
+	"sendMusic":        {paramWxid},
+	"sendChatLog":      {paramWxid},
+	"sendCard":         {paramWxid},
+	"sendXml":          {paramWxid},
+	"sendLocationInfo": {paramWxid},
+	"forwardMsg":       {paramWxid, paramMsgId},
+	"revokeMyMsg":      {paramWxid, paramMsgId},
+	"setReadStatus":    {paramWxid},
+	"getFriendList":    {paramListType},
+	"getGroupList":     {paramListType},
+	"getPublicList":    {paramListType},
+	"queryObj":         {paramWxid},
+	"queryNewFriend":   {paramWxid},
+	"agreeFriendReq": {
+		{Name: "v3", Kind: KindString, Required: true, Desc: "好友请求事件中的 v3"},
+		{Name: "v4", Kind: KindString, Required: true, Desc: "好友请求事件中的 v4"},
+	},
+	"addFriendByV3":      {{Name: "v3", Kind: KindString, Required: true, Desc: "陌生人的 v3"}},
+	"delFriend":          {paramWxid},
+	"editObjRemark":      {paramWxid, {Name: "remark", Kind: KindString, Required: true, Desc: "备注"}},
+	"quitGroup":          {paramGroupWxid},
+	"queryGroup":         {paramGroupWxid},
+	"getMemberList":      {paramGroupWxid},
+	"getMemberNick":      {paramGroupWxid},
+	"addMembers":         {paramGroupWxid},
+	"inviteMembers":      {paramGroupWxid},
+	"delMembers":         {paramGroupWxid},
+	"editSelfMemberNick": {paramGroupWxid, {Name: "nick", Kind: KindString, Required: true, Desc: "群昵称"}},
+	"modifyGroupName":    {paramGroupWxid},
+	"modifyGroupNotice":  {paramGroupWxid},
+	"getGroupQrCode":     {paramGroupWxid},
+	"confirmTrans":       {{Name: "wxid", Kind: KindString, Required: true, Desc: "转账人wxid"}, paramTransferid},
+	"returnTrans":        {{Name: "wxid", Kind: KindString, Required: true, Desc: "转账人wxid"}, paramTransferid},
+	"openBrowser":        {{Name: "url", Kind: KindString, Required: true, Desc: "网址"}},
+}
+
+// digits 数字标识
+var digits = regexp.MustCompile(`^[0-9]+$`)
+
+// Params 获取接口的参数定义，支持别名，未定义时返回 nil
+func Params(apiType string) []Param {
+	return schemas[Canonical(apiType)]
+}
+
+// Validate 按参数定义校验请求参数，不通过时返回 *ValidationError；未定义参数的接口不做校验
+func Validate(apiType string, data map[string]interface{}) error {
+	params := Params(apiType)
+	if len(params) == 0 {
+		return nil
+	}
+
+	var errs []FieldError
+	for _, p := range params {
+		if fe := p.check(data); fe != nil {
+			errs = append(errs, *fe)
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Type: apiType, Errors: errs}
+	}
+	return nil
+}
+
+// check 校验单个参数
+func (p Param) check(data map[string]interface{}) *FieldError {
+	value, ok := data[p.Name]
+	if !ok || value == nil || value == "" {
+		if !p.Required {
+			return nil
+		}
+		msg := fmt.Sprintf("缺少必填参数 %s（%s）", p.Name, p.Desc)
+		if similar := similarKey(data, p.Name); similar != "" {
+			msg += fmt.Sprintf("，是否写成了 %s？", similar)
+		}
+		return &FieldError{Field: p.Name, Reason: ReasonRequired, Msg: msg}
+	}
+
+	switch p.Kind {
+	case KindID:
+		if !isID(value) {
+			return &FieldError{Field: p.Name, Reason: ReasonType, Msg: fmt.Sprintf("参数 %s 应为数字ID", p.Name)}
+		}
+	default:
+		s, ok := value.(string)
+		if !ok {
+			if len(p.Enum) == 0 {
+				return &FieldError{Field: p.Name, Reason: ReasonType, Msg: fmt.Sprintf("参数 %s 应为字符串", p.Name)}
+			}
+			s = fmt.Sprint(value)
+		}
+		if p.Kind == KindChatroom && !strings.HasSuffix(s, "@chatroom") {
+			return &FieldError{Field: p.Name, Reason: ReasonFormat, Msg: fmt.Sprintf("参数 %s 应为群聊wxid（以 @chatroom 结尾）", p.Name)}
+		}
+		if len(p.Enum) > 0 && !contains(p.Enum, s) {
+			return &FieldError{Field: p.Name, Reason: ReasonEnum, Msg: fmt.Sprintf("参数 %s 只能为 %s", p.Name, strings.Join(p.Enum, "、"))}
+		}
+	}
+	return nil
+}
+
+// isID 判断是否为数字ID：数字字符串或非负整数
+func isID(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return digits.MatchString(v)
+	case json.Number:
+		return digits.MatchString(v.String())
+	case float64:
+		return v >= 0 && v == float64(int64(v))
+	case int:
+		return v >= 0
+	case int64:
+		return v >= 0
+	}
+	return false
+}
+
+// similarKey 查找与参数名仅大小写或下划线不同的键，用于提示拼写错误
+func similarKey(data map[string]interface{}, name string) string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.ReplaceAll(s, "_", ""))
+	}
+	for key := range data {
+		if key != name && normalize(key) == normalize(name) {
+			return key
+		}
+	}
+	return ""
+}
+
+// contains 判断字符串是否在列表中
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
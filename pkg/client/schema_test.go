@@ -0,0 +1,59 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiType string
+		data    map[string]interface{}
+		want    map[string]string // 参数名 -> 失败原因，为空表示校验通过
+	}{
+		{name: "参数完整", apiType: "sendText", data: map[string]interface{}{"wxid": "wxid_a", "msg": "你好"}},
+		{name: "缺少必填参数", apiType: "sendText", data: map[string]interface{}{"wxid": "wxid_a"}, want: map[string]string{"msg": ReasonRequired}},
+		{name: "空字符串视为缺少", apiType: "sendText", data: map[string]interface{}{"wxid": "", "msg": "你好"}, want: map[string]string{"wxid": ReasonRequired}},
+		{name: "字符串参数类型错误", apiType: "sendText", data: map[string]interface{}{"wxid": 123, "msg": "你好"}, want: map[string]string{"wxid": ReasonType}},
+		{name: "群聊格式错误", apiType: "quitGroup", data: map[string]interface{}{"wxid": "wxid_a"}, want: map[string]string{"wxid": ReasonFormat}},
+		{name: "群聊格式正确", apiType: "quitGroup", data: map[string]interface{}{"wxid": "10001000@chatroom"}},
+		{name: "数字字符串ID", apiType: "revokeMyMsg", data: map[string]interface{}{"wxid": "wxid_a", "msgId": "123456789012345678"}},
+		{name: "JSON 数字ID", apiType: "revokeMyMsg", data: map[string]interface{}{"wxid": "wxid_a", "msgId": json.Number("123456789012345678")}},
+		{name: "非数字ID", apiType: "revokeMyMsg", data: map[string]interface{}{"wxid": "wxid_a", "msgId": "abc"}, want: map[string]string{"msgId": ReasonType}},
+		{name: "可选值", apiType: "getFriendList", data: map[string]interface{}{"type": 2}},
+		{name: "不在可选值中", apiType: "getFriendList", data: map[string]interface{}{"type": "3"}, want: map[string]string{"type": ReasonEnum}},
+		{name: "可选参数省略", apiType: "getFriendList", data: map[string]interface{}{}},
+		{name: "未定义参数的接口", apiType: "getSelfInfo", data: map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.apiType, tt.data)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("校验失败: %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("错误 = %v, 期望 *ValidationError", err)
+			}
+			got := make(map[string]string, len(verr.Errors))
+			for _, fe := range verr.Errors {
+				got[fe.Field] = fe.Reason
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("校验错误 = %v, 期望 %v", got, tt.want)
+			}
+			for field, reason := range tt.want {
+				if got[field] != reason {
+					t.Fatalf("校验错误 = %v, 期望 %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
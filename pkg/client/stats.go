@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyStats 单个端口上某个接口的调用耗时统计
+type LatencyStats struct {
+	Port     int     `json:"port"`     // 微信端口
+	Type     string  `json:"type"`     // 接口名
+	Count    uint64  `json:"count"`    // 调用次数
+	Errors   uint64  `json:"errors"`   // 请求失败次数（含超时，不含接口返回的错误码）
+	Timeouts uint64  `json:"timeouts"` // 超时次数
+	AvgMs    float64 `json:"avgMs"`    // 平均耗时（毫秒）
+	MaxMs    float64 `json:"maxMs"`    // 最大耗时（毫秒）
+	LastMs   float64 `json:"lastMs"`   // 最近一次耗时（毫秒）
+}
+
+// latencyKey 统计维度
+type latencyKey struct {
+	port    int
+	apiType string
+}
+
+// latency 累计的耗时
+type latency struct {
+	count    uint64
+	errors   uint64
+	timeouts uint64
+	total    time.Duration
+	max      time.Duration
+	last     time.Duration
+}
+
+// latencyRecorder 按端口和接口统计调用耗时
+type latencyRecorder struct {
+	mu    sync.Mutex
+	items map[latencyKey]*latency
+}
+
+// record 记录一次调用
+func (l *latencyRecorder) record(port int, apiType string, elapsed time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.items == nil {
+		l.items = make(map[latencyKey]*latency)
+	}
+	key := latencyKey{port: port, apiType: apiType}
+	item, ok := l.items[key]
+	if !ok {
+		item = &latency{}
+		l.items[key] = item
+	}
+
+	item.count++
+	item.total += elapsed
+	item.last = elapsed
+	if elapsed > item.max {
+		item.max = elapsed
+	}
+	if err != nil {
+		item.errors++
+		if IsTimeout(err) {
+			item.timeouts++
+		}
+	}
+}
+
+// snapshot 按端口、接口名排序输出统计
+func (l *latencyRecorder) snapshot() []LatencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := make([]LatencyStats, 0, len(l.items))
+	for key, item := range l.items {
+		list = append(list, LatencyStats{
+			Port:     key.port,
+			Type:     key.apiType,
+			Count:    item.count,
+			Errors:   item.errors,
+			Timeouts: item.timeouts,
+			AvgMs:    ms(item.total / time.Duration(item.count)),
+			MaxMs:    ms(item.max),
+			LastMs:   ms(item.last),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Port != list[j].Port {
+			return list[i].Port < list[j].Port
+		}
+		return list[i].Type < list[j].Type
+	})
+	return list
+}
+
+// Stats 获取各端口、各接口的调用耗时统计
+func (c *Client) Stats() []LatencyStats {
+	return c.latency.snapshot()
+}
+
+// IsTimeout 判断请求错误是否为超时
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// ms 转换为毫秒
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// 连接池参数
+const (
+	MaxIdleConnsPerHost = 16               // 每个微信端口保留的空闲连接数
+	IdleConnTimeout     = 90 * time.Second // 空闲连接保留时间
+)
+
+// 按接口分类的默认超时
+const (
+	StatusTimeout = 3 * time.Second // 状态查询类接口
+	FileTimeout   = 2 * time.Minute // 收发文件、图片、视频类接口
+)
+
+// DefaultTimeouts 各接口的默认超时，未列出的接口使用 Config.Timeout；
+// Config.Timeouts 中的同名配置覆盖此处的值
+var DefaultTimeouts = map[string]time.Duration{
+	"getLoginStatus": StatusTimeout,
+	"getAuthInfo":    StatusTimeout,
+	"checkWeChat":    StatusTimeout,
+	"getWechatVer":   StatusTimeout,
+	"getSelfInfo":    StatusTimeout,
+	"sendImage":      FileTimeout,
+	"sendFile":       FileTimeout,
+	"sendVideo":      FileTimeout,
+	"sendGif":        FileTimeout,
+	"decryptImage":   FileTimeout,
+	"downloadImage":  FileTimeout,
+	"downloadFile":   FileTimeout,
+}
+
+// sharedTransport 未指定 Transport 的客户端共用的连接池
+var sharedTransport = NewTransport()
+
+// NewTransport 创建保持长连接的 HTTP 传输层，同一端口的请求复用连接
+func NewTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   3 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:        256,
+		MaxIdleConnsPerHost: MaxIdleConnsPerHost,
+		IdleConnTimeout:     IdleConnTimeout,
+		DisableCompression:  true,
+	}
+}
+
+// timeout 获取接口的超时，别名按规范名称查找
+func (c *Client) timeout(apiType string) time.Duration {
+	apiType = Canonical(apiType)
+	if d, ok := c.timeouts[apiType]; ok && d > 0 {
+		return d
+	}
+	if d, ok := DefaultTimeouts[apiType]; ok {
+		return d
+	}
+	return c.defaultTimeout
+}
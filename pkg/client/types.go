@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ID 消息ID、转账ID等标识，兼容 DLL 以字符串或数字返回
+type ID string
+
+// UnmarshalJSON 同时接受字符串与数字
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = ID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*id = ID(n.String())
+	return nil
+}
+
+// 列表获取方式
+const (
+	ListCached  = "1" // 从缓存获取
+	ListRefresh = "2" // 重新遍历
+)
+
+// SendTextRequest 发送文本消息请求
+type SendTextRequest struct {
+	Wxid string `json:"wxid"` // 接收人或群聊wxid
+	Msg  string `json:"msg"`  // 消息内容
+}
+
+// SendFileRequest 发送图片、文件、动图请求
+type SendFileRequest struct {
+	Wxid string `json:"wxid"` // 接收人或群聊wxid
+	Path string `json:"path"` // 本地文件路径
+}
+
+// ListRequest 联系人列表请求
+type ListRequest struct {
+	Type string `json:"type"` // 获取方式：1从缓存获取 2重新遍历
+}
+
+// WxidRequest 只需 wxid 的请求
+type WxidRequest struct {
+	Wxid string `json:"wxid"` // 好友、群聊或群成员wxid
+}
+
+// AgreeFriendReqRequest 同意好友请求
+type AgreeFriendReqRequest struct {
+	V3    string `json:"v3"`    // 好友请求事件中的 v3
+	V4    string `json:"v4"`    // 好友请求事件中的 v4
+	Scene string `json:"scene"` // 好友请求事件中的来源
+}
+
+// TransRequest 确认收款、退还转账请求
+type TransRequest struct {
+	Wxid       string `json:"wxid"`       // 转账人wxid
+	Transferid string `json:"transferid"` // 转账事件中的 transferid
+}
+
+// SendResult 发送消息结果
+type SendResult struct {
+	MsgId  ID `json:"msgId"`  // 消息ID
+	SendId ID `json:"sendId"` // 消息发送请求ID
+}
+
+// LoginStatus 登录状态
+type LoginStatus struct {
+	IsLogin int    `json:"isLogin"` // 是否已登录（1=是，0=否）
+	Wxid    string `json:"wxid"`    // 已登录时为当前账号的 wxid
+}
+
+// SelfInfo 当前登录账号信息
+type SelfInfo struct {
+	Wxid      string `json:"wxid"`      // 微信ID
+	WxNum     string `json:"wxNum"`     // 微信号
+	Nick      string `json:"nick"`      // 昵称
+	AvatarUrl string `json:"avatarUrl"` // 头像地址
+	Device    string `json:"device"`    // 登录设备
+	Phone     string `json:"phone"`     // 手机号
+	Country   string `json:"country"`   // 国家
+	Province  string `json:"province"`  // 省份
+	City      string `json:"city"`      // 城市
+	Sign      string `json:"sign"`      // 个性签名
+}
+
+// Contact 好友、群聊或公众号
+type Contact struct {
+	Wxid      string `json:"wxid"`      // 微信ID
+	WxNum     string `json:"wxNum"`     // 微信号
+	Nick      string `json:"nick"`      // 昵称
+	Remark    string `json:"remark"`    // 备注
+	AvatarUrl string `json:"avatarUrl"` // 头像地址
+	V3        string `json:"v3"`        // V3数据（查询陌生人时返回）
+	V4        string `json:"v4"`        // V4数据（查询陌生人时返回）
+}
+
+// Member 群成员
+type Member struct {
+	Wxid      string `json:"wxid"`      // 微信ID
+	Nick      string `json:"nick"`      // 昵称
+	GroupNick string `json:"groupNick"` // 群昵称
+}
@@ -4,13 +4,15 @@ import (
 	"context"
 	"sync"
 
-	"github.com/wailsapp/wails/v3/pkg/application"
+	"github.com/gogf/gf/v2/frame/g"
+
+	"github.com/naidog/wechat-framework/pkg/types"
 )
 
 // Service 日志服务
 type Service struct {
-	app *application.App
-	mu  sync.RWMutex
+	emitter types.Emitter
+	mu      sync.RWMutex
 }
 
 var (
@@ -18,18 +20,18 @@ var (
 	once             sync.Once
 )
 
-// NewService 创建日志服务实例
-func NewService(app *application.App) *Service {
+// NewService 创建日志服务实例，emitter 为 nil 时日志只写入本地日志
+func NewService(emitter types.Emitter) *Service {
 	return &Service{
-		app: app,
+		emitter: emitter,
 	}
 }
 
-// SetApp 设置应用实例
-func (s *Service) SetApp(app *application.App) {
+// SetEmitter 设置前端事件发送器
+func (s *Service) SetEmitter(emitter types.Emitter) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.app = app
+	s.emitter = emitter
 }
 
 // SendLog 发送日志到前端
@@ -42,7 +44,8 @@ func (s *Service) SendLog(ctx context.Context, timeStamp, response, logType, msg
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.app == nil {
+	if s.emitter == nil {
+		g.Log().Infof(ctx, "[%s] [%s] %s", response, logType, msg)
 		return
 	}
 
@@ -52,7 +55,7 @@ func (s *Service) SendLog(ctx context.Context, timeStamp, response, logType, msg
 	}
 
 	// 发送日志事件到前端
-	s.app.Event.Emit("log:message", map[string]interface{}{
+	s.emitter.Emit("log:message", map[string]interface{}{
 		"timeStamp": timeStamp,
 		"response":  response,
 		"logType":   logType,
@@ -0,0 +1,7 @@
+package types
+
+// Emitter 前端事件发送器
+// Wails 的 app.Event 满足该接口，无界面运行时传 nil 即不发送前端事件。
+type Emitter interface {
+	Emit(name string, data ...any)
+}
@@ -0,0 +1,137 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 回调事件类型
+const (
+	EventInjectSuccess      = "injectSuccess"      // 注入成功
+	EventLoginSuccess       = "loginSuccess"       // 登录成功
+	EventRecvMsg            = "recvMsg"            // 收到消息
+	EventTransPay           = "transPay"           // 转账事件
+	EventFriendReq          = "friendReq"          // 好友请求
+	EventGroupMemberChanges = "groupMemberChanges" // 群成员变动
+	EventAuthExpire         = "authExpire"         // 授权到期
+)
+
+// 框架事件类型，由框架发出，与回调事件一起推送给插件和 SSE 客户端
+const (
+	EventAccountsUpdate = "accountsUpdate" // 账号列表变化，数据为 {"list": [...]}
+	EventAccountState   = "account:state"  // 微信实例状态变化，数据为一次状态变化
+)
+
+// InjectSuccess 注入成功事件数据
+type InjectSuccess struct {
+	Port string `json:"port"` // 监听端口
+	Pid  string `json:"pid"`  // 进程PID
+}
+
+// LoginSuccess 登录成功事件数据
+type LoginSuccess struct {
+	Wxid      string `json:"wxid"`      // wxid
+	WxNum     string `json:"wxNum"`     // 微信号
+	Nick      string `json:"nick"`      // 微信昵称
+	Device    string `json:"device"`    // 登录设备
+	Phone     string `json:"phone"`     // 手机号
+	AvatarUrl string `json:"avatarUrl"` // 头像地址
+	Country   string `json:"country"`   // 国家
+	Province  string `json:"province"`  // 省
+	City      string `json:"city"`      // 城市
+	Email     string `json:"email"`     // 邮箱
+	QQ        string `json:"qq"`        // QQ
+	Sign      string `json:"sign"`      // 个性签名
+}
+
+// RecvMsg 收到消息事件数据
+type RecvMsg struct {
+	TimeStamp     string    `json:"timeStamp"`     // 13位时间戳
+	Time          time.Time `json:"time"`          // 解析后的消息时间
+	FromType      int       `json:"fromType"`      // 来源类型：1私聊 2群聊 3公众号
+	MsgType       int       `json:"msgType"`       // 消息类型
+	MsgSource     int       `json:"msgSource"`     // 消息来源：0别人发送 1自己发送
+	FromWxid      string    `json:"fromWxid"`      // 来源wxid
+	FinalFromWxid string    `json:"finalFromWxid"` // 群内发言人wxid
+	AtWxidList    []string  `json:"atWxidList"`    // 艾特人wxid列表
+	Silence       int       `json:"silence"`       // 消息免打扰：0未开启 1开启
+	Membercount   int       `json:"membercount"`   // 群成员数量
+	Signature     string    `json:"signature"`     // 消息签名
+	Msg           string    `json:"msg"`           // 消息内容
+	MsgId         string    `json:"msgId"`         // 消息ID
+	SendId        string    `json:"sendId"`        // 消息发送请求ID
+}
+
+// TransPay 转账事件数据
+type TransPay struct {
+	FromWxid      string    `json:"fromWxid"`      // 对方wxid
+	MsgSource     int       `json:"msgSource"`     // 1收到转账 2对方接收 3发出转账 4自己接收 5对方退还 6自己退还
+	TransType     int       `json:"transType"`     // 1即时到账 2延时到账
+	Money         string    `json:"money"`         // 金额(元)
+	Memo          string    `json:"memo"`          // 转账备注
+	Transferid    string    `json:"transferid"`    // 转账ID
+	Transcationid string    `json:"transcationid"` // 转账ID
+	Invalidtime   string    `json:"invalidtime"`   // 10位时间戳
+	InvalidAt     time.Time `json:"invalidAt"`     // 解析后的失效时间
+	MsgId         string    `json:"msgId"`         // 消息ID
+}
+
+// FriendReq 好友请求事件数据
+type FriendReq struct {
+	Wxid         string `json:"wxid"`         // 微信ID
+	WxNum        string `json:"wxNum"`        // 微信号
+	Nick         string `json:"nick"`         // 昵称
+	NickBrief    string `json:"nickBrief"`    // 昵称简拼
+	NickWhole    string `json:"nickWhole"`    // 昵称全拼
+	V3           string `json:"v3"`           // V3数据
+	V4           string `json:"v4"`           // V4数据
+	Sign         string `json:"sign"`         // 签名
+	Country      string `json:"country"`      // 国家
+	Province     string `json:"province"`     // 省份
+	City         string `json:"city"`         // 城市
+	AvatarMinUrl string `json:"avatarMinUrl"` // 头像小图
+	AvatarMaxUrl string `json:"avatarMaxUrl"` // 头像大图
+	Sex          string `json:"sex"`          // 性别：0未知 1男 2女
+	Content      string `json:"content"`      // 附言
+	Scene        string `json:"scene"`        // 来源
+	ShareWxid    string `json:"shareWxid"`    // 推荐人wxid
+	ShareNick    string `json:"shareNick"`    // 推荐人昵称
+	GroupWxid    string `json:"groupWxid"`    // 群聊wxid
+	MsgId        string `json:"msgId"`        // 消息ID
+}
+
+// GroupMemberChanges 群成员变动事件数据
+type GroupMemberChanges struct {
+	TimeStamp     string    `json:"timeStamp"`     // 13位时间戳
+	Time          time.Time `json:"time"`          // 解析后的变动时间
+	FromWxid      string    `json:"fromWxid"`      // 群wxid
+	FinalFromWxid string    `json:"finalFromWxid"` // 变动的群成员wxid
+	EventType     int       `json:"eventType"`     // 0退群 1进群
+	InviterWxid   string    `json:"inviterWxid"`   // 邀请人wxid(仅进群时有)
+}
+
+// AuthExpire 授权到期事件数据
+type AuthExpire struct {
+	Wxid       string `json:"wxid"`       // wxid
+	WxNum      string `json:"wxNum"`      // 微信号
+	ExpireTime string `json:"expireTime"` // 到期时间
+	Msg        string `json:"msg"`        // 提示消息
+}
+
+// ParseTimestamp 解析 DLL 回传的时间戳，支持13位毫秒和10位秒，无法解析时返回零值
+func ParseTimestamp(s string) time.Time {
+	s = strings.TrimSpace(s)
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}
+	}
+	switch len(s) {
+	case 13:
+		return time.UnixMilli(n)
+	case 10:
+		return time.Unix(n, 0)
+	default:
+		return time.Time{}
+	}
+}
@@ -37,21 +37,24 @@ type CallbackEvent struct {
 
 // PluginMetadata 插件元数据
 type PluginMetadata struct {
-	ID          string `json:"id"`          // 插件ID
-	Name        string `json:"name"`        // 插件名称
-	Version     string `json:"version"`     // 版本号
-	Author      string `json:"author"`      // 作者
-	Description string `json:"description"` // 描述
-	Icon        string `json:"icon"`        // 图标路径
-	Entry       string `json:"entry"`       // 入口文件
-	Type        string `json:"type"`        // 插件类型
+	ID          string   `json:"id"`                    // 插件ID
+	Name        string   `json:"name"`                  // 插件名称
+	Version     string   `json:"version"`               // 版本号
+	Author      string   `json:"author"`                // 作者
+	Description string   `json:"description"`           // 描述
+	Icon        string   `json:"icon"`                  // 图标路径
+	Entry       string   `json:"entry"`                 // 入口文件
+	Type        string   `json:"type"`                  // 插件类型
+	Permissions []string `json:"permissions,omitempty"` // 申请的权限，如 sendText、events:recvMsg
 }
 
 // PluginInfo 插件信息
 type PluginInfo struct {
-	Metadata PluginMetadata `json:"metadata"` // 元数据
-	Path     string         `json:"path"`     // 插件路径
-	Enabled  bool           `json:"enabled"`  // 是否启用
-	IconURL  string         `json:"iconUrl"`  // 图标URL
-	EntryURL string         `json:"entryUrl"` // 入口URL
+	Metadata    PluginMetadata `json:"metadata"`    // 元数据
+	Path        string         `json:"path"`        // 插件路径
+	Enabled     bool           `json:"enabled"`     // 是否启用
+	IconURL     string         `json:"iconUrl"`     // 图标URL
+	EntryURL    string         `json:"entryUrl"`    // 入口URL
+	Permissions []string       `json:"permissions"` // 实际申请的权限，未声明时为默认权限
+	Approved    bool           `json:"approved"`    // 权限是否已经用户授权
 }
@@ -0,0 +1,33 @@
+package access
+
+import (
+	"github.com/naidog/wechat-framework/internal/core/access"
+)
+
+// AccessService API 令牌管理服务
+type AccessService struct{}
+
+// 获取全部令牌，令牌值一律隐藏
+func (s *AccessService) ListTokens() []access.Token {
+	return access.Default().List()
+}
+
+// 签发令牌，同名令牌会被替换；令牌值只在签发时返回一次
+func (s *AccessService) IssueToken(name string, scopes []string) (access.Token, error) {
+	return access.Default().Issue(name, scopes)
+}
+
+// 吊销签发的令牌
+func (s *AccessService) RevokeToken(name string) error {
+	return access.Default().Revoke(name)
+}
+
+// 获取框架主界面使用的令牌
+func (s *AccessService) GetAppToken() string {
+	return access.Default().AppToken()
+}
+
+// 获取可分配的权限范围
+func (s *AccessService) GetScopes() []string {
+	return access.Scopes
+}
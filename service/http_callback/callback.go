@@ -1,23 +1,28 @@
 package http_callback
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"sync"
 	"time"
 
+	"github.com/naidog/wechat-framework/internal/config"
+	accountCore "github.com/naidog/wechat-framework/internal/core/account"
+	callbackCore "github.com/naidog/wechat-framework/internal/core/callback"
+	"github.com/naidog/wechat-framework/internal/core/dll"
+	"github.com/naidog/wechat-framework/internal/core/event"
+	"github.com/naidog/wechat-framework/internal/core/sse"
+	"github.com/naidog/wechat-framework/pkg/types"
 	"github.com/naidog/wechat-framework/service/utils"
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gctx"
 	"github.com/gogf/gf/v2/os/gfile"
-	"github.com/gogf/gf/v2/util/gconv"
 )
 
 // 全局插件服务引用
@@ -28,144 +33,74 @@ func SetPluginService(ps interface{}) {
 	pluginServiceInstance = ps
 }
 
-// SSE 客户端管理
-var (
-	sseClients      = make(map[chan string]bool)
-	sseClientsMutex sync.RWMutex
-)
+// SSE 事件中心，带序号与环形缓冲区，支持断线补发
+var sseHub = sse.NewHub(sse.LoadBufferSize(gctx.New()))
 
 // BroadcastEventToSSE 广播事件给所有 SSE 客户端
 func BroadcastEventToSSE(eventType string, eventData interface{}) {
-	sseClientsMutex.RLock()
-	defer sseClientsMutex.RUnlock()
-
-	// 将事件序列化为 JSON
-	data, err := json.Marshal(map[string]interface{}{
-		"type": eventType,
-		"data": eventData,
-	})
-	if err != nil {
-		g.Log().Errorf(nil, "SSE 事件序列化失败: %v", err)
-		return
-	}
-
-	message := fmt.Sprintf("data: %s\n\n", string(data))
-
-	// 广播给所有客户端
-	for client := range sseClients {
-		select {
-		case client <- message:
-		default:
-			// 客户端阻塞，跳过
-		}
+	if _, err := sseHub.Publish(eventType, eventData); err != nil {
+		g.Log().Errorf(nil, "%v", err)
 	}
 }
 
 type HttpCallbackService struct{}
 
-// 通用回调事件结构
-type CallbackEvent struct {
-	Type      string                 `json:"type"`      // 事件类型
-	Des       string                 `json:"des"`       // 事件描述
-	Data      map[string]interface{} `json:"data"`      // 事件数据
-	Timestamp string                 `json:"timestamp"` // 时间戳
-	Wxid      string                 `json:"wxid"`      // 微信ID
-	Port      int                    `json:"port"`      // 端口
-	Pid       int                    `json:"pid"`       // 进程ID
-	Flag      string                 `json:"flag"`      // 标识
-}
-
-// 注入成功事件数据
-type InjectSuccessData struct {
-	Port string `json:"port"` // 监听端口
-	Pid  string `json:"pid"`  // 进程PID
+// CallbackEvent 通用回调事件结构
+type CallbackEvent = types.CallbackEvent
+
+// 回调事件注册表
+var eventRegistry = newEventRegistry()
+
+// 回调事件管道，由 StartServer 创建并启动
+var eventPipeline *event.Pipeline
+
+// newEventRegistry 创建事件注册表并注册内置事件处理
+func newEventRegistry() *event.Registry {
+	s := &HttpCallbackService{}
+	registry := event.NewRegistry()
+	registry.OnAny(broadcastEvent)
+	registry.OnInjectSuccess(s.handleInjectSuccess)
+	registry.OnLoginSuccess(s.handleLoginSuccess)
+	registry.OnRecvMsg(s.handleRecvMsg)
+	registry.OnTransPay(s.handleTransPay)
+	registry.OnFriendReq(s.handleFriendReq)
+	registry.OnGroupMemberChanges(s.handleGroupMemberChanges)
+	registry.OnAuthExpire(s.handleAuthExpire)
+	return registry
 }
 
-// 登录成功事件数据
-type LoginSuccessData struct {
-	Wxid      string `json:"wxid"`      // wxid
-	WxNum     string `json:"wxNum"`     // 微信号
-	Nick      string `json:"nick"`      // 微信昵称
-	Device    string `json:"device"`    // 登录设备
-	Phone     string `json:"phone"`     // 手机号
-	AvatarUrl string `json:"avatarUrl"` // 头像地址
-	Country   string `json:"country"`   // 国家
-	Province  string `json:"province"`  // 省
-	City      string `json:"city"`      // 城市
-	Email     string `json:"email"`     // 邮箱
-	QQ        string `json:"qq"`        // QQ
-	Sign      string `json:"sign"`      // 个性签名
+// Events 获取回调事件注册表，用于订阅类型化事件或注册新的事件类型
+func Events() *event.Registry {
+	return eventRegistry
 }
 
-// 收到消息事件数据
-type RecvMsgData struct {
-	TimeStamp     string   `json:"timeStamp"`     // 13位时间戳
-	FromType      int      `json:"fromType"`      // 来源类型：1私聊 2群聊 3公众号
-	MsgType       int      `json:"msgType"`       // 消息类型
-	MsgSource     int      `json:"msgSource"`     // 消息来源：0别人发送 1自己发送
-	FromWxid      string   `json:"fromWxid"`      // 来源wxid
-	FinalFromWxid string   `json:"finalFromWxid"` // 群内发言人wxid
-	AtWxidList    []string `json:"atWxidList"`    // 艾特人wxid列表
-	Silence       int      `json:"silence"`       // 消息免打扰：0未开启 1开启
-	Membercount   int      `json:"membercount"`   // 群成员数量
-	Signature     string   `json:"signature"`     // 消息签名
-	Msg           string   `json:"msg"`           // 消息内容
-	MsgId         string   `json:"msgId"`         // 消息ID
-	SendId        string   `json:"sendId"`        // 消息发送请求ID
+// broadcastEvent 广播原始事件给插件窗口和 SSE 客户端
+func broadcastEvent(ctx context.Context, ev *event.Event) {
+	broadcast(ev.Type, *ev.Raw)
 }
 
-// 转账事件数据
-type TransPayData struct {
-	FromWxid      string `json:"fromWxid"`      // 对方wxid
-	MsgSource     int    `json:"msgSource"`     // 1收到转账 2对方接收 3发出转账 4自己接收 5对方退还 6自己退还
-	TransType     int    `json:"transType"`     // 1即时到账 2延时到账
-	Money         string `json:"money"`         // 金额(元)
-	Memo          string `json:"memo"`          // 转账备注
-	Transferid    string `json:"transferid"`    // 转账ID
-	Transcationid string `json:"transcationid"` // 转账ID
-	Invalidtime   string `json:"invalidtime"`   // 10位时间戳
-	MsgId         string `json:"msgId"`         // 消息ID
+// broadcastAccounts 账号列表变化时广播给插件和 SSE 客户端
+func broadcastAccounts(ctx context.Context, accounts []types.WechatAccount) {
+	broadcast(types.EventAccountsUpdate, types.WechatAccountList{List: accounts})
 }
 
-// 好友请求事件数据
-type FriendReqData struct {
-	Wxid         string `json:"wxid"`         // 微信ID
-	WxNum        string `json:"wxNum"`        // 微信号
-	Nick         string `json:"nick"`         // 昵称
-	NickBrief    string `json:"nickBrief"`    // 昵称简拼
-	NickWhole    string `json:"nickWhole"`    // 昵称全拼
-	V3           string `json:"v3"`           // V3数据
-	V4           string `json:"v4"`           // V4数据
-	Sign         string `json:"sign"`         // 签名
-	Country      string `json:"country"`      // 国家
-	Province     string `json:"province"`     // 省份
-	City         string `json:"city"`         // 城市
-	AvatarMinUrl string `json:"avatarMinUrl"` // 头像小图
-	AvatarMaxUrl string `json:"avatarMaxUrl"` // 头像大图
-	Sex          string `json:"sex"`          // 性别：0未知 1男 2女
-	Content      string `json:"content"`      // 附言
-	Scene        string `json:"scene"`        // 来源
-	ShareWxid    string `json:"shareWxid"`    // 推荐人wxid
-	ShareNick    string `json:"shareNick"`    // 推荐人昵称
-	GroupWxid    string `json:"groupWxid"`    // 群聊wxid
-	MsgId        string `json:"msgId"`        // 消息ID
+// broadcastAccountState 微信实例状态变化时广播给插件和 SSE 客户端
+func broadcastAccountState(ctx context.Context, t accountCore.Transition) {
+	broadcast(types.EventAccountState, t)
 }
 
-// 群成员变动事件数据
-type GroupMemberChangesData struct {
-	TimeStamp     string `json:"timeStamp"`     // 13位时间戳
-	FromWxid      string `json:"fromWxid"`      // 群wxid
-	FinalFromWxid string `json:"finalFromWxid"` // 变动的群成员wxid
-	EventType     int    `json:"eventType"`     // 0退群 1进群
-	InviterWxid   string `json:"inviterWxid"`   // 邀请人wxid(仅进群时有)
-}
+// broadcast 广播事件给所有插件（Wails 窗口）和 SSE 客户端（HTTP 插件）
+func broadcast(eventType string, eventData interface{}) {
+	if pluginServiceInstance != nil {
+		type PluginBroadcaster interface {
+			BroadcastEventToPlugins(eventType string, eventData interface{})
+		}
+		if ps, ok := pluginServiceInstance.(PluginBroadcaster); ok {
+			ps.BroadcastEventToPlugins(eventType, eventData)
+		}
+	}
 
-// 授权到期事件数据
-type AuthExpireData struct {
-	Wxid       string `json:"wxid"`       // wxid
-	WxNum      string `json:"wxNum"`      // 微信号
-	ExpireTime string `json:"expireTime"` // 到期时间
-	Msg        string `json:"msg"`        // 提示消息
+	BroadcastEventToSSE(eventType, eventData)
 }
 
 // 处理微信回调
@@ -174,9 +109,9 @@ func (s *HttpCallbackService) HandleCallback(r *ghttp.Request) {
 	body := r.GetBody()
 	g.Log().Debugf(r.Context(), "收到回调请求，原始数据: %s", string(body))
 
-	var event CallbackEvent
+	var raw CallbackEvent
 
-	if err := r.Parse(&event); err != nil {
+	if err := r.Parse(&raw); err != nil {
 		g.Log().Errorf(r.Context(), "解析回调事件失败: %v, 原始数据: %s", err, string(body))
 		r.Response.WriteJson(g.Map{
 			"code": 400,
@@ -185,41 +120,32 @@ func (s *HttpCallbackService) HandleCallback(r *ghttp.Request) {
 		return
 	}
 
-	g.Log().Infof(r.Context(), "收到回调事件 [%s]: %v", event.Type, event)
-	g.Log().Debugf(r.Context(), "事件类型: '%s', wxid: %s, port: %d, pid: %d", event.Type, event.Wxid, event.Port, event.Pid)
+	// 校验回调来源，拒绝伪造的事件
+	if err := callbackCore.DefaultGuard().Verify(r, &raw); err != nil {
+		r.Response.WriteHeader(http.StatusForbidden)
+		r.Response.WriteJson(g.Map{
+			"code": 403,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	g.Log().Infof(r.Context(), "收到回调事件 [%s]: %v", raw.Type, raw)
+	g.Log().Debugf(r.Context(), "事件类型: '%s', wxid: %s, port: %d, pid: %d", raw.Type, raw.Wxid, raw.Port, raw.Pid)
 
-	// 广播事件给所有插件（Wails 窗口）
-	if pluginServiceInstance != nil {
-		type PluginBroadcaster interface {
-			BroadcastEventToPlugins(eventType string, eventData interface{})
-		}
-		if ps, ok := pluginServiceInstance.(PluginBroadcaster); ok {
-			ps.BroadcastEventToPlugins(event.Type, event)
+	// 解码后放入事件管道异步处理，立即应答 DLL
+	ev, err := eventRegistry.Decode(&raw)
+	if err != nil {
+		if errors.Is(err, event.ErrUnknownType) {
+			g.Log().Warningf(r.Context(), "未知事件类型: '%s', 完整数据: %+v", raw.Type, raw)
+		} else {
+			g.Log().Errorf(r.Context(), "%v", err)
 		}
 	}
-
-	// 广播事件给所有 SSE 客户端（HTTP 插件）
-	BroadcastEventToSSE(event.Type, event)
-
-	// 根据事件类型处理
-	switch event.Type {
-	case "injectSuccess":
-		s.handleInjectSuccess(r, event)
-	case "loginSuccess":
-		s.handleLoginSuccess(r, event)
-	case "recvMsg":
-		s.handleRecvMsg(r, event)
-	case "transPay":
-		s.handleTransPay(r, event)
-	case "friendReq":
-		s.handleFriendReq(r, event)
-	case "groupMemberChanges":
-		g.Log().Info(r.Context(), "========== 进入 groupMemberChanges 处理 ==========")
-		s.handleGroupMemberChanges(r, event)
-	case "authExpire":
-		s.handleAuthExpire(r, event)
-	default:
-		g.Log().Warningf(r.Context(), "未知事件类型: '%s', 完整数据: %+v", event.Type, event)
+	if eventPipeline != nil {
+		eventPipeline.Submit(r.Context(), ev)
+	} else {
+		eventRegistry.Publish(r.Context(), ev)
 	}
 
 	r.Response.WriteJson(g.Map{
@@ -229,83 +155,80 @@ func (s *HttpCallbackService) HandleCallback(r *ghttp.Request) {
 }
 
 // 处理注入成功事件
-func (s *HttpCallbackService) handleInjectSuccess(r *ghttp.Request, event CallbackEvent) {
-
-	port := event.Data["port"]
-	pid := event.Data["pid"]
+func (s *HttpCallbackService) handleInjectSuccess(ctx context.Context, acct event.Account, data *types.InjectSuccess) {
 
 	// 获取全局日志服务
 	logService := utils.GetGlobalLogService()
 	if logService != nil {
 		logService.SendLog(
-			r.Context(),
+			ctx,
 			time.Now().Format("2006-01-02 15:04:05"),
 			"框架",
 			"启动",
-			fmt.Sprintf("微信多开 | 端口: %v | 进程: %v", port, pid),
+			fmt.Sprintf("微信多开 | 端口: %v | 进程: %v", data.Port, data.Pid),
 			"#3959CF",
 		)
 	}
 
+	port, pid := callbackCore.InjectedInstance(acct, data)
+	if err := accountCore.Default().Inject(ctx, port, pid); err != nil {
+		g.Log().Warningf(ctx, "登记微信实例失败: %v", err)
+	}
 }
 
 // 处理登录成功事件
-func (s *HttpCallbackService) handleLoginSuccess(r *ghttp.Request, event CallbackEvent) {
-	data := event.Data
+func (s *HttpCallbackService) handleLoginSuccess(ctx context.Context, acct event.Account, data *types.LoginSuccess) {
 	// 获取外层 wxid
-	wxid := event.Wxid
+	wxid := acct.Wxid
 
 	// 获取全局日志服务
 	logService := utils.GetGlobalLogService()
 	if logService != nil {
 		logService.SendLog(
-			r.Context(),
+			ctx,
 			time.Now().Format("2006-01-02 15:04:05"),
 			wxid,
 			"登录",
-			fmt.Sprintf("登录成功 | 微信号: %v", data["wxNum"]),
+			fmt.Sprintf("登录成功 | 微信号: %v", data.WxNum),
 			"#3959CF",
 		)
 	}
 
-	// 将 port 和 pid 添加到 data 中
-	data["port"] = float64(event.Port)
-	data["pid"] = float64(event.Pid)
+	account := WechatAccount{
+		Wxid:      data.Wxid,
+		WxNum:     data.WxNum,
+		Nick:      data.Nick,
+		AvatarUrl: data.AvatarUrl,
+		Port:      acct.Port,
+		Pid:       acct.Pid,
+	}
+
+	// 只查询当前登录账号的授权信息，不再全量检查
+	if authInfo, ok := s.queryAuthInfo(ctx, acct.Port); ok {
+		account.ExpireTime = authInfo.ExpireTime
+		account.IsExpire = authInfo.IsExpire
+	}
 
 	// 保存微信账号信息
-	if err := s.saveWechatAccount(r.Context(), data); err != nil {
-		g.Log().Warningf(r.Context(), "保存微信账号信息失败: %v", err)
+	if err := s.saveWechatAccount(ctx, account); err != nil {
+		g.Log().Warningf(ctx, "保存微信账号信息失败: %v", err)
 	}
 
-	time.Sleep(1 * time.Second) // 延迟 2 秒，确保账号信息已保存
-	s.CheckAndUpdateAuthInfo(r.Context())
-
 }
 
 // 处理接收消息事件
-func (s *HttpCallbackService) handleRecvMsg(r *ghttp.Request, event CallbackEvent) {
-	data := event.Data
-
+func (s *HttpCallbackService) handleRecvMsg(ctx context.Context, acct event.Account, data *types.RecvMsg) {
 	// 获取接收消息的账号 wxid（外层）
-	receiverWxid := event.Wxid
+	receiverWxid := acct.Wxid
 
-	// 使用 gconv 进行类型转换，支持所有类型
-	fromType := gconv.Int(data["fromType"])
-	msgType := gconv.Int(data["msgType"])
+	g.Log().Debugf(ctx, "接收账号: %s, fromType=%d, msgType=%d", receiverWxid, data.FromType, data.MsgType)
 
-	g.Log().Debugf(r.Context(), "接收账号: %s, fromType=%d, msgType=%d", receiverWxid, fromType, msgType)
-
-	var fromWxid, msg string
-	if v, ok := data["fromWxid"].(string); ok {
-		fromWxid = v
-	}
-	if v, ok := data["msg"].(string); ok {
-		msg = v
-	}
+	fromWxid := data.FromWxid
+	msg := data.Msg
 
 	// 判断消息来源
 	var sourceDesc string
-	switch fromType {
+	switch data.FromType {
 	case 1:
 		sourceDesc = "私聊"
 	case 2:
@@ -313,22 +236,12 @@ func (s *HttpCallbackService) handleRecvMsg(r *ghttp.Request, event CallbackEven
 	case 3:
 		sourceDesc = "公众号"
 	default:
-		sourceDesc = fmt.Sprintf("未知", fromType)
+		sourceDesc = "未知"
 	}
 
-	// 判断消息发送者 暂时用不到
-	//var senderDesc string
-	//if msgSource == 0 {
-	//	senderDesc = "别人发送"
-	//} else if msgSource == 1 {
-	//	senderDesc = "自己发送"
-	//} else {
-	//	senderDesc = "未知来源"
-	//}
-
 	// 判断消息类型
 	var msgTypeDesc string
-	switch msgType {
+	switch data.MsgType {
 	case 1:
 		msgTypeDesc = "文本"
 	case 3:
@@ -354,10 +267,10 @@ func (s *HttpCallbackService) handleRecvMsg(r *ghttp.Request, event CallbackEven
 	case 10000:
 		msgTypeDesc = "系统消息"
 	default:
-		msgTypeDesc = fmt.Sprintf("未知")
+		msgTypeDesc = "未知"
 	}
 
-	g.Log().Infof(r.Context(),
+	g.Log().Infof(ctx,
 		"接收账号: %s | 发送人: %v | 来源：%v | %v消息: %v",
 		receiverWxid, fromWxid, sourceDesc, msgTypeDesc, msg)
 
@@ -366,7 +279,7 @@ func (s *HttpCallbackService) handleRecvMsg(r *ghttp.Request, event CallbackEven
 	if logService != nil {
 
 		logService.SendLog(
-			r.Context(),
+			ctx,
 			time.Now().Format("2006-01-02 15:04:05"),
 			receiverWxid,
 			sourceDesc,
@@ -375,42 +288,17 @@ func (s *HttpCallbackService) handleRecvMsg(r *ghttp.Request, event CallbackEven
 		)
 	}
 
-	// 群聊消息特殊处理 暂时不需要
-	//if fromType == 2 {
-	//	if finalFromWxid, ok := data["finalFromWxid"].(string); ok {
-	//		g.Log().Infof(r.Context(), "  群内发言人: %v", finalFromWxid)
-	//	}
-	//
-	//	// 处理@消息
-	//	if atList, ok := data["atWxidList"].([]interface{}); ok && len(atList) > 0 {
-	//		g.Log().Infof(r.Context(), "  @了: %v", atList)
-	//	}
-	//}
-
 }
 
 // 处理转账事件
-func (s *HttpCallbackService) handleTransPay(r *ghttp.Request, event CallbackEvent) {
-	data := event.Data
+func (s *HttpCallbackService) handleTransPay(ctx context.Context, acct event.Account, data *types.TransPay) {
 	// 获取外层 wxid
-	wxid := event.Wxid
-
-	var fromWxid, money, memo string
-
-	// 使用 gconv 进行类型转换
-	msgSource := gconv.Int(data["msgSource"])
+	wxid := acct.Wxid
 
-	if v, ok := data["fromWxid"].(string); ok {
-		fromWxid = v
-	}
-	if v, ok := data["money"].(string); ok {
-		money = v
-	}
-	if v, ok := data["memo"].(string); ok {
-		memo = v
-	}
+	fromWxid, money, memo := data.FromWxid, data.Money, data.Memo
+	msgSource := data.MsgSource
 
-	g.Log().Debugf(r.Context(), "转账事件 msgSource=%d", msgSource)
+	g.Log().Debugf(ctx, "转账事件 msgSource=%d", msgSource)
 
 	var sourceDesc string
 	switch msgSource {
@@ -428,7 +316,7 @@ func (s *HttpCallbackService) handleTransPay(r *ghttp.Request, event CallbackEve
 		sourceDesc = "自己退还"
 	}
 
-	g.Log().Infof(r.Context(),
+	g.Log().Infof(ctx,
 		"[转账事件] 账号: %s | %s | 对方: %v | 金额: %v元 | 备注: %v",
 		wxid, sourceDesc, fromWxid, money, memo,
 	)
@@ -438,7 +326,7 @@ func (s *HttpCallbackService) handleTransPay(r *ghttp.Request, event CallbackEve
 	if logService != nil {
 		// 根据转账类型设置颜色
 		var color string
-		g.Log().Debugf(r.Context(), "前端日志 msgSource=%d", msgSource)
+		g.Log().Debugf(ctx, "前端日志 msgSource=%d", msgSource)
 		switch msgSource {
 		case 1, 4: // 收到转账、自己接收转账
 			color = "#3959CF"
@@ -455,7 +343,7 @@ func (s *HttpCallbackService) handleTransPay(r *ghttp.Request, event CallbackEve
 		}
 
 		logService.SendLog(
-			r.Context(),
+			ctx,
 			time.Now().Format("2006-01-02 15:04:05"),
 			wxid,
 			"转账",
@@ -468,37 +356,14 @@ func (s *HttpCallbackService) handleTransPay(r *ghttp.Request, event CallbackEve
 }
 
 // 处理好友请求事件
-func (s *HttpCallbackService) handleFriendReq(r *ghttp.Request, event CallbackEvent) {
-	data := event.Data
+func (s *HttpCallbackService) handleFriendReq(ctx context.Context, acct event.Account, data *types.FriendReq) {
 	// 获取外层 wxid（接收请求的账号）
-	receiverWxid := event.Wxid
+	receiverWxid := acct.Wxid
 
-	var wxid, wxNum, nick, content, scene, v3, v4 string
-
-	if v, ok := data["wxid"].(string); ok {
-		wxid = v
-	}
-	if v, ok := data["wxNum"].(string); ok {
-		wxNum = v
-	}
-	if v, ok := data["nick"].(string); ok {
-		nick = v
-	}
-	if v, ok := data["content"].(string); ok {
-		content = v
-	}
-	if v, ok := data["scene"].(string); ok {
-		scene = v
-	}
-	if v, ok := data["v3"].(string); ok {
-		v3 = v
-	}
-	if v, ok := data["v4"].(string); ok {
-		v4 = v
-	}
+	wxid, wxNum, nick, content := data.Wxid, data.WxNum, data.Nick, data.Content
 
 	var sceneDesc string
-	switch scene {
+	switch data.Scene {
 	case "1":
 		sceneDesc = "QQ"
 	case "3":
@@ -519,18 +384,18 @@ func (s *HttpCallbackService) handleFriendReq(r *ghttp.Request, event CallbackEv
 		sceneDesc = "未知"
 	}
 
-	g.Log().Infof(r.Context(),
+	g.Log().Infof(ctx,
 		"[好友请求] 接收账号: %s | 请求人 wxid: %v | 微信号: %v | 昵称: %v | 附言: %v | 来源: %s",
 		receiverWxid, wxid, wxNum, nick, content, sceneDesc,
 	)
-	g.Log().Debugf(r.Context(), "  V3: %v", v3)
-	g.Log().Debugf(r.Context(), "  V4: %v", v4)
+	g.Log().Debugf(ctx, "  V3: %v", data.V3)
+	g.Log().Debugf(ctx, "  V4: %v", data.V4)
 
 	// 发送日志到前端
 	logService := utils.GetGlobalLogService()
 	if logService != nil {
 		logService.SendLog(
-			r.Context(),
+			ctx,
 			time.Now().Format("2006-01-02 15:04:05"),
 			receiverWxid,
 			"好友请求",
@@ -542,28 +407,15 @@ func (s *HttpCallbackService) handleFriendReq(r *ghttp.Request, event CallbackEv
 }
 
 // 处理群成员变动事件
-func (s *HttpCallbackService) handleGroupMemberChanges(r *ghttp.Request, event CallbackEvent) {
+func (s *HttpCallbackService) handleGroupMemberChanges(ctx context.Context, acct event.Account, data *types.GroupMemberChanges) {
 
-	data := event.Data
 	// 获取外层 wxid
-	wxid := event.Wxid
-
-	var fromWxid, finalFromWxid, inviterWxid string
+	wxid := acct.Wxid
 
-	// 使用 gconv 进行类型转换
-	eventType := gconv.Int(data["eventType"])
+	fromWxid, finalFromWxid, inviterWxid := data.FromWxid, data.FinalFromWxid, data.InviterWxid
+	eventType := data.EventType
 
-	if v, ok := data["fromWxid"].(string); ok {
-		fromWxid = v
-	}
-	if v, ok := data["finalFromWxid"].(string); ok {
-		finalFromWxid = v
-	}
-	if v, ok := data["inviterWxid"].(string); ok {
-		inviterWxid = v
-	}
-
-	g.Log().Debugf(r.Context(), "群成员变动: eventType=%d, fromWxid=%s, finalFromWxid=%s, inviterWxid=%s",
+	g.Log().Debugf(ctx, "群成员变动: eventType=%d, fromWxid=%s, finalFromWxid=%s, inviterWxid=%s",
 		eventType, fromWxid, finalFromWxid, inviterWxid)
 
 	var eventDesc string
@@ -574,12 +426,12 @@ func (s *HttpCallbackService) handleGroupMemberChanges(r *ghttp.Request, event C
 	}
 
 	if inviterWxid != "" {
-		g.Log().Infof(r.Context(),
+		g.Log().Infof(ctx,
 			"[群成员变动] 账号: %s | %s | 群: %v | 成员: %v | 邀请人: %v",
 			wxid, eventDesc, fromWxid, finalFromWxid, inviterWxid,
 		)
 	} else {
-		g.Log().Infof(r.Context(),
+		g.Log().Infof(ctx,
 			"[群成员变动] 账号: %s | %s | 群: %v | 成员: %v",
 			wxid, eventDesc, fromWxid, finalFromWxid,
 		)
@@ -603,7 +455,7 @@ func (s *HttpCallbackService) handleGroupMemberChanges(r *ghttp.Request, event C
 		}
 
 		logService.SendLog(
-			r.Context(),
+			ctx,
 			time.Now().Format("2006-01-02 15:04:05"),
 			wxid,
 			"群变动",
@@ -615,21 +467,13 @@ func (s *HttpCallbackService) handleGroupMemberChanges(r *ghttp.Request, event C
 }
 
 // 处理授权到期事件
-func (s *HttpCallbackService) handleAuthExpire(r *ghttp.Request, event CallbackEvent) {
-	data := event.Data
+func (s *HttpCallbackService) handleAuthExpire(ctx context.Context, acct event.Account, data *types.AuthExpire) {
 	// 获取外层 wxid
-	wxid := event.Wxid
+	wxid := acct.Wxid
 
-	var expireTime, msg string
+	expireTime, msg := data.ExpireTime, data.Msg
 
-	if v, ok := data["expireTime"].(string); ok {
-		expireTime = v
-	}
-	if v, ok := data["msg"].(string); ok {
-		msg = v
-	}
-
-	g.Log().Warningf(r.Context(),
+	g.Log().Warningf(ctx,
 		"[授权到期] wxid: %v, 到期时间: %v, 消息: %v",
 		wxid, expireTime, msg,
 	)
@@ -637,7 +481,7 @@ func (s *HttpCallbackService) handleAuthExpire(r *ghttp.Request, event CallbackE
 	logService := utils.GetGlobalLogService()
 	if logService != nil {
 		logService.SendLog(
-			r.Context(),
+			ctx,
 			time.Now().Format("2006-01-02 15:04:05"),
 			wxid,
 			"授权",
@@ -645,6 +489,10 @@ func (s *HttpCallbackService) handleAuthExpire(r *ghttp.Request, event CallbackE
 			"#F5222D",
 		)
 	}
+
+	if err := accountCore.Default().Expire(ctx, acct.Port, expireTime, "收到授权到期事件"); err != nil {
+		g.Log().Warningf(ctx, "更新账号授权信息失败: %v", err)
+	}
 }
 
 // WechatAccount 微信账号信息
@@ -659,183 +507,29 @@ type WechatAccount struct {
 	IsExpire   int    `json:"isExpire"`             // 是否已到期（1=是，0=否）
 }
 
-// WechatAccountList 微信账号列表
-type WechatAccountList struct {
-	List []WechatAccount `json:"list"`
-}
-
-var (
-	wechatAccountFile = "resources/currentWechat.json"
-	fileMutex         sync.Mutex
-)
-
-// saveWechatAccount 保存微信账号信息到 currentWechat.json
-func (s *HttpCallbackService) saveWechatAccount(ctx context.Context, data map[string]interface{}) error {
-	fileMutex.Lock()
-	defer fileMutex.Unlock()
-
-	// 提取账号信息
-	var account WechatAccount
-
-	if v, ok := data["wxid"].(string); ok {
-		account.Wxid = v
-	}
-	if v, ok := data["wxNum"].(string); ok {
-		account.WxNum = v
-	}
-	if v, ok := data["nick"].(string); ok {
-		account.Nick = v
-	}
-	if v, ok := data["avatarUrl"].(string); ok {
-		account.AvatarUrl = v
-	}
-	if v, ok := data["port"].(float64); ok {
-		account.Port = int(v)
-	}
-	if v, ok := data["pid"].(float64); ok {
-		account.Pid = int(v)
-	}
-
-	// wxid 为必须字段
-	if account.Wxid == "" {
-		return fmt.Errorf("wxid 为空")
-	}
-
-	// 读取现有数据
-	var accountList WechatAccountList
-
-	// 确保 resource 目录存在
-	resourceDir := filepath.Dir(wechatAccountFile)
-	if !gfile.Exists(resourceDir) {
-		if err := gfile.Mkdir(resourceDir); err != nil {
-			return fmt.Errorf("创建 resource 目录失败: %v", err)
-		}
-	}
-
-	// 如果文件存在，读取现有数据
-	if gfile.Exists(wechatAccountFile) {
-		fileData, err := os.ReadFile(wechatAccountFile)
-		if err != nil {
-			return fmt.Errorf("读取文件失败: %v", err)
-		}
-
-		if len(fileData) > 0 {
-			if err := json.Unmarshal(fileData, &accountList); err != nil {
-				return fmt.Errorf("解析 JSON 失败: %v", err)
-			}
-		}
-	}
-
-	// 检查是否已存在该 wxid
-	found := false
-	for i, acc := range accountList.List {
-		if acc.Wxid == account.Wxid {
-			// 更新现有记录
-			accountList.List[i] = account
-			found = true
-			g.Log().Debugf(ctx, "更新微信账号: %s", account.Wxid)
-			break
-		}
-	}
-
-	// 如果不存在，追加新记录
-	if !found {
-		accountList.List = append(accountList.List, account)
-		g.Log().Infof(ctx, "新增微信账号: %s", account.Wxid)
-	}
-
-	// 序列化为 JSON
-	jsonData, err := json.MarshalIndent(accountList, "", "  ")
-	if err != nil {
-		return fmt.Errorf("序列化 JSON 失败: %v", err)
-	}
-
-	// 写入文件
-	if err := os.WriteFile(wechatAccountFile, jsonData, 0644); err != nil {
-		return fmt.Errorf("写入文件失败: %v", err)
+// saveWechatAccount 登记微信账号，账号注册表负责保存并推送账号变化
+func (s *HttpCallbackService) saveWechatAccount(ctx context.Context, account WechatAccount) error {
+	if err := accountCore.Default().Login(ctx, types.WechatAccount(account)); err != nil {
+		return err
 	}
-
-	g.Log().Debugf(ctx, "微信账号信息已保存到: %s", wechatAccountFile)
+	g.Log().Debugf(ctx, "微信账号已登记: %s", account.Wxid)
 	return nil
 }
 
 // CheckAndUpdateAuthInfo 检查并更新所有微信账号的授权信息
 func (s *HttpCallbackService) CheckAndUpdateAuthInfo(ctx context.Context) {
-	g.Log().Info(ctx, "开始检查微信账号授权信息...")
-
-	// 读取 currentWechat.json
-	if !gfile.Exists(wechatAccountFile) {
-		g.Log().Debug(ctx, "currentWechat.json 文件不存在，跳过检查")
-		return
-	}
-
-	fileData, err := os.ReadFile(wechatAccountFile)
-	if err != nil {
-		g.Log().Warningf(ctx, "读取 currentWechat.json 失败: %v", err)
-		return
-	}
-
-	var accountList WechatAccountList
-	if len(fileData) > 0 {
-		if err := json.Unmarshal(fileData, &accountList); err != nil {
-			g.Log().Warningf(ctx, "解析 currentWechat.json 失败: %v", err)
-			return
-		}
-	}
-
-	if len(accountList.List) == 0 {
+	registry := accountCore.Default()
+	if len(registry.List()) == 0 {
 		g.Log().Debug(ctx, "没有微信账号需要检查")
 		return
 	}
 
-	g.Log().Infof(ctx, "发现 %d 个微信账号，开始并发检查授权信息...", len(accountList.List))
-
-	// 并发检查每个账号
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	updatedAccounts := make([]WechatAccount, 0)
-
-	for _, account := range accountList.List {
-		wg.Add(1)
-		go func(acc WechatAccount) {
-			defer wg.Done()
-
-			// 查询授权信息
-			if authInfo, ok := s.queryAuthInfo(ctx, acc.Port); ok {
-				// 更新授权信息
-				acc.ExpireTime = authInfo.ExpireTime
-				acc.IsExpire = authInfo.IsExpire
-
-				mu.Lock()
-				updatedAccounts = append(updatedAccounts, acc)
-				mu.Unlock()
-
-				g.Log().Infof(ctx, "✓ %s (端口:%d) 授权信息更新成功，到期时间: %s", acc.Wxid, acc.Port, authInfo.ExpireTime)
-			} else {
-				g.Log().Warningf(ctx, "✗ %s (端口:%d) 请求失败，已从列表中移除", acc.Wxid, acc.Port)
-			}
-		}(account)
-	}
-
-	wg.Wait()
-
-	// 保存更新后的列表
-	fileMutex.Lock()
-	defer fileMutex.Unlock()
-
-	accountList.List = updatedAccounts
-	jsonData, err := json.MarshalIndent(accountList, "", "  ")
-	if err != nil {
-		g.Log().Errorf(ctx, "序列化 JSON 失败: %v", err)
-		return
-	}
+	g.Log().Infof(ctx, "发现 %d 个微信账号，开始并发检查授权信息...", len(registry.List()))
 
-	if err := os.WriteFile(wechatAccountFile, jsonData, 0644); err != nil {
-		g.Log().Errorf(ctx, "写入文件失败: %v", err)
-		return
-	}
+	// 请求失败但连续失败次数未达到阈值的账号标记为无响应，交给心跳检测
+	accountCore.DefaultHeartbeat().Check(ctx, true)
 
-	g.Log().Infof(ctx, "授权信息检查完成，剩余 %d 个有效账号", len(updatedAccounts))
+	g.Log().Infof(ctx, "授权信息检查完成，剩余 %d 个有效账号", len(registry.List()))
 }
 
 // AuthInfoResult 授权信息结果
@@ -844,71 +538,24 @@ type AuthInfoResult struct {
 	IsExpire   int    `json:"isExpire"`
 }
 
-// AuthInfoResponse 授权信息响应
-type AuthInfoResponse struct {
-	Code   int            `json:"code"`
-	Msg    string         `json:"msg"`
-	Result AuthInfoResult `json:"result"`
-}
-
 // queryAuthInfo 查询单个微信的授权信息
 func (s *HttpCallbackService) queryAuthInfo(ctx context.Context, port int) (*AuthInfoResult, bool) {
-	url := fmt.Sprintf("http://127.0.0.1:%d/wechat/httpapi", port)
-
-	// 构造请求体
-	requestBody := map[string]string{
-		"type": "getAuthInfo",
-	}
-	jsonData, _ := json.Marshal(requestBody)
-
-	// 创建 HTTP 请求
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		g.Log().Debugf(ctx, "创建请求失败 (port:%d): %v", port, err)
-		return nil, false
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		g.Log().Debugf(ctx, "请求失败 (port:%d): %v", port, err)
-		return nil, false
-	}
-	defer resp.Body.Close()
-
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
+	info, err := dll.Client().GetAuthInfo(ctx, port)
 	if err != nil {
-		g.Log().Debugf(ctx, "读取响应失败 (port:%d): %v", port, err)
-		return nil, false
-	}
-
-	// 解析响应
-	var authResp AuthInfoResponse
-	if err := json.Unmarshal(body, &authResp); err != nil {
-		g.Log().Debugf(ctx, "解析响应失败 (port:%d): %v", port, err)
-		return nil, false
-	}
-
-	if authResp.Code != 200 {
-		g.Log().Debugf(ctx, "请求失败 (port:%d): code=%d, msg=%s", port, authResp.Code, authResp.Msg)
+		g.Log().Debugf(ctx, "查询授权信息失败 (port:%d): %v", port, err)
 		return nil, false
 	}
-
-	return &authResp.Result, true
+	return &AuthInfoResult{
+		ExpireTime: info.ExpireTime,
+		IsExpire:   info.IsExpire,
+	}, true
 }
 
 // ============ 插件 HTTP API ============
 
 type PluginAPIService struct{}
 
-// GetConfig 获取 config.yaml
+// GetConfig 获取 config.yaml，令牌与密钥已隐藏
 func (s *PluginAPIService) GetConfig(r *ghttp.Request) {
 	configPath := "configs/config.yaml"
 	if !gfile.Exists(configPath) {
@@ -919,38 +566,41 @@ func (s *PluginAPIService) GetConfig(r *ghttp.Request) {
 		return
 	}
 
-	content := gfile.GetContents(configPath)
+	content, err := config.Redact(gfile.GetBytes(configPath))
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
 	r.Response.WriteJsonExit(g.Map{
 		"code": 200,
 		"data": content,
 	})
 }
 
-// GetCurrentWechat 获取 currentWechat.json
+// GetCurrentWechat 获取当前已登录的微信账号列表
 func (s *PluginAPIService) GetCurrentWechat(r *ghttp.Request) {
-	currentWechatPath := "resources/currentWechat.json"
-	if !gfile.Exists(currentWechatPath) {
-		r.Response.WriteJsonExit(g.Map{
-			"code": 404,
-			"msg":  "当前微信账号文件不存在",
-		})
-		return
-	}
+	r.Response.WriteJsonExit(g.Map{
+		"code": 200,
+		"data": types.WechatAccountList{List: accountCore.Default().List()},
+	})
+}
 
-	content := gfile.GetContents(currentWechatPath)
-	// 尝试解析为 JSON
-	var jsonData interface{}
-	if err := json.Unmarshal([]byte(content), &jsonData); err != nil {
-		r.Response.WriteJsonExit(g.Map{
-			"code": 500,
-			"msg":  "JSON 解析失败",
-		})
-		return
-	}
+// GetWechatStates 获取各微信实例的生命周期状态与最近的状态变化
+func (s *PluginAPIService) GetWechatStates(r *ghttp.Request) {
+	r.Response.WriteJsonExit(g.Map{
+		"code": 200,
+		"data": accountCore.Default().Instances(),
+	})
+}
 
+// GetWechatHealth 获取各微信实例的心跳检测统计
+func (s *PluginAPIService) GetWechatHealth(r *ghttp.Request) {
 	r.Response.WriteJsonExit(g.Map{
 		"code": 200,
-		"data": jsonData,
+		"data": accountCore.DefaultHeartbeat().Health(),
 	})
 }
 
@@ -1104,52 +754,25 @@ func (s *PluginAPIService) UploadPlugin(r *ghttp.Request) {
 	})
 }
 
-// EventStream SSE 事件流接口
-func (s *PluginAPIService) EventStream(r *ghttp.Request) {
-	// 设置 SSE 响应头
-	r.Response.Header().Set("Content-Type", "text/event-stream")
-	r.Response.Header().Set("Cache-Control", "no-cache")
-	r.Response.Header().Set("Connection", "keep-alive")
-	r.Response.Header().Set("Access-Control-Allow-Origin", "*")
-
-	// 创建客户端通道
-	clientChan := make(chan string, 10)
-
-	// 注册客户端
-	sseClientsMutex.Lock()
-	sseClients[clientChan] = true
-	sseClientsMutex.Unlock()
-
-	g.Log().Infof(r.Context(), "SSE 客户端已连接，当前总数: %d", len(sseClients))
-
-	// 发送连接成功消息
-	r.Response.Write("data: {\"type\":\"connected\",\"msg\":\"连接成功\"}\n\n")
-	r.Response.Flush()
-
-	// 监听客户端断开
-	notify := r.Context().Done()
-
-	// 保持连接
-	for {
-		select {
-		case <-notify:
-			// 客户端断开
-			sseClientsMutex.Lock()
-			delete(sseClients, clientChan)
-			sseClientsMutex.Unlock()
-			close(clientChan)
-			g.Log().Infof(r.Context(), "SSE 客户端已断开，当前总数: %d", len(sseClients))
-			return
-
-		case msg := <-clientChan:
-			// 发送消息给客户端
-			r.Response.Write(msg)
-			r.Response.Flush()
-
-		case <-time.After(30 * time.Second):
-			// 心跳，保持连接
-			r.Response.Write(": heartbeat\n\n")
-			r.Response.Flush()
-		}
+// GetMetrics 获取事件管道、SSE、回调校验、微信接口调用耗时与熔断统计信息
+func (s *PluginAPIService) GetMetrics(r *ghttp.Request) {
+	var stats event.PipelineStats
+	if eventPipeline != nil {
+		stats = eventPipeline.Stats()
 	}
+	r.Response.WriteJsonExit(g.Map{
+		"code": 200,
+		"data": g.Map{
+			"events":   stats,
+			"sse":      sseHub.Stats(),
+			"callback": callbackCore.DefaultGuard().Stats(),
+			"dll":      dll.Stats(),
+			"breakers": dll.Breakers(),
+		},
+	})
+}
+
+// EventStream SSE 事件流接口，支持通过 Last-Event-ID 补发断线期间的事件
+func (s *PluginAPIService) EventStream(r *ghttp.Request) {
+	sseHub.Serve(r)
 }
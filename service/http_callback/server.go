@@ -5,6 +5,19 @@ import (
 	"context"
 	"fmt"
 
+	messageAPI "github.com/naidog/wechat-framework/internal/api/message"
+	processAPI "github.com/naidog/wechat-framework/internal/api/process"
+	webhookAPI "github.com/naidog/wechat-framework/internal/api/webhook"
+	"github.com/naidog/wechat-framework/internal/core/access"
+	accountCore "github.com/naidog/wechat-framework/internal/core/account"
+	callbackCore "github.com/naidog/wechat-framework/internal/core/callback"
+	"github.com/naidog/wechat-framework/internal/core/event"
+	"github.com/naidog/wechat-framework/internal/core/message"
+	"github.com/naidog/wechat-framework/internal/core/ports"
+	"github.com/naidog/wechat-framework/internal/core/supervisor"
+	"github.com/naidog/wechat-framework/internal/core/webhook"
+	"github.com/naidog/wechat-framework/internal/core/ws"
+	"github.com/naidog/wechat-framework/pkg/client"
 	"github.com/naidog/wechat-framework/service/wechat_api"
 
 	"github.com/gogf/gf/v2/frame/g"
@@ -13,7 +26,9 @@ import (
 )
 
 type HttpServerService struct {
-	server *ghttp.Server
+	server       *ghttp.Server
+	messageStore *message.Store
+	webhooks     *webhook.Dispatcher
 }
 
 // StartServer 启动HTTP服务
@@ -33,8 +48,40 @@ func (s *HttpServerService) StartServer() error {
 	// 设置最大上传文件大小为 100MB
 	s.server.SetClientMaxBodySize(100 * 1024 * 1024)
 
-	// 配置 CORS 跨域支持
-	s.server.Use(ghttp.MiddlewareCORS)
+	// 访问控制：CORS 来源、IP 白名单与 API 令牌，微信回调和插件静态文件免令牌
+	accessManager := access.Default()
+	accessManager.Exempt("/wechat/callback", "/plugins/")
+	s.server.Use(accessManager.Middleware)
+
+	// 启动回调事件管道
+	eventPipeline = event.NewPipeline(eventRegistry, event.LoadPipelineConfig(ctx))
+	eventPipeline.Start()
+
+	// 打开消息存储，记录收发的消息
+	store, err := message.Open(message.LoadStorePath(ctx))
+	if err != nil {
+		g.Log().Errorf(ctx, "消息存储打开失败，历史消息功能不可用: %v", err)
+	} else {
+		s.messageStore = store
+		store.Attach(eventRegistry)
+	}
+
+	// 微信API代理，HTTP 接口与 WebSocket 命令共用
+	wechatProxy := wechat_api.NewProxy(s.messageStore)
+
+	// 回调实例校验以账号注册表中的账号为已登记实例，账号变化时推送给插件和 SSE 客户端
+	accounts := accountCore.Default()
+	callbackCore.DefaultGuard().SetAccounts(accounts.GetAccounts)
+	accounts.Subscribe(broadcastAccounts)
+	accounts.SubscribeState(broadcastAccountState)
+
+	// 启动 webhook 投递
+	s.webhooks, err = webhook.New(webhook.LoadConfig(ctx))
+	if err != nil {
+		return fmt.Errorf("webhook 配置错误: %v", err)
+	}
+	s.webhooks.Attach(eventRegistry)
+	s.webhooks.Start()
 
 	// 注册回调路由
 	callbackService := &HttpCallbackService{}
@@ -44,72 +91,46 @@ func (s *HttpServerService) StartServer() error {
 	pluginAPIService := &PluginAPIService{}
 	s.server.BindHandler("/api/plugin/config", pluginAPIService.GetConfig)
 	s.server.BindHandler("/api/plugin/wechat", pluginAPIService.GetCurrentWechat)
+	s.server.BindHandler("GET:/api/plugin/wechat/states", pluginAPIService.GetWechatStates)
+	s.server.BindHandler("GET:/api/plugin/wechat/health", pluginAPIService.GetWechatHealth)
 	s.server.BindHandler("/api/plugin/log", pluginAPIService.SendLog)
 	s.server.BindHandler("/api/plugin/events", pluginAPIService.EventStream)
 	s.server.BindHandler("/api/plugin/upload", pluginAPIService.UploadPlugin)
+	s.server.BindHandler("/api/plugin/metrics", pluginAPIService.GetMetrics)
+
+	// 注册 WebSocket 事件与命令接口
+	wsServer := ws.NewServer(sseHub, wechatProxy)
+	s.server.BindHandler("/api/plugin/ws", wsServer.Serve)
+
 	g.Log().Info(ctx, "插件 API 服务已启用（包括 SSE 事件流和文件上传）")
 
+	// 注册消息历史路由
+	messageAPI := messageAPI.NewAPI(s.messageStore)
+	s.server.BindHandler("GET:/api/messages", messageAPI.Query)
+
+	// 注册 webhook 管理路由
+	webhookAPI := webhookAPI.NewAPI(s.webhooks)
+	s.server.BindHandler("GET:/api/webhooks", webhookAPI.List)
+	s.server.BindHandler("GET:/api/webhooks/deadletter", webhookAPI.DeadLetters)
+	s.server.BindHandler("POST:/api/webhooks/deadletter/redrive", webhookAPI.Redrive)
+
+	// 注册微信进程管理路由
+	processAPI := processAPI.NewAPI(supervisor.Default(), ports.Default())
+	s.server.BindHandler("GET:/api/processes", processAPI.List)
+	s.server.BindHandler("GET:/api/processes/ports", processAPI.Ports)
+	s.server.BindHandler("POST:/api/processes/stop", processAPI.Stop)
+	s.server.BindHandler("POST:/api/processes/restart", processAPI.Restart)
+
 	// 注册插件静态文件服务
 	s.server.AddStaticPath("/plugins", "plugins")
 	g.Log().Info(ctx, "插件静态文件服务已启用: /plugins -> plugins/")
 
-	// 注册微信API代理路由
-	wechatAPI := &wechat_api.WechatAPIProxyService{}
-	// 基础接口
-	s.server.BindHandler("/api/wechat/editVersion", wechatAPI.EditVersion)
-	s.server.BindHandler("/api/wechat/getLoginStatus", wechatAPI.GetLoginStatus)
-	s.server.BindHandler("/api/wechat/getLoginQRCode", wechatAPI.GetLoginQRCode)
-	s.server.BindHandler("/api/wechat/setDownloadImage", wechatAPI.SetDownloadImage)
-	s.server.BindHandler("/api/wechat/decryptImage", wechatAPI.DecryptImage)
-	s.server.BindHandler("/api/wechat/checkWeChat", wechatAPI.CheckWeChat)
-	s.server.BindHandler("/api/wechat/getAuthInfo", wechatAPI.GetAuthInfo)
-	// 消息发送接口
-	s.server.BindHandler("/api/wechat/sendText", wechatAPI.SendText)
-	s.server.BindHandler("/api/wechat/sendText2", wechatAPI.SendText2)
-	s.server.BindHandler("/api/wechat/sendReferText", wechatAPI.SendReferText)
-	s.server.BindHandler("/api/wechat/sendImage", wechatAPI.SendImage)
-	s.server.BindHandler("/api/wechat/sendFile", wechatAPI.SendFile)
-	s.server.BindHandler("/api/wechat/sendGif", wechatAPI.SendGif)
-	s.server.BindHandler("/api/wechat/sendShareUrl", wechatAPI.SendShareUrl)
-	s.server.BindHandler("/api/wechat/sendApplet", wechatAPI.SendApplet)
-	s.server.BindHandler("/api/wechat/sendMusic", wechatAPI.SendMusic)
-	s.server.BindHandler("/api/wechat/sendChatLog", wechatAPI.SendChatLog)
-	s.server.BindHandler("/api/wechat/sendCard", wechatAPI.SendCard)
-	s.server.BindHandler("/api/wechat/sendXml", wechatAPI.SendXml)
-	s.server.BindHandler("/api/wechat/sendLocationInfo", wechatAPI.SendLocationInfo)
-	// 信息获取接口
-	s.server.BindHandler("/api/wechat/getSelfInfo", wechatAPI.GetSelfInfo)
-	s.server.BindHandler("/api/wechat/getLabelList", wechatAPI.GetLabelList)
-	s.server.BindHandler("/api/wechat/getFriendList", wechatAPI.GetFriendList)
-	s.server.BindHandler("/api/wechat/getGroupList", wechatAPI.GetGroupList)
-	s.server.BindHandler("/api/wechat/getPublicList", wechatAPI.GetPublicList)
-	// 好友管理接口
-	s.server.BindHandler("/api/wechat/agreeFriendReq", wechatAPI.AgreeFriendReq)
-	s.server.BindHandler("/api/wechat/addFriendByV3", wechatAPI.AddFriendByV3)
-	s.server.BindHandler("/api/wechat/addFriendByGroupWxid", wechatAPI.AddFriendByGroupWxid)
-	s.server.BindHandler("/api/wechat/delFriend", wechatAPI.DelFriend)
-	s.server.BindHandler("/api/wechat/editObjRemark", wechatAPI.EditObjRemark)
-	s.server.BindHandler("/api/wechat/queryNewFriend", wechatAPI.QueryNewFriend)
-	s.server.BindHandler("/api/wechat/queryObj", wechatAPI.QueryObj)
-	// 群聊管理接口
-	s.server.BindHandler("/api/wechat/quitGroup", wechatAPI.QuitGroup)
-	s.server.BindHandler("/api/wechat/createGroup", wechatAPI.CreateGroup)
-	s.server.BindHandler("/api/wechat/queryGroup", wechatAPI.QueryGroup)
-	s.server.BindHandler("/api/wechat/addMembers", wechatAPI.AddMembers)
-	s.server.BindHandler("/api/wechat/inviteMembers", wechatAPI.InviteMembers)
-	s.server.BindHandler("/api/wechat/delMembers", wechatAPI.DelMembers)
-	s.server.BindHandler("/api/wechat/getMemberList", wechatAPI.GetMemberList)
-	s.server.BindHandler("/api/wechat/getMemberNick", wechatAPI.GetMemberNick)
-	s.server.BindHandler("/api/wechat/editSelfMemberNick", wechatAPI.EditSelfMemberNick)
-	// 转账与其他接口
-	s.server.BindHandler("/api/wechat/confirmTrans", wechatAPI.ConfirmTrans)
-	s.server.BindHandler("/api/wechat/returnTrans", wechatAPI.ReturnTrans)
-	s.server.BindHandler("/api/wechat/openBrowser", wechatAPI.OpenBrowser)
-	s.server.BindHandler("/api/wechat/runCloudFunction", wechatAPI.RunCloudFunction)
-	s.server.BindHandler("/api/wechat/setReadStatus", wechatAPI.SetReadStatus)
-	s.server.BindHandler("/api/wechat/authCami", wechatAPI.AuthCami)
-	s.server.BindHandler("/api/wechat/revokeMyMsg", wechatAPI.RevokeMyMsg)
-	g.Log().Info(ctx, "微信API代理服务已启用: 47个接口")
+	// 注册微信API代理路由，接口名与别名见 /api/wechat/_routes
+	s.server.BindHandler("GET:/api/wechat/_routes", wechatProxy.Routes)
+	s.server.BindHandler("POST:/api/wechat/_batch", wechatProxy.Batch)
+	s.server.BindHandler("GET:/api/wechat/_batch/{id}", wechatProxy.BatchStatus)
+	s.server.BindHandler("/api/wechat/{type}", wechatProxy.Handle)
+	g.Log().Infof(ctx, "微信API代理服务已启用: %d个接口", len(client.Routes))
 
 	// 启动服务
 	go func() {
@@ -128,7 +149,18 @@ func (s *HttpServerService) StartServer() error {
 // StopServer 停止HTTP服务
 func (s *HttpServerService) StopServer(ctx context.Context) error {
 	if s.server != nil {
-		return s.server.Shutdown()
+		if err := s.server.Shutdown(); err != nil {
+			return err
+		}
+	}
+	if eventPipeline != nil {
+		eventPipeline.Stop()
+	}
+	if s.webhooks != nil {
+		s.webhooks.Stop()
+	}
+	if s.messageStore != nil {
+		return s.messageStore.Close()
 	}
 	return nil
 }
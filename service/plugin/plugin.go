@@ -14,26 +14,33 @@ import (
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/naidog/wechat-framework/internal/config"
+	"github.com/naidog/wechat-framework/internal/core/access"
+	"github.com/naidog/wechat-framework/internal/core/account"
+	"github.com/naidog/wechat-framework/pkg/types"
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
 type PluginMetadata struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Version     string `json:"version"`
-	Author      string `json:"author"`
-	Description string `json:"description"`
-	Icon        string `json:"icon"`
-	Entry       string `json:"entry"`
-	Type        string `json:"type"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Author      string   `json:"author"`
+	Description string   `json:"description"`
+	Icon        string   `json:"icon"`
+	Entry       string   `json:"entry"`
+	Type        string   `json:"type"`
+	Permissions []string `json:"permissions,omitempty"` // 申请的权限，如 sendText、events:recvMsg
 }
 
 type PluginInfo struct {
-	Metadata PluginMetadata `json:"metadata"`
-	Path     string         `json:"path"`
-	Enabled  bool           `json:"enabled"`
-	IconURL  string         `json:"iconUrl"`
-	EntryURL string         `json:"entryUrl"`
+	Metadata    PluginMetadata `json:"metadata"`
+	Path        string         `json:"path"`
+	Enabled     bool           `json:"enabled"`
+	IconURL     string         `json:"iconUrl"`
+	EntryURL    string         `json:"entryUrl"`
+	Permissions []string       `json:"permissions"` // 实际申请的权限，未声明时为默认权限
+	Approved    bool           `json:"approved"`    // 权限是否已经用户授权
 }
 
 type PluginService struct {
@@ -132,12 +139,15 @@ func (s *PluginService) scanPluginsFromDisk() ([]PluginInfo, error) {
 			iconURL = fmt.Sprintf("http://localhost:9001/plugins/%s/%s", entry.Name(), metadata.Icon)
 		}
 
+		permissions := access.PluginPermissions(metadata.Permissions)
 		pluginInfo := PluginInfo{
-			Metadata: metadata,
-			Path:     pluginPath,
-			Enabled:  true,
-			IconURL:  iconURL,
-			EntryURL: fmt.Sprintf("/plugins/%s/%s", entry.Name(), metadata.Entry),
+			Metadata:    metadata,
+			Path:        pluginPath,
+			Enabled:     true,
+			IconURL:     iconURL,
+			EntryURL:    fmt.Sprintf("/plugins/%s/%s", entry.Name(), metadata.Entry),
+			Permissions: permissions,
+			Approved:    access.Default().Approved(metadata.ID, permissions),
 		}
 
 		plugins = append(plugins, pluginInfo)
@@ -185,6 +195,12 @@ func (s *PluginService) OpenPlugin(ctx context.Context, pluginID string) error {
 	}
 	s.windowMutex.Unlock()
 
+	// 签发插件令牌，插件通过 URL 中的 token 参数调用接口
+	token, err := access.Default().IssuePlugin(pluginID, targetPlugin.Permissions)
+	if err != nil {
+		return err
+	}
+
 	// 构建插件 URL
 	pluginURL := fmt.Sprintf("http://localhost:9001%s", targetPlugin.EntryURL)
 
@@ -193,7 +209,7 @@ func (s *PluginService) OpenPlugin(ctx context.Context, pluginID string) error {
 		Title:               targetPlugin.Metadata.Name,
 		Width:               520,
 		Height:              380,
-		URL:                 pluginURL,
+		URL:                 pluginURL + "?" + access.TokenParam + "=" + token.Token,
 		MaximiseButtonState: application.ButtonDisabled, // 禁止最大化
 		DevToolsEnabled:     false,                      // 禁用开发者工具
 		Mac: application.MacWindow{
@@ -205,6 +221,7 @@ func (s *PluginService) OpenPlugin(ctx context.Context, pluginID string) error {
 	})
 
 	if window == nil {
+		access.Default().RevokePlugin(pluginID, false)
 		return fmt.Errorf("创建窗口失败")
 	}
 
@@ -220,6 +237,36 @@ func (s *PluginService) OpenPlugin(ctx context.Context, pluginID string) error {
 	return nil
 }
 
+// ApprovePlugin 授权插件申请的权限
+func (s *PluginService) ApprovePlugin(ctx context.Context, pluginID string) error {
+	plugins, err := s.ScanPlugins()
+	if err != nil {
+		return err
+	}
+
+	for _, plugin := range plugins {
+		if plugin.Metadata.ID != pluginID {
+			continue
+		}
+		if err := access.Default().Approve(pluginID, plugin.Permissions); err != nil {
+			return fmt.Errorf("保存插件授权失败: %v", err)
+		}
+
+		// 更新缓存中的授权状态
+		s.cacheMutex.Lock()
+		for i := range s.pluginCache {
+			if s.pluginCache[i].Metadata.ID == pluginID {
+				s.pluginCache[i].Approved = true
+			}
+		}
+		s.cacheMutex.Unlock()
+
+		g.Log().Infof(ctx, "已授权插件 %s 的权限: %v", plugin.Metadata.Name, plugin.Permissions)
+		return nil
+	}
+	return fmt.Errorf("插件不存在: %s", pluginID)
+}
+
 // ClosePlugin 关闭插件窗口并清理引用
 func (s *PluginService) ClosePlugin(ctx context.Context, pluginID string) error {
 	s.windowMutex.Lock()
@@ -232,6 +279,8 @@ func (s *PluginService) ClosePlugin(ctx context.Context, pluginID string) error
 	}
 	s.windowMutex.Unlock()
 
+	access.Default().RevokePlugin(pluginID, false)
+
 	if !exists {
 		return fmt.Errorf("插件未打开: %s", pluginID)
 	}
@@ -347,24 +396,22 @@ func unzip(src, dest string) error {
 	return nil
 }
 
-// GetConfigYaml 获取 config.yaml 文件内容
+// GetConfigYaml 获取 config.yaml 文件内容，令牌与密钥已隐藏
 func (s *PluginService) GetConfigYaml() (string, error) {
 	configPath := "configs/config.yaml"
 	if !gfile.Exists(configPath) {
 		return "", fmt.Errorf("配置文件不存在")
 	}
-	content := gfile.GetContents(configPath)
-	return content, nil
+	return config.Redact(gfile.GetBytes(configPath))
 }
 
-// GetCurrentWechat 获取 currentWechat.json 文件内容
+// GetCurrentWechat 获取当前已登录的微信账号列表（JSON，格式同 currentWechat.json）
 func (s *PluginService) GetCurrentWechat() (string, error) {
-	currentWechatPath := "resources/currentWechat.json"
-	if !gfile.Exists(currentWechatPath) {
-		return "", fmt.Errorf("当前微信账号文件不存在")
+	data, err := json.MarshalIndent(types.WechatAccountList{List: account.Default().List()}, "", "  ")
+	if err != nil {
+		return "", err
 	}
-	content := gfile.GetContents(currentWechatPath)
-	return content, nil
+	return string(data), nil
 }
 
 // SendPluginLog 插件发送日志到主程序
@@ -392,6 +439,10 @@ func (s *PluginService) BroadcastEventToPlugins(eventType string, eventData inte
 	defer s.windowMutex.RUnlock()
 
 	for pluginID, window := range s.pluginWindows {
+		// 只向有该事件权限的插件广播
+		if token, ok := access.Default().PluginToken(pluginID); ok && !token.AllowEvent(eventType) {
+			continue
+		}
 		if window != nil {
 			// 向插件窗口发送事件
 			window.EmitEvent("wechat:event", map[string]interface{}{
@@ -466,6 +517,11 @@ func (s *PluginService) UninstallPlugin(ctx context.Context, pluginID string) er
 	s.pluginCache = newCache
 	s.cacheMutex.Unlock()
 
+	// 吊销令牌并清除授权，重新安装时需再次授权
+	if err := access.Default().RevokePlugin(pluginID, true); err != nil {
+		g.Log().Warningf(ctx, "清除插件授权失败 %s: %v", pluginID, err)
+	}
+
 	g.Log().Infof(ctx, "插件已卸载: %s", pluginID)
 	return nil
 }
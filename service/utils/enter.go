@@ -6,7 +6,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"syscall"
 
 	"github.com/naidog/wechat-framework/service/config"
 )
@@ -18,7 +17,7 @@ type NoupdateWechatService struct{}
 // 获取微信安装目录
 func GetWechatInstallPath() (string, error) {
 	cmd := exec.Command("cmd", "/c", "reg", "query", "HKEY_CURRENT_USER\\Software\\Tencent\\Weixin", "/v", "InstallPath")
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	hideWindow(cmd)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -103,11 +102,11 @@ func (n *NoupdateWechatService) NoupdateWechat() {
 	if res["update"] == "1" {
 		// 拒绝所有人访问
 		cmd := exec.Command("icacls", "C:\\Users\\Administrator\\AppData\\Roaming\\Tencent\\xwechat\\update\\download", "/deny", "Everyone:F")
-		cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+		hideWindow(cmd)
 
 		_, err := cmd.CombinedOutput()
 		if err != nil {
-			fmt.Errorf("执行禁止自动更新失败: %v", err)
+			fmt.Println("执行禁止自动更新失败:", err)
 			return
 		}
 
@@ -117,11 +116,11 @@ func (n *NoupdateWechatService) NoupdateWechat() {
 
 	// 移除拒绝规则
 	cmd := exec.Command("icacls", "C:\\Users\\Administrator\\AppData\\Roaming\\Tencent\\xwechat\\update\\download", "/remove:d", "Everyone")
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	hideWindow(cmd)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		fmt.Errorf("执行关闭微信自动更新失败: %v, 输出: %s", err, string(output))
+		fmt.Println("执行关闭微信自动更新失败:", err, "输出:", string(output))
 		return
 	}
 
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package utils
+
+import "os/exec"
+
+// hideWindow 非 Windows 平台无控制台窗口，无需处理
+func hideWindow(cmd *exec.Cmd) {}
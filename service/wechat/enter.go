@@ -1,21 +1,23 @@
+//go:build windows
+
 package wechat
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 	"syscall"
-	"time"
 	"unsafe"
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gctx"
 	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/naidog/wechat-framework/internal/core/callback"
+	"github.com/naidog/wechat-framework/internal/core/ports"
+	"github.com/naidog/wechat-framework/internal/core/supervisor"
 	"golang.org/x/sys/windows"
 )
 
@@ -89,17 +91,17 @@ func (w *WeChatService) createAndDenyMutex(ctx context.Context, mutexName string
 
 	const aclSize = 4096
 	aclBuffer := make([]byte, aclSize)
-	pAcl := uintptr(unsafe.Pointer(&aclBuffer[0]))
+	acl := (*windows.ACL)(unsafe.Pointer(&aclBuffer[0]))
 
 	const ACL_REVISION = 2
-	ret, _, err := procInitializeAcl.Call(pAcl, uintptr(aclSize), uintptr(ACL_REVISION))
+	ret, _, err := procInitializeAcl.Call(uintptr(unsafe.Pointer(acl)), uintptr(aclSize), uintptr(ACL_REVISION))
 	if ret == 0 {
 		return fmt.Errorf("初始化 ACL 失败: %v", err)
 	}
 
 	const MUTEX_ALL_ACCESS = 0x1F0001
 	ret, _, err = procAddAccessDeniedAce.Call(
-		pAcl,
+		uintptr(unsafe.Pointer(acl)),
 		uintptr(ACL_REVISION),
 		uintptr(MUTEX_ALL_ACCESS),
 		uintptr(unsafe.Pointer(pEveryoneSID)),
@@ -114,7 +116,7 @@ func (w *WeChatService) createAndDenyMutex(ctx context.Context, mutexName string
 		windows.DACL_SECURITY_INFORMATION,
 		nil,
 		nil,
-		(*windows.ACL)(unsafe.Pointer(pAcl)),
+		acl,
 		nil,
 	)
 	if err != nil {
@@ -125,9 +127,39 @@ func (w *WeChatService) createAndDenyMutex(ctx context.Context, mutexName string
 	return nil
 }
 
+// RunWechat 在端口范围内分配空闲端口启动微信，进程交由 supervisor 托管，退出后按重启策略自动重启
 func (w *WeChatService) RunWechat() (bool, error) {
-	ctx := gctx.New()
+	return w.launch(gctx.New(), "")
+}
+
+// RunWechatAccount 为已登录过的账号启动微信，优先使用该账号上次登录时的端口
+func (w *WeChatService) RunWechatAccount(wxid string) (bool, error) {
+	return w.launch(gctx.New(), wxid)
+}
+
+// launch 分配端口并启动微信
+func (w *WeChatService) launch(ctx context.Context, wxid string) (bool, error) {
+	allocator := ports.Default()
+	port, err := allocator.Allocate(wxid)
+	if err != nil {
+		return false, err
+	}
 
+	cachePath, _ := g.Cfg().Get(ctx, "wechat.cachePath")
+	spec := supervisor.Spec{
+		Port:      port,
+		CachePath: cachePath.String(),
+	}
+
+	if _, err := supervisor.Default().Launch(ctx, spec, w.startWechat); err != nil {
+		allocator.Release(port)
+		return false, err
+	}
+	return true, nil
+}
+
+// startWechat 按启动参数写入 config.json 并启动微信，自动重启时使用相同的端口与缓存目录
+func (w *WeChatService) startWechat(ctx context.Context, spec supervisor.Spec) (*exec.Cmd, error) {
 	if err := w.EnableMultiWeChat(ctx); err != nil {
 		g.Log().Warningf(ctx, "解除多开限制失败: %v", err)
 
@@ -135,10 +167,9 @@ func (w *WeChatService) RunWechat() (bool, error) {
 
 	installPath, err := g.Cfg().Get(ctx, "wechat.installationPath")
 	if err != nil || installPath.String() == "" {
-		return false, fmt.Errorf("获取微信安装路径失败")
+		return nil, fmt.Errorf("获取微信安装路径失败")
 	}
 
-	cachePath, _ := g.Cfg().Get(ctx, "wechat.cachePath")
 	timeOut, _ := g.Cfg().Get(ctx, "wechat.timeOut")
 	decodePict, _ := g.Cfg().Get(ctx, "wechat.decodePict")
 	ignoreMsg, _ := g.Cfg().Get(ctx, "wechat.ignoreMsg")
@@ -148,42 +179,43 @@ func (w *WeChatService) RunWechat() (bool, error) {
 
 	resourceDir := "resources"
 	if !gfile.Exists(resourceDir) {
-		return false, fmt.Errorf("resources 目录不存在")
+		return nil, fmt.Errorf("resources 目录不存在")
 	}
 
 	versionDllSrc := filepath.Join(resourceDir, "version.dll")
 	versionDllDst := filepath.Join(installPath.String(), "version.dll")
 
 	if !gfile.Exists(versionDllSrc) {
-		return false, fmt.Errorf("version.dll 文件不存在: %s", versionDllSrc)
+		return nil, fmt.Errorf("version.dll 文件不存在: %s", versionDllSrc)
 	}
 
 	if !gfile.Exists(versionDllDst) {
 		err = gfile.CopyFile(versionDllSrc, versionDllDst)
 		if err != nil {
-			return false, fmt.Errorf("复制 version.dll 失败: %v", err)
+			return nil, fmt.Errorf("复制 version.dll 失败: %v", err)
 		}
 		g.Log().Info(ctx, "version.dll 已复制到:", versionDllDst)
 	} else {
 		g.Log().Info(ctx, "version.dll 已存在，跳过复制:", versionDllDst)
 	}
 
-	randomPort := rand.Intn(56536) + 9000
-
 	dllRelPath := filepath.Join(resourceDir, "4.1.2.17.dll")
 	dllAbsPath, err := filepath.Abs(dllRelPath)
 	if err != nil {
-		return false, fmt.Errorf("获取 dll 绝对路径失败: %v", err)
+		return nil, fmt.Errorf("获取 dll 绝对路径失败: %v", err)
 	}
 
 	if !gfile.Exists(dllAbsPath) {
-		return false, fmt.Errorf("4.1.2.17.dll 文件不存在: %s", dllAbsPath)
+		return nil, fmt.Errorf("4.1.2.17.dll 文件不存在: %s", dllAbsPath)
 	}
 
+	// 回调地址附带共享密钥，框架据此校验回调来源
+	guard := callback.DefaultGuard()
+
 	config := ConfigJSON{
-		CallBackUrl:      "http://127.0.0.1:9001/wechat/callback",
-		Port:             fmt.Sprintf("%d", randomPort),
-		CacheData:        cachePath.String(),
+		CallBackUrl:      guard.CallbackURL("http://127.0.0.1:9001/wechat/callback"),
+		Port:             fmt.Sprintf("%d", spec.Port),
+		CacheData:        spec.CachePath,
 		TimeOut:          timeOut.String(),
 		AutoLogin:        "0",
 		Ver:              "",
@@ -197,48 +229,30 @@ func (w *WeChatService) RunWechat() (bool, error) {
 
 	jsonData, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
-		return false, fmt.Errorf("生成 JSON 失败: %v", err)
+		return nil, fmt.Errorf("生成 JSON 失败: %v", err)
 	}
 
 	configJsonDst := filepath.Join(installPath.String(), "config.json")
 	err = os.WriteFile(configJsonDst, jsonData, 0644)
 	if err != nil {
-		return false, fmt.Errorf("写入 config.json 失败: %v", err)
+		return nil, fmt.Errorf("写入 config.json 失败: %v", err)
 	}
 
 	g.Log().Info(ctx, "成功配置微信")
 	g.Log().Infof(ctx, "- config.json 已更新到: %s", configJsonDst)
-	g.Log().Infof(ctx, "- 随机端口: %d", randomPort)
+	g.Log().Infof(ctx, "- 端口: %d", spec.Port)
 
 	wechatExe := filepath.Join(installPath.String(), "Weixin.exe")
 	if !gfile.Exists(wechatExe) {
-		return false, fmt.Errorf("微信程序不存在: %s", wechatExe)
+		return nil, fmt.Errorf("微信程序不存在: %s", wechatExe)
 	}
 
 	cmd := exec.Command(wechatExe)
 	cmd.Dir = installPath.String() // 设置工作目录为微信安装目录
 	// 注意：不要设置 HideWindow，否则微信窗口会被隐藏
 
-	err = cmd.Start()
-	if err != nil {
-		return false, fmt.Errorf("启动微信失败: %v", err)
-	}
-
-	pid := cmd.Process.Pid
-	g.Log().Infof(ctx, "- 微信进程已启动，PID: %d", pid)
-
-	// 等待2秒后检查进程是否还存活
-	time.Sleep(2 * time.Second)
-
-	// 使用 tasklist 命令检查进程是否还在运行
-	checkCmd := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH")
-	checkCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-	output, err := checkCmd.Output()
-	if err != nil || len(output) == 0 || !strings.Contains(string(output), "Weixin.exe") {
-		return false, fmt.Errorf("微信进程 PID %d 已退出，可能是配置错误、权限问题或DLL文件损坏", pid)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动微信失败: %v", err)
 	}
-
-	g.Log().Infof(ctx, "- 微信进程运行正常，PID: %d", pid)
-
-	return true, nil
+	return cmd, nil
 }
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package wechat
+
+import (
+	"context"
+	"errors"
+)
+
+// 启动与多开微信依赖 Windows API，其他平台仅保留同名方法以便编译
+var errUnsupported = errors.New("启动微信仅支持 Windows")
+
+type WeChatService struct{}
+
+// 解除微信多开限制
+func (w *WeChatService) EnableMultiWeChat(ctx context.Context) error {
+	return errUnsupported
+}
+
+func (w *WeChatService) RunWechat() (bool, error) {
+	return false, errUnsupported
+}
+
+func (w *WeChatService) RunWechatAccount(wxid string) (bool, error) {
+	return false, errUnsupported
+}
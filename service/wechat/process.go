@@ -0,0 +1,24 @@
+package wechat
+
+import (
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/naidog/wechat-framework/internal/core/supervisor"
+)
+
+// GetProcesses 获取框架启动的微信进程及其启动与退出记录
+func (w *WeChatService) GetProcesses() []supervisor.Process {
+	return supervisor.Default().Processes()
+}
+
+// StopWechat 结束端口上的微信进程，结束后不再自动重启
+func (w *WeChatService) StopWechat(port int) error {
+	return supervisor.Default().Stop(gctx.New(), port)
+}
+
+// RestartWechat 用相同的端口与缓存目录重新启动端口上的微信进程
+func (w *WeChatService) RestartWechat(port int) (bool, error) {
+	if _, err := supervisor.Default().Restart(gctx.New(), port); err != nil {
+		return false, err
+	}
+	return true, nil
+}